@@ -0,0 +1,704 @@
+package main
+
+import (
+	"encoding/json"
+	"eu-clams/internal/analysis"
+	"eu-clams/internal/config"
+	"eu-clams/internal/export"
+	"eu-clams/internal/model"
+	"eu-clams/internal/stats"
+	"eu-clams/internal/storage"
+	"eu-clams/src/service"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// command is a subcommand given after the global flags, e.g. "eu-clams -config c.yaml edit ..."
+type command struct {
+	usage string
+	run   func(cfg config.Config, args []string) error
+}
+
+// commands lists the available subcommands by name
+var commands = map[string]command{
+	"edit": {
+		usage: "edit (-id <id> | -at <time> [-match <text>]) [-player <name>] [-team <name>] [-target <name>] [-type <kill|craft|find>] [-value <ped>] [-location <name>] [-hof <true|false>]",
+		run:   runEditCommand,
+	},
+	"delete": {
+		usage: "delete (-id <id> | -at <time> [-match <text>])",
+		run:   runDeleteCommand,
+	},
+	"timeseries": {
+		usage: "timeseries [-interval <day|week|month>] [-from <date>] [-to <date>] [-by-type]",
+		run:   runTimeSeriesCommand,
+	},
+	"sessions": {
+		usage: "sessions [-id <id>] [-rebuild <chat log>]",
+		run:   runSessionsCommand,
+	},
+	"leaderboard": {
+		usage: "leaderboard [-interval <day|week|month>] [-period <period>] [-periods <n>] [-limit <n>] [-rebuild <chat log>]",
+		run:   runLeaderboardCommand,
+	},
+	"compare": {
+		usage: "compare [-preset <day|week|month>] [-a-from <date>] [-a-to <date>] [-a-player <name>] [-a-team <name>] [-b-from <date>] [-b-to <date>] [-b-player <name>] [-b-team <name>] [-json]",
+		run:   runCompareCommand,
+	},
+	"goals": {
+		usage: "goals [-add -metric <globals|hofs|value> -threshold <n> [-name <name>] [-type <type>] [-target <name>] [-location <name>] [-period <day|week|month>]] [-remove <id>]",
+		run:   runGoalsCommand,
+	},
+	"records": {
+		usage: "records [-rebuild]",
+		run:   runRecordsCommand,
+	},
+	"markups": {
+		usage: "markups [-import <csv file>] [-set <item> -markup <125%|+5>] [-remove <item>] [-limit <n>]",
+		run:   runMarkupsCommand,
+	},
+	"export": {
+		usage: "export [-format <csv|json|ndjson|html>] [-o <file>] [-columns <list>] [-delimiter <char|tab|semicolon>] [-from <date>] [-to <date>] [-type <type>] [-target <name>] [-location <name>] [-min-value <ped>] [-max-value <ped>] [-tier <global|hof>] [-sort <field>] [-order <asc|desc>]",
+		run:   runExportCommand,
+	},
+	"theme": {
+		usage: "theme -export <dir>",
+		run:   runThemeCommand,
+	},
+	"tokens": {
+		usage: "tokens [-add <name> [-scope <read|member|admin>]] [-remove <name>]",
+		run:   runTokensCommand,
+	},
+}
+
+// runCommand runs the named subcommand and exits
+func runCommand(cfg config.Config, args []string) {
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Printf("Unknown command: %s\n\nAvailable commands:\n", args[0])
+		for _, c := range commands {
+			fmt.Printf("  %s\n", c.usage)
+		}
+		os.Exit(2)
+	}
+
+	if err := cmd.run(cfg, args[1:]); err != nil {
+		log.Error("%s: %v", args[0], err)
+		os.Exit(1)
+	}
+}
+
+// openDatabase loads the configured database the same way the data processor does
+func openDatabase(cfg config.Config) (*storage.EntropyDB, error) {
+	dataProcessor := service.NewDataProcessorService(log, cfg, "")
+	if err := dataProcessor.Initialize(); err != nil {
+		return nil, err
+	}
+	return dataProcessor.GetDatabase(), nil
+}
+
+// findGlobal finds a stored global by ID, or else the single global at the given time,
+// optionally narrowed by text contained in its raw message
+func findGlobal(db *storage.EntropyDB, id, at, match string) (storage.GlobalEntry, error) {
+	if id != "" {
+		if entry, ok := db.GetGlobal(id); ok {
+			return entry, nil
+		}
+		return storage.GlobalEntry{}, storage.ErrGlobalNotFound
+	}
+	if at == "" {
+		return storage.GlobalEntry{}, fmt.Errorf("-id or -at is required")
+	}
+	ts, err := time.Parse("2006-01-02 15:04:05", at)
+	if err != nil {
+		return storage.GlobalEntry{}, fmt.Errorf("invalid -at time %q (format: 2006-01-02 15:04:05)", at)
+	}
+
+	var found []storage.GlobalEntry
+	for _, g := range db.Globals {
+		if g.Timestamp.Equal(ts) && strings.Contains(g.RawMessage, match) {
+			found = append(found, g)
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return storage.GlobalEntry{}, storage.ErrGlobalNotFound
+	case 1:
+		return found[0], nil
+	default:
+		for _, g := range found {
+			fmt.Printf("  %s  %s\n", g.ID, g.RawMessage)
+		}
+		return storage.GlobalEntry{}, fmt.Errorf("%d globals at %s, use -id or -match to pick one", len(found), at)
+	}
+}
+
+// runEditCommand corrects fields of a stored global
+func runEditCommand(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ExitOnError)
+	id := fs.String("id", "", "ID of the global to edit")
+	at := fs.String("at", "", "Timestamp of the global to edit (2006-01-02 15:04:05)")
+	match := fs.String("match", "", "Text contained in the raw message, if several globals share the timestamp")
+	player := fs.String("player", "", "New player name")
+	team := fs.String("team", "", "New team name")
+	target := fs.String("target", "", "New creature, item or resource name")
+	typ := fs.String("type", "", "New type (kill, craft or find)")
+	value := fs.Float64("value", 0, "New PED value")
+	location := fs.String("location", "", "New location")
+	hof := fs.Bool("hof", false, "Whether the global is a Hall of Fame entry")
+	fs.Parse(args)
+
+	// Only flags given on the command line become part of the edit
+	var edit storage.GlobalEdit
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "player":
+			edit.PlayerName = player
+		case "team":
+			edit.TeamName = team
+		case "target":
+			edit.Target = target
+		case "type":
+			edit.Type = typ
+		case "value":
+			edit.Value = value
+		case "location":
+			edit.Location = location
+		case "hof":
+			edit.IsHof = hof
+		}
+	})
+	if edit.IsEmpty() {
+		return fmt.Errorf("nothing to change, give at least one of -player, -team, -target, -type, -value, -location or -hof")
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	entry, err := findGlobal(db, *id, *at, *match)
+	if err != nil {
+		return err
+	}
+
+	updated, err := service.EditGlobal(db, entry.ID, edit, "cli", log)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Updated %s: %s %s %s (%s) %.2f PED\n", updated.ID, updated.Timestamp.Format("2006-01-02 15:04:05"),
+		updated.Type, valueOr(updated.PlayerName, updated.TeamName), updated.Target, updated.Value)
+	return nil
+}
+
+// runDeleteCommand removes a stored global
+func runDeleteCommand(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("delete", flag.ExitOnError)
+	id := fs.String("id", "", "ID of the global to delete")
+	at := fs.String("at", "", "Timestamp of the global to delete (2006-01-02 15:04:05)")
+	match := fs.String("match", "", "Text contained in the raw message, if several globals share the timestamp")
+	fs.Parse(args)
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	entry, err := findGlobal(db, *id, *at, *match)
+	if err != nil {
+		return err
+	}
+
+	if err := service.DeleteGlobal(db, entry.ID, "cli", log); err != nil {
+		return err
+	}
+
+	fmt.Printf("Deleted %s: %s\n", entry.ID, entry.RawMessage)
+	return nil
+}
+
+// runTimeSeriesCommand prints globals aggregated per day, week or month
+func runTimeSeriesCommand(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("timeseries", flag.ExitOnError)
+	interval := fs.String("interval", "day", "Bucket size: day, week or month")
+	from := fs.String("from", "", "First date to include (2006-01-02)")
+	to := fs.String("to", "", "Last date to include (2006-01-02)")
+	byType := fs.Bool("by-type", false, "Split every period by global type")
+	fs.Parse(args)
+
+	start, end, err := model.ParseTimeRange(*from, *to)
+	if err != nil {
+		return err
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	statsService := service.NewStatsService(log, db, cfg.PlayerName, cfg.TeamName)
+	series, err := statsService.GenerateTimeSeries(*interval, start, end, *byType)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(stats.FormatTimeSeriesTable(series))
+	return nil
+}
+
+// runSessionsCommand lists the detected sessions or shows one of them in detail
+func runSessionsCommand(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("sessions", flag.ExitOnError)
+	id := fs.String("id", "", "ID of the session to show in detail")
+	rebuild := fs.String("rebuild", "", "Chat log to detect all sessions from again, replacing the stored ones")
+	fs.Parse(args)
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+
+	if *rebuild != "" {
+		count, err := db.RebuildSessions(*rebuild)
+		if err != nil {
+			return err
+		}
+		db.CloseIdleSession(analysis.WallClockNow())
+		if err := db.SaveDatabase(db.Path(), log); err != nil {
+			return err
+		}
+		fmt.Printf("Detected %d sessions\n\n", count)
+	}
+
+	statsService := service.NewStatsService(log, db, cfg.PlayerName, cfg.TeamName)
+	if *id == "" {
+		fmt.Print(stats.FormatSessionList(statsService.GetSessions()))
+		return nil
+	}
+
+	detail, ok := statsService.GetSession(*id)
+	if !ok {
+		return fmt.Errorf("session %s not found", *id)
+	}
+	fmt.Print(stats.FormatSessionDetail(detail))
+	return nil
+}
+
+// runLeaderboardCommand prints the universe leaderboards of the latest or a given period
+func runLeaderboardCommand(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("leaderboard", flag.ExitOnError)
+	interval := fs.String("interval", "day", "Period length: day, week or month")
+	period := fs.String("period", "", "Period to show, e.g. 2025-05-16, 2025-W20 or 2025-05 (default: the latest)")
+	periods := fs.Int("periods", 1, "Number of latest periods to show")
+	limit := fs.Int("limit", model.DefaultLeaderboardSize, "Entries per ranking")
+	rebuild := fs.String("rebuild", "", "Chat log to capture everyone's globals from again, replacing the stored ones")
+	fs.Parse(args)
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+
+	if *rebuild != "" {
+		count, err := db.RebuildUniverse(*rebuild)
+		if err != nil {
+			return err
+		}
+		if err := db.SaveDatabase(db.Path(), log); err != nil {
+			return err
+		}
+		fmt.Printf("Captured %d globals\n\n", count)
+	} else if len(db.Universe) == 0 {
+		return fmt.Errorf("no universe globals captured, set capture_universe: true in the config or use -rebuild")
+	}
+
+	statsService := service.NewStatsService(log, db, cfg.PlayerName, cfg.TeamName)
+	boards, err := statsService.GenerateLeaderboards(*interval, *limit)
+	if err != nil {
+		return err
+	}
+
+	shown := 0
+	for _, board := range boards {
+		if *period != "" && board.Period != *period {
+			continue
+		}
+		if *period == "" && shown >= *periods {
+			break
+		}
+		if shown > 0 {
+			fmt.Println()
+		}
+		fmt.Print(stats.FormatLeaderboard(board))
+		shown++
+	}
+	if shown == 0 && *period != "" {
+		return fmt.Errorf("no globals captured in period %s", *period)
+	}
+	return nil
+}
+
+// runCompareCommand prints the statistics of two time ranges or identities side by side
+func runCompareCommand(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	preset := fs.String("preset", "", "Compare the previous with the current day, week or month")
+	var a, b model.DatasetQuery
+	for _, side := range []struct {
+		name  string
+		query *model.DatasetQuery
+	}{{"a", &a}, {"b", &b}} {
+		fs.StringVar(&side.query.Label, side.name+"-label", "", "Label of dataset "+strings.ToUpper(side.name))
+		fs.StringVar(&side.query.From, side.name+"-from", "", "First date of dataset "+strings.ToUpper(side.name)+" (2006-01-02)")
+		fs.StringVar(&side.query.To, side.name+"-to", "", "Last date of dataset "+strings.ToUpper(side.name)+" (2006-01-02)")
+		fs.StringVar(&side.query.Player, side.name+"-player", "", "Player of dataset "+strings.ToUpper(side.name)+" (default: your own globals)")
+		fs.StringVar(&side.query.Team, side.name+"-team", "", "Team of dataset "+strings.ToUpper(side.name))
+	}
+	asJSON := fs.Bool("json", false, "Print the comparison as JSON")
+	fs.Parse(args)
+
+	filterA, filterB, err := model.ParseDatasets(*preset, a, b, analysis.WallClockNow())
+	if err != nil {
+		return err
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	statsService := service.NewStatsService(log, db, cfg.PlayerName, cfg.TeamName)
+	comparison := statsService.Compare(filterA, filterB)
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(comparison)
+	}
+	fmt.Print(stats.FormatComparison(comparison))
+	return nil
+}
+
+// runGoalsCommand adds or removes goals and prints the progress towards them and the achievements
+func runGoalsCommand(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("goals", flag.ExitOnError)
+	add := fs.Bool("add", false, "Add a goal described by the other flags")
+	var goal model.Goal
+	fs.StringVar(&goal.Name, "name", "", "Name of the goal (default: a description of it)")
+	fs.StringVar(&goal.Metric, "metric", model.GoalMetricGlobals, "What to count: globals, hofs or value (PED)")
+	fs.Float64Var(&goal.Threshold, "threshold", 0, "Amount to reach")
+	fs.StringVar(&goal.Type, "type", "", "Only count globals of this type: kill, craft or find")
+	fs.StringVar(&goal.Target, "target", "", "Only count globals of this creature, item or deposit")
+	fs.StringVar(&goal.Location, "location", "", "Only count globals at this location")
+	fs.StringVar(&goal.Period, "period", "", "Start over every day, week or month")
+	remove := fs.String("remove", "", "ID of the goal to remove")
+	fs.Parse(args)
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+
+	now := analysis.WallClockNow()
+	if *add {
+		added, err := db.AddGoal(goal, now)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Added goal %s: %s\n\n", added.ID, added.Name)
+	}
+	if *remove != "" {
+		if !db.RemoveGoal(*remove) {
+			return fmt.Errorf("goal %s not found", *remove)
+		}
+		fmt.Printf("Removed goal %s\n\n", *remove)
+	}
+
+	// Catch up on achievements and completions before listing them
+	db.UpdateProgress(now)
+	if err := db.SaveDatabase(db.Path(), log); err != nil {
+		return err
+	}
+
+	statsService := service.NewStatsService(log, db, cfg.PlayerName, cfg.TeamName)
+	fmt.Print(stats.FormatGoalProgress(statsService.GetGoalProgress()))
+	fmt.Println()
+	fmt.Print(stats.FormatAchievements(statsService.GetAchievements()))
+	return nil
+}
+
+// runRecordsCommand prints the personal records, optionally flagging them in the whole history again
+func runRecordsCommand(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("records", flag.ExitOnError)
+	rebuild := fs.Bool("rebuild", false, "Flag the globals that set a record in the whole stored history again")
+	fs.Parse(args)
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+
+	if *rebuild {
+		count := db.ComputeRecords()
+		if err := db.SaveDatabase(db.Path(), log); err != nil {
+			return err
+		}
+		fmt.Printf("Flagged %d globals that set a personal record\n\n", count)
+	}
+
+	statsService := service.NewStatsService(log, db, cfg.PlayerName, cfg.TeamName)
+	fmt.Print(stats.FormatRecordBook(statsService.GetRecordBook()))
+	return nil
+}
+
+// runMarkupsCommand edits the markups in the configuration file and prints them with the
+// targets that still lack one
+func runMarkupsCommand(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("markups", flag.ExitOnError)
+	importPath := fs.String("import", "", "CSV file of item and markup rows to merge into the configuration")
+	set := fs.String("set", "", "Item or resource to set the markup of")
+	markup := fs.String("markup", "", "Markup for -set: a percentage of TT such as 125%, or PED on top of TT such as +5")
+	remove := fs.String("remove", "", "Item or resource to remove the markup of")
+	limit := fs.Int("limit", 20, "Maximum number of missing markups to list (0 for all)")
+	fs.Parse(args)
+
+	fileCfg, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	if *importPath != "" {
+		f, err := os.Open(*importPath)
+		if err != nil {
+			return err
+		}
+		count, err := service.ImportMarkupCSV(&fileCfg, f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", *importPath, err)
+		}
+		fmt.Printf("Imported %d markups from %s\n\n", count, *importPath)
+		changed = true
+	}
+	if *set != "" {
+		m, err := model.ParseMarkup(*set, *markup)
+		if err != nil {
+			return err
+		}
+		service.SetConfigMarkups(&fileCfg, model.MergeMarkups(service.ConfigMarkups(fileCfg), []model.Markup{m}))
+		changed = true
+	}
+	if *remove != "" {
+		markups := service.ConfigMarkups(fileCfg)
+		kept := markups[:0]
+		for _, m := range markups {
+			if !strings.EqualFold(m.Item, *remove) {
+				kept = append(kept, m)
+			}
+		}
+		if len(kept) == len(fileCfg.Markups) {
+			return fmt.Errorf("no markup for %s", *remove)
+		}
+		service.SetConfigMarkups(&fileCfg, kept)
+		changed = true
+	}
+	if changed {
+		if err := fileCfg.SaveConfigToFile(configFile); err != nil {
+			return err
+		}
+		cfg.Markups = fileCfg.Markups
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	statsService := service.NewStatsService(log, db, cfg.PlayerName, cfg.TeamName)
+	fmt.Print(stats.FormatMarkupReport(statsService.GetMarkupReport(), *limit))
+	return nil
+}
+
+// runTokensCommand lists, adds and removes the tokens of the web server. A new token is
+// printed once; the configuration file keeps it for the server.
+func runTokensCommand(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("tokens", flag.ExitOnError)
+	add := fs.String("add", "", "Name of a new token, e.g. the device or person using it")
+	scope := fs.String("scope", service.ScopeRead, "Scope of the new token: read, member to also push globals to this hub, or admin to also edit globals and goals")
+	remove := fs.String("remove", "", "Name of the token to remove")
+	fs.Parse(args)
+
+	fileCfg, err := loadConfigFile()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *add != "":
+		for _, t := range fileCfg.WebTokens {
+			if strings.EqualFold(t.Name, *add) {
+				return fmt.Errorf("a token named %s already exists", *add)
+			}
+		}
+		token, err := service.GenerateWebToken()
+		if err != nil {
+			return err
+		}
+		tokens := append(fileCfg.WebTokens, config.WebToken{Name: *add, Token: token, Scope: *scope})
+		if err := service.ValidateWebAuth(tokens, fileCfg.WebAllowedOrigins); err != nil {
+			return err
+		}
+		fileCfg.WebTokens = tokens
+		if err := fileCfg.SaveConfigToFile(configFile); err != nil {
+			return err
+		}
+		fmt.Printf("Added %s token %s: %s\n\n", *scope, *add, token)
+	case *remove != "":
+		kept := fileCfg.WebTokens[:0]
+		for _, t := range fileCfg.WebTokens {
+			if !strings.EqualFold(t.Name, *remove) {
+				kept = append(kept, t)
+			}
+		}
+		if len(kept) == len(fileCfg.WebTokens) {
+			return fmt.Errorf("no token named %s", *remove)
+		}
+		fileCfg.WebTokens = kept
+		if err := fileCfg.SaveConfigToFile(configFile); err != nil {
+			return err
+		}
+		fmt.Printf("Removed token %s\n\n", *remove)
+	}
+
+	if len(fileCfg.WebTokens) == 0 {
+		fmt.Println("No web tokens: anyone who can reach the web server can use it")
+		return nil
+	}
+	fmt.Printf("%-24s %s\n", "Name", "Scope")
+	for _, t := range fileCfg.WebTokens {
+		fmt.Printf("%-24s %s\n", t.Name, valueOr(t.Scope, service.ScopeRead))
+	}
+	return nil
+}
+
+// runThemeCommand copies the built-in dashboard files into a directory to edit and use as
+// web_theme_dir
+func runThemeCommand(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("theme", flag.ExitOnError)
+	export := fs.String("export", "", "Directory to copy the built-in templates and static files into")
+	fs.Parse(args)
+
+	if *export == "" {
+		return fmt.Errorf("-export is required")
+	}
+	written, err := service.ExportTheme(*export)
+	if err != nil {
+		return err
+	}
+	for _, path := range written {
+		fmt.Println(path)
+	}
+	fmt.Printf("\nExported %d files. Keep only those you change and set web_theme_dir: %s\n", len(written), *export)
+	return nil
+}
+
+// runExportCommand writes the player's globals matching the filters to a file or stdout
+func runExportCommand(cfg config.Config, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "", "Export format: csv, json, ndjson or html (default from the -o extension, else csv)")
+	output := fs.String("o", "", "File to write, stdout if empty")
+	columns := fs.String("columns", "", "Comma-separated CSV columns: "+strings.Join(export.Columns, ", "))
+	delimiter := fs.String("delimiter", "", "CSV delimiter: a character, tab, comma (default) or semicolon")
+	from := fs.String("from", "", "First date to include (2006-01-02)")
+	to := fs.String("to", "", "Last date to include (2006-01-02)")
+	typ := fs.String("type", "", "Only globals of this type: kill, craft or find")
+	target := fs.String("target", "", "Only targets containing this text")
+	location := fs.String("location", "", "Only locations containing this text")
+	minValue := fs.Float64("min-value", 0, "Minimum value in PED")
+	maxValue := fs.Float64("max-value", 0, "Maximum value in PED, 0 for no maximum")
+	tier := fs.String("tier", "", "Only globals that did not make the Hall of Fame (global) or only HoFs (hof)")
+	sortField := fs.String("sort", "timestamp", "Sort by "+strings.Join(storage.GlobalSortFields, ", "))
+	order := fs.String("order", "asc", "Sort order: asc or desc")
+	fs.Parse(args)
+
+	opts := export.Options{Format: *format}
+	if opts.Format == "" {
+		opts.Format = strings.TrimPrefix(strings.ToLower(filepath.Ext(*output)), ".")
+		if !slices.Contains(export.Formats, opts.Format) {
+			opts.Format = export.FormatCSV
+		}
+	}
+	var err error
+	if *columns != "" {
+		if opts.Columns, err = export.ParseColumns(*columns); err != nil {
+			return err
+		}
+	}
+	if opts.Delimiter, err = export.ParseDelimiter(*delimiter); err != nil {
+		return err
+	}
+	if *order != "asc" && *order != "desc" {
+		return fmt.Errorf("invalid -order %q: must be asc or desc", *order)
+	}
+	q := storage.GlobalQuery{
+		Type:       *typ,
+		Target:     *target,
+		Location:   *location,
+		MinValue:   *minValue,
+		MaxValue:   *maxValue,
+		Tier:       *tier,
+		Sort:       *sortField,
+		Descending: *order == "desc",
+	}
+	if q.From, q.To, err = model.ParseTimeRange(*from, *to); err != nil {
+		return err
+	}
+
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return err
+	}
+	if *output == "" {
+		return service.ExportGlobals(os.Stdout, db, q, opts, time.Now())
+	}
+
+	// Write next to the target and rename, so a failed export leaves no partial file
+	tmp := *output + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	err = service.ExportGlobals(file, db, q, opts, time.Now())
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, *output); err != nil {
+		return err
+	}
+	fmt.Printf("Exported to %s\n", *output)
+	return nil
+}
+
+// loadConfigFile loads the configuration file for editing. Commands edit the file as stored
+// rather than cfg, which holds command line overrides.
+func loadConfigFile() (config.Config, error) {
+	fileCfg, err := config.LoadConfigFromFile(configFile)
+	if os.IsNotExist(err) {
+		return config.NewDefaultConfig(), nil
+	}
+	return fileCfg, err
+}
+
+// valueOr returns value, or fallback if value is empty
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
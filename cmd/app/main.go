@@ -19,6 +19,9 @@ const (
 
 var log = logger.New()
 
+// configFile is the configuration file that subcommands save changes to
+var configFile = "config.yaml"
+
 func main() { // Define command-line flags
 	configPath := flag.String("config", "", "Path to configuration file")
 	logPath := flag.String("log", "", "Path to Entropia Universe chat log file")
@@ -34,6 +37,7 @@ func main() { // Define command-line flags
 	gameWindow := flag.String("game-window", "Entropia Universe Client", "Game window title")
 	webServer := flag.Bool("web", false, "Start a web server to view statistics")
 	webPort := flag.Int("web-port", 8080, "Port for the web server")
+	webBind := flag.String("web-bind", "", "Address for the web server to listen on, e.g. 0.0.0.0 for every interface (default from config)")
 	verbose := flag.Bool("verbose", false, "Enable verbose (debug) logging")
 
 	// Parse command-line flags
@@ -109,6 +113,17 @@ func main() { // Define command-line flags
 	cfg.GameWindowTitle = *gameWindow
 	log.Info("Game window title: %s", cfg.GameWindowTitle)
 
+	if *webBind != "" {
+		cfg.WebBindAddress = *webBind
+	}
+
+	// Run a subcommand if one was given after the flags
+	if flag.NArg() > 0 {
+		configFile = actualConfigPath
+		runCommand(cfg, flag.Args())
+		return
+	}
+
 	// Use command-line interface if explicitly requested or if certain flags are set
 	if *useCLI || *showStats || *importLog || *monitor {
 		log.Info("Starting in CLI mode")
@@ -153,6 +168,15 @@ func main() { // Define command-line flags
 
 			// Don't wait for Ctrl+C here, we'll do that after starting all services
 		}
+		// Push new globals to the team hub while monitoring
+		var hubClient *service.HubClient
+		if !*importLog && (*monitor || !*showStats) {
+			var err error
+			if hubClient, err = service.StartHubClient(log, cfg); err != nil {
+				log.Error("Failed to start pushing to the hub: %v", err)
+				os.Exit(1)
+			}
+		}
 		// Show statistics if requested
 		if *showStats {
 			if cfg.PlayerName == "" {
@@ -188,14 +212,24 @@ func main() { // Define command-line flags
 
 			log.Info("Starting web server on port %d...", webServerPort)
 			webService = service.NewWebService(log, dataProcessor.GetDatabase(), cfg.PlayerName, cfg.TeamName, webServerPort)
+			if err := service.ConfigureWebService(webService, cfg); err != nil {
+				log.Error("%v", err)
+				os.Exit(1)
+			}
 			if err := webService.Initialize(); err != nil {
 				log.Error("Failed to initialize web service: %v", err)
 				os.Exit(1)
-			} // Always start the web server in background
+			}
+			// Open the port here so that a port in use stops startup with a clear message
+			if err := webService.Listen(); err != nil {
+				log.Error("Failed to start web server: %v", err)
+				webService.Stop()
+				os.Exit(1)
+			}
+			// Always start the web server in background
 			go func() {
 				if err := webService.Run(); err != nil {
 					log.Error("Web server error: %v", err)
-					os.Exit(1)
 				}
 			}()
 		} // If we have any background services running, wait for Ctrl+C
@@ -212,6 +246,9 @@ func main() { // Define command-line flags
 			if webService != nil {
 				webService.Stop()
 			}
+			if hubClient != nil {
+				hubClient.Stop()
+			}
 		}
 
 		return // Exit after completing CLI operations
@@ -0,0 +1,194 @@
+// Package analysis provides analyses over the timing of stored globals
+package analysis
+
+import (
+	"eu-clams/internal/model"
+	"math"
+	"sort"
+	"time"
+)
+
+// DefaultStreakWindow is the maximum gap between globals of the same streak
+const DefaultStreakWindow = 10 * time.Minute
+
+// Period is a span of time between two globals
+type Period struct {
+	Start   string  `json:"start"` // Same layout as model.GlobalEntry.Timestamp
+	End     string  `json:"end"`
+	Seconds float64 `json:"seconds"`
+}
+
+// Streak is a run of globals that each followed the previous one within the streak window
+type Streak struct {
+	Start      string  `json:"start"`
+	End        string  `json:"end"`
+	Count      int     `json:"count"`
+	TotalValue float64 `json:"total_value"`
+}
+
+// GapStats describes the gaps between consecutive globals of one group
+type GapStats struct {
+	Globals               int     `json:"globals"`
+	LastGlobal            string  `json:"last_global,omitempty"`
+	CurrentDroughtSeconds float64 `json:"current_drought_seconds"` // Time since the last global
+	LongestDrought        *Period `json:"longest_drought,omitempty"`
+	AverageGapSeconds     float64 `json:"average_gap_seconds"`
+	MedianGapSeconds      float64 `json:"median_gap_seconds"`
+	P90GapSeconds         float64 `json:"p90_gap_seconds"`
+	Streaks               int     `json:"streaks"`
+	LongestStreak         *Streak `json:"longest_streak,omitempty"`
+}
+
+// DroughtReport holds gap statistics overall, per type and per target
+type DroughtReport struct {
+	GeneratedAt         string              `json:"generated_at"`
+	StreakWindowSeconds float64             `json:"streak_window_seconds"`
+	Overall             GapStats            `json:"overall"`
+	ByType              map[string]GapStats `json:"by_type"`
+	ByTarget            map[string]GapStats `json:"by_target"`
+}
+
+// WallClockNow returns the current local time with the zone dropped, matching how chat log
+// timestamps are stored
+func WallClockNow() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), now.Minute(), now.Second(), 0, time.UTC)
+}
+
+// AnalyzeDroughts computes the gaps between consecutive globals overall, per type and per
+// target. now is the moment the current drought is measured to, and streakWindow the
+// maximum gap between globals of the same streak.
+func AnalyzeDroughts(globals []model.GlobalEntry, now time.Time, streakWindow time.Duration) DroughtReport {
+	if streakWindow <= 0 {
+		streakWindow = DefaultStreakWindow
+	}
+
+	type timedEntry struct {
+		model.GlobalEntry
+		time time.Time
+	}
+
+	var all []timedEntry
+	for _, entry := range globals {
+		t, err := entry.Time()
+		if err != nil {
+			continue
+		}
+		all = append(all, timedEntry{entry, t})
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].time.Before(all[j].time) })
+
+	byType := make(map[string][]timedEntry)
+	byTarget := make(map[string][]timedEntry)
+	for _, e := range all {
+		byType[e.Type] = append(byType[e.Type], e)
+		byTarget[e.Target] = append(byTarget[e.Target], e)
+	}
+
+	analyze := func(entries []timedEntry) GapStats {
+		times := make([]time.Time, len(entries))
+		values := make([]float64, len(entries))
+		for i, e := range entries {
+			times[i] = e.time
+			values[i] = e.Value
+		}
+		return gapStats(times, values, now, streakWindow)
+	}
+
+	report := DroughtReport{
+		GeneratedAt:         now.Format(model.TimestampLayout),
+		StreakWindowSeconds: streakWindow.Seconds(),
+		Overall:             analyze(all),
+		ByType:              make(map[string]GapStats, len(byType)),
+		ByTarget:            make(map[string]GapStats, len(byTarget)),
+	}
+	for typ, entries := range byType {
+		report.ByType[typ] = analyze(entries)
+	}
+	for target, entries := range byTarget {
+		report.ByTarget[target] = analyze(entries)
+	}
+
+	return report
+}
+
+// gapStats computes gap statistics for chronologically sorted global times and their values
+func gapStats(times []time.Time, values []float64, now time.Time, streakWindow time.Duration) GapStats {
+	stats := GapStats{Globals: len(times)}
+	if len(times) == 0 {
+		return stats
+	}
+
+	last := times[len(times)-1]
+	stats.LastGlobal = last.Format(model.TimestampLayout)
+	if now.After(last) {
+		stats.CurrentDroughtSeconds = now.Sub(last).Seconds()
+	}
+
+	gaps := make([]float64, 0, len(times)-1)
+	var total float64
+	streak := Streak{Start: stats.LastGlobal, Count: 1}
+
+	for i := range times {
+		if i > 0 {
+			gap := times[i].Sub(times[i-1])
+			gaps = append(gaps, gap.Seconds())
+			total += gap.Seconds()
+
+			if stats.LongestDrought == nil || gap.Seconds() > stats.LongestDrought.Seconds {
+				stats.LongestDrought = &Period{
+					Start:   times[i-1].Format(model.TimestampLayout),
+					End:     times[i].Format(model.TimestampLayout),
+					Seconds: gap.Seconds(),
+				}
+			}
+
+			if gap <= streakWindow {
+				streak.Count++
+				streak.End = times[i].Format(model.TimestampLayout)
+				streak.TotalValue += values[i]
+				continue
+			}
+			stats.closeStreak(streak)
+		}
+		streak = Streak{
+			Start:      times[i].Format(model.TimestampLayout),
+			End:        times[i].Format(model.TimestampLayout),
+			Count:      1,
+			TotalValue: values[i],
+		}
+	}
+	stats.closeStreak(streak)
+
+	if len(gaps) > 0 {
+		stats.AverageGapSeconds = total / float64(len(gaps))
+		sort.Float64s(gaps)
+		stats.MedianGapSeconds = percentile(gaps, 50)
+		stats.P90GapSeconds = percentile(gaps, 90)
+	}
+
+	return stats
+}
+
+// closeStreak counts a finished run of globals if it is a streak
+func (s *GapStats) closeStreak(streak Streak) {
+	if streak.Count < 2 {
+		return
+	}
+	s.Streaks++
+	if s.LongestStreak == nil || streak.Count > s.LongestStreak.Count {
+		longest := streak
+		s.LongestStreak = &longest
+	}
+}
+
+// percentile returns the p-th percentile of sorted values using linear interpolation
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+	return sorted[lower] + (sorted[upper]-sorted[lower])*(rank-float64(lower))
+}
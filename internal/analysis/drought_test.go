@@ -0,0 +1,52 @@
+package analysis
+
+import (
+	"eu-clams/internal/model"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeDroughts(t *testing.T) {
+	t.Parallel()
+
+	globals := []model.GlobalEntry{
+		{Timestamp: "2025-05-16 10:00:00", Type: "kill", Target: "Atrox", Value: 50},
+		{Timestamp: "2025-05-16 10:05:00", Type: "kill", Target: "Atrox", Value: 60},
+		{Timestamp: "2025-05-16 10:12:00", Type: "kill", Target: "Daikiba", Value: 70},
+		{Timestamp: "2025-05-16 12:12:00", Type: "find", Target: "Lysterium", Value: 80},
+		{Timestamp: "2025-05-16 13:12:00", Type: "kill", Target: "Atrox", Value: 90},
+		{Timestamp: "invalid", Type: "kill", Target: "Atrox", Value: 100},
+	}
+	now := time.Date(2025, 5, 16, 14, 12, 0, 0, time.UTC)
+
+	report := AnalyzeDroughts(globals, now, 10*time.Minute)
+	overall := report.Overall
+
+	if overall.Globals != 5 {
+		t.Errorf("Globals = %d, want 5", overall.Globals)
+	}
+	if overall.CurrentDroughtSeconds != 3600 {
+		t.Errorf("CurrentDroughtSeconds = %v, want 3600", overall.CurrentDroughtSeconds)
+	}
+	if overall.LongestDrought == nil || overall.LongestDrought.Seconds != 7200 || overall.LongestDrought.Start != "2025-05-16 10:12:00" {
+		t.Errorf("LongestDrought = %+v, want 2h from 10:12", overall.LongestDrought)
+	}
+	// Gaps are 5m, 7m, 60m and 120m
+	if overall.AverageGapSeconds != 2880 {
+		t.Errorf("AverageGapSeconds = %v, want 2880", overall.AverageGapSeconds)
+	}
+	if overall.MedianGapSeconds != 2010 {
+		t.Errorf("MedianGapSeconds = %v, want 2010", overall.MedianGapSeconds)
+	}
+	if overall.Streaks != 1 || overall.LongestStreak == nil || overall.LongestStreak.Count != 3 || overall.LongestStreak.TotalValue != 180 {
+		t.Errorf("Streaks = %d, LongestStreak = %+v, want one streak of 3 globals worth 180", overall.Streaks, overall.LongestStreak)
+	}
+
+	if kill := report.ByType["kill"]; kill.Globals != 4 || kill.LongestDrought.Seconds != 3*3600 {
+		t.Errorf("ByType[kill] = %+v, want 4 globals and a 3h drought", kill)
+	}
+	find := report.ByTarget["Lysterium"]
+	if find.Globals != 1 || find.LongestDrought != nil || find.Streaks != 0 || find.CurrentDroughtSeconds != 7200 {
+		t.Errorf("ByTarget[Lysterium] = %+v, want a single global without gaps", find)
+	}
+}
@@ -12,6 +12,48 @@ type Config struct {
 	GameWindowTitle     string  `yaml:"game_window_title"`
 	EnableWebServer     bool    `yaml:"enable_web_server"`
 	WebServerPort       int     `yaml:"web_server_port"`
+	WebBindAddress      string  `yaml:"web_bind_address"`       // Interface to listen on; 0.0.0.0 for every interface
+	WebTLS              bool    `yaml:"web_tls"`                // Serve HTTPS
+	WebTLSCert          string  `yaml:"web_tls_cert,omitempty"` // Certificate and key files; without them a self-signed certificate is used
+	WebTLSKey           string  `yaml:"web_tls_key,omitempty"`
+	WebThemeDir         string  `yaml:"web_theme_dir,omitempty"` // Files replacing the built-in dashboard templates and static files
+	SessionIdleMinutes  int     `yaml:"session_idle_minutes"`    // Chat log inactivity that ends a session
+	CaptureUniverse     bool    `yaml:"capture_universe"`        // Also store everyone else's globals for leaderboards
+	// Value distribution; empty lists use the built-in defaults
+	HistogramEdges []float64      `yaml:"histogram_edges,omitempty"` // Bucket edges in PED, ascending
+	ValueBrackets  []ValueBracket `yaml:"value_brackets,omitempty"`
+	// Market value of items and resources; targets without a markup are valued at TT
+	Markups []Markup `yaml:"markups,omitempty"`
+	// Web server access; without tokens anyone who can reach the server may use it
+	WebTokens         []WebToken `yaml:"web_tokens,omitempty"`
+	WebAllowedOrigins []string   `yaml:"web_allowed_origins,omitempty"` // Other sites allowed to use the API, e.g. https://example.com
+	// Team hub: a hub collects the globals its members push with member-scoped web tokens
+	HubMode  bool   `yaml:"hub_mode,omitempty"`  // Accept globals from members and serve the team dashboard
+	HubURL   string `yaml:"hub_url,omitempty"`   // Hub to push our own new globals to, e.g. https://hub.example.com:8080
+	HubToken string `yaml:"hub_token,omitempty"` // Member token the hub gave us
+}
+
+// ValueBracket is a named value range starting at Min PED and ending at the next bracket's Min
+type ValueBracket struct {
+	Name string  `yaml:"name"`
+	Min  float64 `yaml:"min"`
+}
+
+// Markup is the market value of an item or resource: either Percent of its TT value
+// (e.g. 125) or Absolute PED on top of it
+type Markup struct {
+	Item     string  `yaml:"item"`
+	Percent  float64 `yaml:"percent,omitempty"`
+	Absolute float64 `yaml:"absolute,omitempty"`
+}
+
+// WebToken grants access to the web server with the read, member or admin scope. Read tokens
+// may view everything; member tokens may also push globals to a hub, named after the token;
+// admin tokens may also edit globals and goals.
+type WebToken struct {
+	Name  string `yaml:"name"`
+	Token string `yaml:"token"`
+	Scope string `yaml:"scope"`
 }
 
 // NewDefaultConfig returns a config with default values
@@ -26,5 +68,7 @@ func NewDefaultConfig() Config {
 		GameWindowTitle:     "Entropia Universe Client",
 		EnableWebServer:     false,
 		WebServerPort:       8080,
+		WebBindAddress:      "127.0.0.1",
+		SessionIdleMinutes:  30,
 	}
 }
@@ -0,0 +1,267 @@
+// Package export writes globals as CSV, JSON, NDJSON or a standalone HTML report
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"eu-clams/internal/model"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Export formats
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"   // One indented document with the filters and the globals
+	FormatNDJSON = "ndjson" // One global per line
+	FormatHTML   = "html"   // Standalone report with a summary and the globals
+)
+
+// Formats lists the export formats
+var Formats = []string{FormatCSV, FormatJSON, FormatNDJSON, FormatHTML}
+
+// Columns lists the CSV columns that can be exported
+var Columns = []string{"id", "timestamp", "type", "player", "team", "target", "value", "market_value", "location", "is_hof", "records", "raw_message"}
+
+// DefaultColumns are the CSV columns exported when none are chosen
+var DefaultColumns = []string{"timestamp", "type", "player", "team", "target", "value", "location", "is_hof"}
+
+// csvTimeLayout is the timestamp layout in CSV files, which spreadsheets read as a date and time
+const csvTimeLayout = "2006-01-02 15:04:05"
+
+// utf8BOM makes Excel read a CSV file as UTF-8
+const utf8BOM = "\uFEFF"
+
+// Options selects the format of an export
+type Options struct {
+	Format    string
+	Columns   []string // CSV columns, DefaultColumns when empty
+	Delimiter rune     // CSV field delimiter, a comma when zero
+}
+
+// Global is an exported global: the global as the API returns it and its market value
+type Global struct {
+	model.GlobalEntryJSON
+	MarketValue float64 `json:"market_value"` // Value with the markup of the target applied, TT without one
+}
+
+// Report describes what was exported, for the JSON document and the HTML report
+type Report struct {
+	Title     string
+	Generated time.Time
+	Filters   map[string]string // Filters that were set, by parameter name
+	Stats     model.Stats       // Statistics of the exported globals
+}
+
+// jsonDocument is the JSON export
+type jsonDocument struct {
+	Generated   string            `json:"generated_at"`
+	Filters     map[string]string `json:"filters"`
+	Count       int               `json:"count"`
+	Hofs        int               `json:"hofs"`
+	Total       float64           `json:"total_value"`
+	TotalMarket float64           `json:"total_market_value"`
+	Globals     []Global          `json:"globals"`
+}
+
+// Validate checks the format, columns and delimiter of the options
+func (o Options) Validate() error {
+	if !slices.Contains(Formats, o.Format) {
+		return fmt.Errorf("invalid format %q: must be one of %s", o.Format, strings.Join(Formats, ", "))
+	}
+	for _, c := range o.Columns {
+		if !slices.Contains(Columns, c) {
+			return fmt.Errorf("invalid column %q: must be one of %s", c, strings.Join(Columns, ", "))
+		}
+	}
+	if o.Delimiter != 0 && !validDelimiter(o.Delimiter) {
+		return fmt.Errorf("invalid delimiter %q", o.Delimiter)
+	}
+	return nil
+}
+
+// ParseDelimiter reads a CSV delimiter: a single character, or "tab", "comma" or "semicolon"
+func ParseDelimiter(s string) (rune, error) {
+	switch strings.ToLower(s) {
+	case "", "comma":
+		return ',', nil
+	case "semicolon":
+		return ';', nil
+	case "tab", `\t`:
+		return '\t', nil
+	}
+	r := []rune(s)
+	if len(r) != 1 || !validDelimiter(r[0]) {
+		return 0, fmt.Errorf("invalid delimiter %q: must be a single character, tab, comma or semicolon", s)
+	}
+	return r[0], nil
+}
+
+// validDelimiter returns whether a character can separate CSV fields
+func validDelimiter(r rune) bool {
+	return r != '"' && r != '\r' && r != '\n' && r != '\uFEFF' && r != utf8.RuneError
+}
+
+// ParseColumns reads a comma-separated list of CSV columns
+func ParseColumns(s string) ([]string, error) {
+	var columns []string
+	for _, c := range strings.Split(s, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			columns = append(columns, c)
+		}
+	}
+	if err := (Options{Format: FormatCSV, Columns: columns}).Validate(); err != nil {
+		return nil, err
+	}
+	return columns, nil
+}
+
+// ContentType returns the media type of a format
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatHTML:
+		return "text/html; charset=utf-8"
+	}
+	return "application/json"
+}
+
+// FileName returns the name of an export file made at a time
+func FileName(format string, t time.Time) string {
+	return "eu-clams-globals-" + t.Format("2006-01-02-150405") + "." + format
+}
+
+// Write writes globals in the format of the options
+func Write(w io.Writer, globals []Global, report Report, o Options) error {
+	if err := o.Validate(); err != nil {
+		return err
+	}
+	switch o.Format {
+	case FormatCSV:
+		return writeCSV(w, globals, o)
+	case FormatJSON:
+		return writeJSON(w, globals, report)
+	case FormatNDJSON:
+		return writeNDJSON(w, globals)
+	default:
+		return writeHTML(w, globals, report)
+	}
+}
+
+// writeCSV writes a header and a row per global. The file starts with a byte order mark and
+// uses CRLF line endings for Excel, and text cells are kept from being read as formulas.
+func writeCSV(w io.Writer, globals []Global, o Options) error {
+	columns := o.Columns
+	if len(columns) == 0 {
+		columns = DefaultColumns
+	}
+	if _, err := io.WriteString(w, utf8BOM); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+	if o.Delimiter != 0 {
+		cw.Comma = o.Delimiter
+	}
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	row := make([]string, len(columns))
+	for _, g := range globals {
+		for i, c := range columns {
+			row[i] = csvField(g, c)
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvField returns the value of a column for a global
+func csvField(g Global, column string) string {
+	switch column {
+	case "id":
+		return g.ID
+	case "timestamp":
+		if t, err := time.Parse(time.RFC3339, g.Timestamp); err == nil {
+			return t.Format(csvTimeLayout)
+		}
+		return g.Timestamp
+	case "type":
+		return g.Type
+	case "player":
+		return safeCell(g.PlayerName)
+	case "team":
+		return safeCell(g.TeamName)
+	case "target":
+		return safeCell(g.Target)
+	case "value":
+		return strconv.FormatFloat(g.Value, 'f', 2, 64)
+	case "market_value":
+		return strconv.FormatFloat(g.MarketValue, 'f', 2, 64)
+	case "location":
+		return safeCell(g.Location)
+	case "is_hof":
+		return strconv.FormatBool(g.IsHof)
+	case "records":
+		return strings.Join(g.Records, " ")
+	case "raw_message":
+		return safeCell(g.RawMessage)
+	}
+	return ""
+}
+
+// safeCell prefixes text that a spreadsheet would run as a formula with an apostrophe
+func safeCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// writeJSON writes an indented document with the filters, totals and globals
+func writeJSON(w io.Writer, globals []Global, report Report) error {
+	doc := jsonDocument{
+		Generated: report.Generated.UTC().Format(time.RFC3339),
+		Filters:   report.Filters,
+		Count:     len(globals),
+		Globals:   globals,
+	}
+	if doc.Filters == nil {
+		doc.Filters = map[string]string{}
+	}
+	if doc.Globals == nil {
+		doc.Globals = []Global{}
+	}
+	for _, g := range globals {
+		doc.Total += g.Value
+		doc.TotalMarket += g.MarketValue
+		if g.IsHof {
+			doc.Hofs++
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// writeNDJSON writes one global per line
+func writeNDJSON(w io.Writer, globals []Global) error {
+	enc := json.NewEncoder(w)
+	for _, g := range globals {
+		if err := enc.Encode(g); err != nil {
+			return err
+		}
+	}
+	return nil
+}
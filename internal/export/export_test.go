@@ -0,0 +1,124 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"eu-clams/internal/model"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testGlobals = []Global{
+	{GlobalEntryJSON: model.GlobalEntryJSON{ID: "a", Timestamp: "2025-05-16T10:00:00Z", Type: "kill", PlayerName: "Jane Doe", Target: "Atrox", Value: 100, Location: "Calypso"}, MarketValue: 120},
+	{GlobalEntryJSON: model.GlobalEntryJSON{ID: "b", Timestamp: "2025-05-16T11:30:00Z", Type: "craft", PlayerName: "Jane Doe", Target: "=HYPERLINK(\"x\")", Value: 1500.5, IsHof: true}, MarketValue: 1500.5},
+}
+
+func TestWriteCSV(t *testing.T) {
+	t.Parallel()
+
+	var b strings.Builder
+	opts := Options{Format: FormatCSV, Columns: []string{"timestamp", "target", "value", "market_value", "is_hof"}, Delimiter: ';'}
+	if err := Write(&b, testGlobals, Report{}, opts); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	want := "\uFEFFtimestamp;target;value;market_value;is_hof\r\n" +
+		"2025-05-16 10:00:00;Atrox;100.00;120.00;false\r\n" +
+		"2025-05-16 11:30:00;\"'=HYPERLINK(\"\"x\"\")\";1500.50;1500.50;true\r\n"
+	if b.String() != want {
+		t.Errorf("CSV =\n%q\nwant\n%q", b.String(), want)
+	}
+
+	b.Reset()
+	Write(&b, nil, Report{}, Options{Format: FormatCSV})
+	if b.String() != "\uFEFF"+strings.Join(DefaultColumns, ",")+"\r\n" {
+		t.Errorf("CSV without columns = %q, want the default header", b.String())
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	t.Parallel()
+
+	var b strings.Builder
+	report := Report{Generated: time.Date(2025, 5, 17, 8, 0, 0, 0, time.UTC), Filters: map[string]string{"type": "kill"}}
+	if err := Write(&b, testGlobals, report, Options{Format: FormatJSON}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	var doc jsonDocument
+	if err := json.Unmarshal([]byte(b.String()), &doc); err != nil {
+		t.Fatalf("JSON export does not parse: %v", err)
+	}
+	if doc.Count != 2 || doc.Hofs != 1 || doc.Total != 1600.5 || doc.TotalMarket != 1620.5 || doc.Filters["type"] != "kill" || doc.Generated != "2025-05-17T08:00:00Z" {
+		t.Errorf("JSON document = %+v", doc)
+	}
+	if !strings.Contains(b.String(), "\n  \"globals\": [") {
+		t.Error("JSON export is not indented")
+	}
+
+	b.Reset()
+	if err := Write(&b, testGlobals, report, Options{Format: FormatNDJSON}); err != nil {
+		t.Fatalf("Write(ndjson) error = %v", err)
+	}
+	lines := bufio.NewScanner(strings.NewReader(b.String()))
+	count := 0
+	for lines.Scan() {
+		var g Global
+		if err := json.Unmarshal(lines.Bytes(), &g); err != nil || g.ID != testGlobals[count].ID || g.MarketValue != testGlobals[count].MarketValue {
+			t.Errorf("NDJSON line %d = %s", count+1, lines.Text())
+		}
+		count++
+	}
+	if count != 2 {
+		t.Errorf("NDJSON has %d lines, want 2", count)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	t.Parallel()
+
+	var b strings.Builder
+	report := Report{
+		Title:     "Report <of> Jane",
+		Generated: time.Date(2025, 5, 17, 8, 0, 0, 0, time.UTC),
+		Filters:   map[string]string{"min_value": "50"},
+		Stats:     model.Stats{TotalGlobals: 2, TotalHofs: 1, TotalValue: 1600.5, ByType: map[string]int{"kill": 1, "craft": 1}},
+	}
+	if err := Write(&b, testGlobals, report, Options{Format: FormatHTML}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	html := b.String()
+	for _, want := range []string{"Report &lt;of&gt; Jane", "min_value: 50", "1600.50 PED", `<tr class="hof">`, "2025-05-16 11:30:00", "=HYPERLINK(&#34;x&#34;)"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("HTML report lacks %s", want)
+		}
+	}
+	if strings.Contains(html, "<script") || strings.Contains(html, "<link") {
+		t.Error("HTML report loads other resources")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	t.Parallel()
+
+	for _, o := range []Options{
+		{Format: "xlsx"},
+		{Format: FormatCSV, Columns: []string{"colour"}},
+		{Format: FormatCSV, Delimiter: '"'},
+	} {
+		if err := o.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded", o)
+		}
+	}
+
+	for s, want := range map[string]rune{"": ',', "semicolon": ';', "tab": '\t', "|": '|'} {
+		if got, err := ParseDelimiter(s); err != nil || got != want {
+			t.Errorf("ParseDelimiter(%q) = %q, %v, want %q", s, got, err, want)
+		}
+	}
+	if _, err := ParseDelimiter("::"); err == nil {
+		t.Error("ParseDelimiter accepted two characters")
+	}
+	if columns, err := ParseColumns(" target, value "); err != nil || len(columns) != 2 || columns[0] != "target" {
+		t.Errorf("ParseColumns() = %v, %v", columns, err)
+	}
+}
@@ -0,0 +1,141 @@
+package export
+
+import (
+	"eu-clams/internal/model"
+	"fmt"
+	"html/template"
+	"io"
+	"sort"
+	"time"
+)
+
+// maxReportRows is the number of targets and locations listed in an HTML report
+const maxReportRows = 20
+
+// reportFilter is a filter shown in the HTML report
+type reportFilter struct {
+	Name, Value string
+}
+
+// reportType is the count of one global type in the HTML report
+type reportType struct {
+	Type  string
+	Count int
+}
+
+// reportPage is the data of the HTML report template
+type reportPage struct {
+	Title     string
+	Generated string
+	Filters   []reportFilter
+	Stats     model.Stats
+	Types     []reportType
+	Targets   []model.TargetStats
+	Locations []model.LocationStats
+	Globals   []reportGlobal
+}
+
+// reportGlobal is a global as listed in the HTML report
+type reportGlobal struct {
+	Global
+	Time string
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"ped":     func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"percent": func(v float64) string { return fmt.Sprintf("%.1f%%", v*100) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="UTF-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: Arial, sans-serif; margin: 2em; color: #222; }
+h1 { margin-bottom: 0.2em; }
+.meta { color: #666; margin-bottom: 1.5em; }
+.summary { display: flex; flex-wrap: wrap; gap: 1em; margin-bottom: 1.5em; }
+.summary div { background: #f4f6f8; border-radius: 6px; padding: 0.8em 1.2em; }
+.summary b { display: block; font-size: 1.4em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border-bottom: 1px solid #ddd; padding: 0.3em 0.8em; text-align: left; }
+th { background: #f4f6f8; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+tr.hof td { background: #fff8e1; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<div class="meta">Generated {{.Generated}}{{range .Filters}} &middot; {{.Name}}: {{.Value}}{{end}}</div>
+
+<div class="summary">
+<div>Globals<b>{{.Stats.TotalGlobals}}</b></div>
+<div>HoFs<b>{{.Stats.TotalHofs}}</b></div>
+<div>Total<b>{{ped .Stats.TotalValue}} PED</b></div>
+<div>Market value<b>{{ped .Stats.TotalMarketValue}} PED</b></div>
+<div>Highest<b>{{ped .Stats.HighestValue}} PED</b>{{.Stats.HighestValueItem}}</div>
+</div>
+
+{{if .Types}}<h2>By type</h2>
+<table>
+<tr><th>Type</th><th>Globals</th></tr>
+{{range .Types}}<tr><td>{{.Type}}</td><td class="num">{{.Count}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Targets}}<h2>Top targets</h2>
+<table>
+<tr><th>Target</th><th>Type</th><th>Globals</th><th>Total PED</th><th>Average</th><th>Max</th><th>HoFs</th><th>HoF rate</th></tr>
+{{range .Targets}}<tr><td>{{.Target}}</td><td>{{.Type}}</td><td class="num">{{.Count}}</td><td class="num">{{ped .TotalValue}}</td><td class="num">{{ped .AverageValue}}</td><td class="num">{{ped .MaxValue}}</td><td class="num">{{.Hofs}}</td><td class="num">{{percent .HofRate}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Locations}}<h2>Top locations</h2>
+<table>
+<tr><th>Location</th><th>Globals</th><th>Total PED</th><th>Average</th><th>HoFs</th></tr>
+{{range .Locations}}<tr><td>{{.Location}}</td><td class="num">{{.Count}}</td><td class="num">{{ped .TotalValue}}</td><td class="num">{{ped .AverageValue}}</td><td class="num">{{.Hofs}}</td></tr>
+{{end}}</table>
+{{end}}
+<h2>Globals</h2>
+<table>
+<tr><th>Time</th><th>Type</th><th>Player</th><th>Target</th><th>PED</th><th>Location</th><th>HoF</th></tr>
+{{range .Globals}}<tr{{if .IsHof}} class="hof"{{end}}><td>{{.Time}}</td><td>{{.Type}}</td><td>{{.PlayerName}}{{if .TeamName}} ({{.TeamName}}){{end}}</td><td>{{.Target}}</td><td class="num">{{ped .Value}}</td><td>{{.Location}}</td><td>{{if .IsHof}}HoF{{end}}</td></tr>
+{{else}}<tr><td colspan="7">No globals match the filters</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// writeHTML writes a standalone report with a summary, the top targets and locations and
+// every global; it loads nothing from elsewhere, so it can be mailed or archived
+func writeHTML(w io.Writer, globals []Global, report Report) error {
+	page := reportPage{
+		Title:     report.Title,
+		Generated: report.Generated.Format(csvTimeLayout),
+		Stats:     report.Stats,
+		Targets:   report.Stats.ByTarget,
+		Locations: report.Stats.Locations,
+	}
+	if page.Title == "" {
+		page.Title = "EU-CLAMS Globals"
+	}
+	for name, value := range report.Filters {
+		page.Filters = append(page.Filters, reportFilter{name, value})
+	}
+	sort.Slice(page.Filters, func(i, j int) bool { return page.Filters[i].Name < page.Filters[j].Name })
+	for t, n := range report.Stats.ByType {
+		page.Types = append(page.Types, reportType{t, n})
+	}
+	sort.Slice(page.Types, func(i, j int) bool { return page.Types[i].Type < page.Types[j].Type })
+	if len(page.Targets) > maxReportRows {
+		page.Targets = page.Targets[:maxReportRows]
+	}
+	if len(page.Locations) > maxReportRows {
+		page.Locations = page.Locations[:maxReportRows]
+	}
+	for _, g := range globals {
+		entry := reportGlobal{Global: g, Time: g.Timestamp}
+		if t, err := time.Parse(time.RFC3339, g.Timestamp); err == nil {
+			entry.Time = t.Format(csvTimeLayout)
+		}
+		page.Globals = append(page.Globals, entry)
+	}
+	return reportTemplate.Execute(w, page)
+}
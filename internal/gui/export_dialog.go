@@ -0,0 +1,120 @@
+package gui
+
+import (
+	"eu-clams/internal/export"
+	"eu-clams/internal/model"
+	"eu-clams/internal/storage"
+	"eu-clams/src/service"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// exportDelimiters are the CSV delimiters offered by the export dialog
+var exportDelimiters = map[string]rune{"Comma": ',', "Semicolon": ';', "Tab": '\t'}
+
+// showExportDialog asks for a format, CSV options and filters, then for the file to export
+// the matching globals to
+func (g *MainGUI) showExportDialog() {
+	formatSelect := widget.NewSelect(export.Formats, nil)
+	formatSelect.SetSelected(export.FormatCSV)
+	columnsCheck := widget.NewCheckGroup(export.Columns, nil)
+	columnsCheck.Horizontal = true
+	columnsCheck.SetSelected(export.DefaultColumns)
+	delimiterSelect := widget.NewSelect([]string{"Comma", "Semicolon", "Tab"}, nil)
+	delimiterSelect.SetSelected("Comma")
+	formatSelect.OnChanged = func(format string) {
+		if format == export.FormatCSV {
+			columnsCheck.Enable()
+			delimiterSelect.Enable()
+		} else {
+			columnsCheck.Disable()
+			delimiterSelect.Disable()
+		}
+	}
+
+	fromEntry := widget.NewEntry()
+	fromEntry.SetPlaceHolder("2006-01-02")
+	toEntry := widget.NewEntry()
+	toEntry.SetPlaceHolder("2006-01-02")
+	typeSelect := widget.NewSelect([]string{"", "kill", "craft", "find"}, nil)
+	targetEntry := widget.NewEntry()
+	targetEntry.SetPlaceHolder("Part of the target name")
+	minValueEntry := widget.NewEntry()
+	minValueEntry.SetPlaceHolder("0")
+	tierSelect := widget.NewSelect([]string{"", storage.TierGlobal, storage.TierHof}, nil)
+
+	form := widget.NewForm(
+		widget.NewFormItem("Format", formatSelect),
+		widget.NewFormItem("CSV columns", columnsCheck),
+		widget.NewFormItem("CSV delimiter", delimiterSelect),
+		widget.NewFormItem("From", fromEntry),
+		widget.NewFormItem("To", toEntry),
+		widget.NewFormItem("Type", typeSelect),
+		widget.NewFormItem("Target", targetEntry),
+		widget.NewFormItem("Minimum PED", minValueEntry),
+		widget.NewFormItem("Tier", tierSelect),
+	)
+
+	d := dialog.NewCustomConfirm("Export Globals", "Export…", "Cancel", form, func(ok bool) {
+		if !ok {
+			return
+		}
+		opts := export.Options{Format: formatSelect.Selected}
+		if opts.Format == export.FormatCSV {
+			opts.Columns = columnsCheck.Selected
+			opts.Delimiter = exportDelimiters[delimiterSelect.Selected]
+			if len(opts.Columns) == 0 {
+				dialog.ShowError(fmt.Errorf("choose at least one column"), g.mainWindow)
+				return
+			}
+		}
+		q := storage.GlobalQuery{Type: typeSelect.Selected, Target: targetEntry.Text, Tier: tierSelect.Selected}
+		var err error
+		if q.From, q.To, err = model.ParseTimeRange(strings.TrimSpace(fromEntry.Text), strings.TrimSpace(toEntry.Text)); err != nil {
+			dialog.ShowError(err, g.mainWindow)
+			return
+		}
+		if v := strings.TrimSpace(minValueEntry.Text); v != "" {
+			if q.MinValue, err = strconv.ParseFloat(v, 64); err != nil || q.MinValue < 0 {
+				dialog.ShowError(fmt.Errorf("invalid minimum value: must be a positive number"), g.mainWindow)
+				return
+			}
+		}
+		g.exportToFile(q, opts)
+	}, g.mainWindow)
+	d.Resize(fyne.NewSize(700, 450))
+	d.Show()
+}
+
+// exportToFile asks for a file and writes the export to it
+func (g *MainGUI) exportToFile(q storage.GlobalQuery, opts export.Options) {
+	db, err := g.getDatabase()
+	if err != nil {
+		dialog.ShowError(err, g.mainWindow)
+		return
+	}
+
+	save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		err = service.ExportGlobals(writer, db, q, opts, time.Now())
+		if closeErr := writer.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			dialog.ShowError(err, g.mainWindow)
+			g.log.Error("Failed to export globals: %v", err)
+			return
+		}
+		g.statusLabel.SetText("Exported globals to " + writer.URI().Path())
+	}, g.mainWindow)
+	save.SetFileName(export.FileName(opts.Format, time.Now()))
+	save.Show()
+}
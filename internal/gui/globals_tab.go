@@ -0,0 +1,181 @@
+package gui
+
+import (
+	"eu-clams/internal/storage"
+	"eu-clams/src/service"
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// getDatabase returns the database shared by the whole GUI, loading it from disk the first
+// time. The data processors and the web server all work on this one instance, so a change
+// made through one of them is not lost when another saves.
+func (g *MainGUI) getDatabase() (*storage.EntropyDB, error) {
+	g.dbMu.Lock()
+	defer g.dbMu.Unlock()
+	if g.db != nil {
+		return g.db, nil
+	}
+
+	// Initialize data processor service just to get database
+	tempService := service.NewDataProcessorService(g.log, g.config, "")
+	if err := tempService.Initialize(); err != nil {
+		return nil, fmt.Errorf("failed to initialize data processor: %w", err)
+	}
+	g.db = tempService.GetDatabase()
+	return g.db, nil
+}
+
+// loadedDatabase returns the shared database, or nil if nothing loaded it yet
+func (g *MainGUI) loadedDatabase() *storage.EntropyDB {
+	g.dbMu.Lock()
+	defer g.dbMu.Unlock()
+	return g.db
+}
+
+// createGlobalsTab creates the content for the globals tab, where stored globals can be corrected or deleted
+func (g *MainGUI) createGlobalsTab() fyne.CanvasObject {
+	var db *storage.EntropyDB
+	var globals []storage.GlobalEntry
+	var selected *storage.GlobalEntry
+
+	// Form fields for the selected global
+	typeSelect := widget.NewSelect([]string{"kill", "craft", "find"}, nil)
+	playerEntry := widget.NewEntry()
+	teamEntry := widget.NewEntry()
+	targetEntry := widget.NewEntry()
+	valueEntry := widget.NewEntry()
+	locationEntry := widget.NewEntry()
+	hofCheck := widget.NewCheck("", nil)
+	rawLabel := widget.NewLabel("")
+	rawLabel.Wrapping = fyne.TextWrapWord
+
+	list := widget.NewList(
+		func() int { return len(globals) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			e := globals[id]
+			record := ""
+			if len(e.Records) > 0 {
+				record = "  \u2605 record"
+			}
+			obj.(*widget.Label).SetText(fmt.Sprintf("%s  %s  %s  %.0f PED%s",
+				e.Timestamp.Format("2006-01-02 15:04"), e.Type, e.Target, e.Value, record))
+		},
+	)
+
+	form := &widget.Form{
+		Items: []*widget.FormItem{
+			{Text: "Type", Widget: typeSelect},
+			{Text: "Player", Widget: playerEntry},
+			{Text: "Team", Widget: teamEntry},
+			{Text: "Target", Widget: targetEntry, HintText: "Creature, item or resource"},
+			{Text: "Value (PED)", Widget: valueEntry},
+			{Text: "Location", Widget: locationEntry},
+			{Text: "Hall of Fame", Widget: hofCheck},
+			{Text: "Raw Message", Widget: rawLabel},
+		},
+		SubmitText: "Save Changes",
+	}
+	form.Hide()
+	var deleteButton *widget.Button
+
+	reload := func() {
+		var err error
+		db, err = g.getDatabase()
+		if err != nil {
+			dialog.ShowError(err, g.mainWindow)
+			return
+		}
+		db.RLock()
+		globals = db.GetPlayerGlobals()
+		db.RUnlock()
+		selected = nil
+		list.UnselectAll()
+		list.Refresh()
+		form.Hide()
+		deleteButton.Hide()
+	}
+
+	list.OnSelected = func(id widget.ListItemID) {
+		e := globals[id]
+		selected = &e
+		typeSelect.SetSelected(e.Type)
+		playerEntry.SetText(e.PlayerName)
+		teamEntry.SetText(e.TeamName)
+		targetEntry.SetText(e.Target)
+		valueEntry.SetText(strconv.FormatFloat(e.Value, 'f', -1, 64))
+		locationEntry.SetText(e.Location)
+		hofCheck.SetChecked(e.IsHof)
+		rawLabel.SetText(e.RawMessage)
+		form.Show()
+		deleteButton.Show()
+	}
+
+	form.OnSubmit = func() {
+		if selected == nil {
+			return
+		}
+		value, err := strconv.ParseFloat(valueEntry.Text, 64)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("invalid value: must be a number"), g.mainWindow)
+			return
+		}
+		edit := storage.GlobalEdit{
+			Type:       &typeSelect.Selected,
+			PlayerName: &playerEntry.Text,
+			TeamName:   &teamEntry.Text,
+			Target:     &targetEntry.Text,
+			Value:      &value,
+			Location:   &locationEntry.Text,
+			IsHof:      &hofCheck.Checked,
+		}
+		if _, err := service.EditGlobal(db, selected.ID, edit, "gui", g.log); err != nil {
+			dialog.ShowError(err, g.mainWindow)
+			return
+		}
+		g.statusLabel.SetText("Global updated")
+		reload()
+	}
+
+	deleteButton = widget.NewButtonWithIcon("Delete Global", theme.DeleteIcon(), func() {
+		if selected == nil {
+			return
+		}
+		entry := *selected
+		dialog.ShowConfirm("Delete Global",
+			fmt.Sprintf("Delete this global?\n\n%s", entry.RawMessage),
+			func(confirmed bool) {
+				if !confirmed {
+					return
+				}
+				if err := service.DeleteGlobal(db, entry.ID, "gui", g.log); err != nil {
+					dialog.ShowError(err, g.mainWindow)
+					return
+				}
+				g.statusLabel.SetText("Global deleted")
+				reload()
+			}, g.mainWindow)
+	})
+
+	deleteButton.Hide()
+
+	refreshButton := widget.NewButtonWithIcon("Reload", theme.ViewRefreshIcon(), reload)
+
+	editor := container.NewVBox(form, deleteButton)
+	split := container.NewHSplit(list, container.NewVScroll(editor))
+	split.SetOffset(0.45)
+
+	return container.NewBorder(
+		widget.NewLabelWithStyle("Stored Globals", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		container.NewHBox(refreshButton),
+		nil, nil,
+		split,
+	)
+}
@@ -0,0 +1,150 @@
+package gui
+
+import (
+	"eu-clams/internal/model"
+	"eu-clams/internal/stats"
+	"eu-clams/src/service"
+	"fmt"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// recentAchievements is the number of latest achievements shown on the dashboard
+const recentAchievements = 5
+
+// createGoalsPanel creates the dashboard panel with goal progress and the latest achievements
+func (g *MainGUI) createGoalsPanel() fyne.CanvasObject {
+	g.goalsLabel = widget.NewLabel("")
+	g.goalsLabel.TextStyle = fyne.TextStyle{Monospace: true}
+
+	refreshButton := widget.NewButtonWithIcon("Reload", theme.ViewRefreshIcon(), g.refreshGoals)
+	addButton := widget.NewButtonWithIcon("Add Goal", theme.ContentAddIcon(), g.showAddGoalDialog)
+	removeButton := widget.NewButtonWithIcon("Remove Goal", theme.ContentRemoveIcon(), g.showRemoveGoalDialog)
+
+	g.refreshGoals()
+
+	return widget.NewCard("Goals & Achievements", "", container.NewVBox(
+		g.goalsLabel,
+		container.NewHBox(refreshButton, addButton, removeButton),
+	))
+}
+
+// refreshGoals shows the current goal progress and the latest achievements
+func (g *MainGUI) refreshGoals() {
+	db, err := g.getDatabase()
+	if err != nil {
+		g.goalsLabel.SetText(fmt.Sprintf("Database not available: %v", err))
+		return
+	}
+
+	statsService := service.NewStatsService(g.log, db, g.config.PlayerName, g.config.TeamName)
+	unlocked, locked := statsService.GetAchievements()
+	if len(unlocked) > recentAchievements {
+		unlocked = unlocked[len(unlocked)-recentAchievements:]
+	}
+	g.goalsLabel.SetText(stats.FormatGoalProgress(statsService.GetGoalProgress()) + "\n" +
+		stats.FormatAchievements(unlocked, nil) +
+		fmt.Sprintf("  %d achievements still locked\n", len(locked)))
+}
+
+// showAlert shows a personal record, unlocked achievement or completed goal while monitoring
+func (g *MainGUI) showAlert(message string) {
+	g.app.SendNotification(fyne.NewNotification("EU-CLAMS", message))
+	fyne.Do(func() {
+		g.statusLabel.SetText(message)
+		g.refreshGoals()
+	})
+}
+
+// showAddGoalDialog asks for a new goal and stores it
+func (g *MainGUI) showAddGoalDialog() {
+	nameEntry := widget.NewEntry()
+	nameEntry.SetPlaceHolder("Optional, e.g. Atrox hunter")
+	metricSelect := widget.NewSelect([]string{model.GoalMetricGlobals, model.GoalMetricHofs, model.GoalMetricValue}, nil)
+	metricSelect.SetSelected(model.GoalMetricGlobals)
+	thresholdEntry := widget.NewEntry()
+	thresholdEntry.SetPlaceHolder("e.g. 50")
+	typeSelect := widget.NewSelect([]string{"", "kill", "craft", "find"}, nil)
+	targetEntry := widget.NewEntry()
+	locationEntry := widget.NewEntry()
+	periodSelect := widget.NewSelect([]string{"", model.IntervalDay, model.IntervalWeek, model.IntervalMonth}, nil)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Count", metricSelect),
+		widget.NewFormItem("Amount", thresholdEntry),
+		widget.NewFormItem("Type", typeSelect),
+		widget.NewFormItem("Target", targetEntry),
+		widget.NewFormItem("Location", locationEntry),
+		widget.NewFormItem("Period", periodSelect),
+	}
+
+	dialog.ShowForm("Add Goal", "Add", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		threshold, err := strconv.ParseFloat(thresholdEntry.Text, 64)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("invalid amount: %s", thresholdEntry.Text), g.mainWindow)
+			return
+		}
+		db, err := g.getDatabase()
+		if err != nil {
+			dialog.ShowError(err, g.mainWindow)
+			return
+		}
+
+		goal := model.Goal{
+			Name:      nameEntry.Text,
+			Metric:    metricSelect.Selected,
+			Threshold: threshold,
+			Type:      typeSelect.Selected,
+			Target:    targetEntry.Text,
+			Location:  locationEntry.Text,
+			Period:    periodSelect.Selected,
+		}
+		if _, err := service.AddGoal(db, goal, g.log); err != nil {
+			dialog.ShowError(err, g.mainWindow)
+			return
+		}
+		g.refreshGoals()
+	}, g.mainWindow)
+}
+
+// showRemoveGoalDialog asks which goal to remove
+func (g *MainGUI) showRemoveGoalDialog() {
+	db, err := g.getDatabase()
+	if err != nil {
+		dialog.ShowError(err, g.mainWindow)
+		return
+	}
+	db.RLock()
+	goals := append([]model.Goal(nil), db.Goals...)
+	db.RUnlock()
+	if len(goals) == 0 {
+		dialog.ShowInformation("Remove Goal", "No goals set", g.mainWindow)
+		return
+	}
+
+	options := make([]string, len(goals))
+	for i, goal := range goals {
+		options[i] = goal.ID + ": " + goal.Name
+	}
+	goalSelect := widget.NewSelect(options, nil)
+
+	dialog.ShowForm("Remove Goal", "Remove", "Cancel", []*widget.FormItem{widget.NewFormItem("Goal", goalSelect)}, func(ok bool) {
+		if !ok || goalSelect.SelectedIndex() < 0 {
+			return
+		}
+		if err := service.RemoveGoal(db, goals[goalSelect.SelectedIndex()].ID, g.log); err != nil {
+			dialog.ShowError(err, g.mainWindow)
+			return
+		}
+		g.refreshGoals()
+	}, g.mainWindow)
+}
@@ -1,8 +1,10 @@
 package gui
 
 import (
+	"eu-clams/internal/analysis"
 	"eu-clams/internal/config"
 	"eu-clams/internal/logger"
+	"eu-clams/internal/stats"
 	"eu-clams/internal/storage"
 	"eu-clams/pkg/screenshot"
 	"eu-clams/src/service"
@@ -10,8 +12,10 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strconv"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -29,13 +33,17 @@ type MainGUI struct {
 	config      config.Config
 	log         *logger.Logger
 	dataService *service.DataProcessorService
+	db          *storage.EntropyDB  // Shared by the data processors, the web server and the tabs, see getDatabase
+	dbMu        sync.Mutex          // Guards loading db
 	webService  *service.WebService // Track the web service instance
+	hubClient   *service.HubClient  // Pushes new globals to the team hub, if one is configured
 
 	// Status variables
 	isMonitoring  bool
 	statusLabel   *widget.Label
 	monitorButton *widget.Button
 	infoLabel     *widget.Label
+	goalsLabel    *widget.Label
 
 	// Configuration reloading
 	configPath     string
@@ -136,6 +144,7 @@ func (g *MainGUI) createUI() {
 		widget.NewLabelWithStyle("EU-CLAMS Dashboard", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
 		boxesContainer,
 		buttonsContainer,
+		g.createGoalsPanel(),
 	)
 
 	// Create configuration tab content
@@ -143,6 +152,9 @@ func (g *MainGUI) createUI() {
 
 	// Create statistics tab content
 	statsContent := g.createStatsTab()
+	// Create globals tab content
+	globalsContent := g.createGlobalsTab()
+	sessionsContent := g.createSessionsTab()
 	// Create debug tab content
 	debugContent := g.createDebugTab()
 
@@ -151,6 +163,8 @@ func (g *MainGUI) createUI() {
 		container.NewTabItemWithIcon("Dashboard", theme.HomeIcon(), dashboardContent),
 		container.NewTabItemWithIcon("Configuration", theme.SettingsIcon(), configContent),
 		container.NewTabItemWithIcon("Statistics", theme.DocumentIcon(), statsContent),
+		container.NewTabItemWithIcon("Globals", theme.ListIcon(), globalsContent),
+		container.NewTabItemWithIcon("Sessions", theme.HistoryIcon(), sessionsContent),
 		container.NewTabItemWithIcon("Debug", theme.HelpIcon(), debugContent),
 	)
 
@@ -212,12 +226,27 @@ func (g *MainGUI) startMonitoring() {
 
 // startMonitoringWithPath starts monitoring with the specified chat log path
 func (g *MainGUI) startMonitoringWithPath(chatLogPath string) {
-	// Initialize data processor service
+	// Initialize data processor service on the database the web server and tabs show
+	db, err := g.getDatabase()
+	if err != nil {
+		dialog.ShowError(err, g.mainWindow)
+		return
+	}
 	g.dataService = service.NewDataProcessorService(g.log, g.config, chatLogPath)
+	g.dataService.UseDatabase(db)
 	if err := g.dataService.Initialize(); err != nil {
 		dialog.ShowError(fmt.Errorf("failed to initialize data processor: %w", err), g.mainWindow)
 		return
 	}
+	g.dataService.SetAlertHandler(g.showAlert)
+	g.refreshGoals()
+	if g.hubClient == nil {
+		hubClient, err := service.StartHubClient(g.log, g.config)
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("failed to start pushing to the hub: %w", err), g.mainWindow)
+		}
+		g.hubClient = hubClient
+	}
 	// Update UI on the main thread first
 	g.statusLabel.SetText("Monitoring chat log...")
 
@@ -260,8 +289,14 @@ func (g *MainGUI) importChatLog() {
 			return
 		}
 
-		// Initialize data processor service for import only
+		// Initialize data processor service for import only, on the shared database
+		db, err := g.getDatabase()
+		if err != nil {
+			dialog.ShowError(err, g.mainWindow)
+			return
+		}
 		dataService := service.NewDataProcessorService(g.log, g.config, chatLogPath)
+		dataService.UseDatabase(db)
 		if err := dataService.Initialize(); err != nil {
 			dialog.ShowError(fmt.Errorf("failed to initialize data processor: %w", err), g.mainWindow)
 			return
@@ -311,7 +346,7 @@ func (g *MainGUI) importChatLog() {
 func (g *MainGUI) initWebServer() (string, error) {
 	// If web service is already running, just return its URL
 	if g.webService != nil {
-		return fmt.Sprintf("http://localhost:%d", g.config.WebServerPort), nil
+		return g.webService.URL(), nil
 	}
 
 	// Check if a web service for this port is already registered (started by CLI mode)
@@ -323,7 +358,7 @@ func (g *MainGUI) initWebServer() (string, error) {
 	if existingService := service.GetWebServiceByPort(webPort); existingService != nil {
 		g.log.Info("Found existing web service on port %d, reusing it", webPort)
 		g.webService = existingService
-		return fmt.Sprintf("http://localhost:%d", webPort), nil
+		return existingService.URL(), nil
 	}
 
 	// Validate configuration
@@ -331,42 +366,45 @@ func (g *MainGUI) initWebServer() (string, error) {
 		return "", fmt.Errorf("player name is required")
 	}
 
-	// Get database from existing service or create new one
-	var db *storage.EntropyDB
-	if g.dataService != nil {
-		db = g.dataService.GetDatabase()
-	} else {
-		// Initialize data processor service just to get database
-		tempService := service.NewDataProcessorService(g.log, g.config, "")
-		if err := tempService.Initialize(); err != nil {
-			return "", fmt.Errorf("failed to initialize data processor: %w", err)
-		}
-		db = tempService.GetDatabase()
+	// Serve the database the data processor works on, so edits and pushes are not lost
+	db, err := g.getDatabase()
+	if err != nil {
+		return "", err
 	}
 	// Port is already set above
 	// No need to redefine webPort here
 
 	// Initialize web service if it's enabled in the config
-	g.webService = service.NewWebService(g.log, db, g.config.PlayerName, g.config.TeamName, webPort)
-	if err := g.webService.Initialize(); err != nil {
-		g.webService = nil
+	webService := service.NewWebService(g.log, db, g.config.PlayerName, g.config.TeamName, webPort)
+	if err := service.ConfigureWebService(webService, g.config); err != nil {
+		return "", err
+	}
+	if err := webService.Initialize(); err != nil {
 		return "", fmt.Errorf("failed to initialize web server: %w", err)
 	}
+	// Open the port now so that a port in use is reported to the caller
+	if err := webService.Listen(); err != nil {
+		webService.Stop()
+		return "", err
+	}
+	g.webService = webService
 
 	// Start the web server in the background
 	go func() {
 		g.log.Info("Starting web server on port %d", webPort)
-		if err := g.webService.Run(); err != nil {
+		if err := webService.Run(); err != nil {
 			g.log.Error("Web server error: %v", err)
 			fyne.Do(func() {
 				dialog.ShowError(fmt.Errorf("web server error: %w", err), g.mainWindow)
+				if g.webService == webService {
+					g.webService = nil // Clear the reference if it stopped
+				}
 			})
-			g.webService = nil // Clear the reference if it fails to start
 		}
 	}()
 
 	// Return the URL to the web stats
-	return fmt.Sprintf("http://localhost:%d", webPort), nil
+	return webService.URL(), nil
 }
 
 // startWebServer launches the web browser to the web stats
@@ -388,12 +426,7 @@ func (g *MainGUI) startWebServer(showDialog bool) {
 			return
 		}
 	} else {
-		// Use port from config for existing web service
-		webPort := g.config.WebServerPort
-		if webPort <= 0 {
-			webPort = 8080 // Default port
-		}
-		url = fmt.Sprintf("http://localhost:%d", webPort)
+		url = g.webService.URL()
 	}
 
 	// If showing dialog was requested, show information dialog
@@ -471,10 +504,20 @@ func (g *MainGUI) createConfigTab() fyne.CanvasObject {
 	enableWebServerCheck := widget.NewCheck("", nil)
 	enableWebServerCheck.SetChecked(g.config.EnableWebServer)
 
+	captureUniverseCheck := widget.NewCheck("", nil)
+	captureUniverseCheck.SetChecked(g.config.CaptureUniverse)
+
 	webServerPortEntry := widget.NewEntry()
 	webServerPortEntry.SetText(strconv.Itoa(g.config.WebServerPort))
 	webServerPortEntry.SetPlaceHolder("8080")
 
+	webBindAddressEntry := widget.NewEntry()
+	webBindAddressEntry.SetText(g.config.WebBindAddress)
+	webBindAddressEntry.SetPlaceHolder(service.DefaultWebBindAddress)
+
+	webTLSCheck := widget.NewCheck("", nil)
+	webTLSCheck.SetChecked(g.config.WebTLS)
+
 	// Create buttons for file selection
 	dbPathButton := widget.NewButtonWithIcon("Browse", theme.FolderOpenIcon(), func() {
 		dialog.ShowFileOpen(func(uri fyne.URIReadCloser, err error) {
@@ -519,8 +562,14 @@ func (g *MainGUI) createConfigTab() fyne.CanvasObject {
 			{Text: "Screenshot Delay", Widget: screenshotDelayEntry, HintText: "Delay in seconds before taking a screenshot (default: 0.6)"},
 			{Text: "Game Window Title", Widget: gameWindowTitleEntry, HintText: "Beginning of Entropia Universe window title"},
 			{Text: "Enable Web Server", Widget: enableWebServerCheck, HintText: "Start a web server to view statistics"}, {Text: "Web Server Port", Widget: webServerPortEntry, HintText: "Port for the web server (default: 8080)"},
+			{Text: "Web Bind Address", Widget: webBindAddressEntry, HintText: "127.0.0.1 for this computer only, 0.0.0.0 for the whole network"},
+			{Text: "Web HTTPS", Widget: webTLSCheck, HintText: "Serve HTTPS, with a self-signed certificate unless web_tls_cert is set"},
+			{Text: "Capture All Globals", Widget: captureUniverseCheck, HintText: "Also store everyone else's globals for leaderboards"},
+			{Text: "Markups", Widget: widget.NewButtonWithIcon("Edit Markups", theme.DocumentCreateIcon(), g.showMarkupsDialog), HintText: "Market value of items and resources, saved right away"},
 		},
 		OnSubmit: func() {
+			oldConfig := g.config
+
 			// Update configuration values from form fields
 			g.config.PlayerName = playerNameEntry.Text
 			g.config.TeamName = teamNameEntry.Text
@@ -529,6 +578,9 @@ func (g *MainGUI) createConfigTab() fyne.CanvasObject {
 			g.config.ScreenshotDirectory = screenshotDirEntry.Text
 			g.config.GameWindowTitle = gameWindowTitleEntry.Text
 			g.config.EnableWebServer = enableWebServerCheck.Checked
+			g.config.CaptureUniverse = captureUniverseCheck.Checked
+			g.config.WebBindAddress = webBindAddressEntry.Text
+			g.config.WebTLS = webTLSCheck.Checked
 
 			// Convert screenshot delay from string to float64
 			screenshotDelay := 0.6 // Default delay
@@ -565,7 +617,7 @@ func (g *MainGUI) createConfigTab() fyne.CanvasObject {
 			g.updateUIFromConfig()
 
 			// Update services if needed (pass old config for comparison)
-			g.updateServicesFromConfig(g.config)
+			g.updateServicesFromConfig(oldConfig)
 
 			// Show success message
 			dialog.ShowInformation("Success", "Configuration saved successfully", g.mainWindow)
@@ -694,6 +746,10 @@ func (g *MainGUI) createStatsTab() fyne.CanvasObject {
 	statsScroll := container.NewScroll(statsLabel)
 	statsScroll.SetMinSize(fyne.NewSize(500, 400))
 
+	// Maximum gap between globals counted as a streak
+	streakWindowSelect := widget.NewSelect([]string{"5m", "10m", "30m", "1h"}, nil)
+	streakWindowSelect.SetSelected("10m")
+
 	// Create a refresh button
 	refreshButton := widget.NewButtonWithIcon("Refresh Statistics", theme.ViewRefreshIcon(), func() {
 		// Create a progress dialog using the recommended approach
@@ -716,21 +772,14 @@ func (g *MainGUI) createStatsTab() fyne.CanvasObject {
 			}
 
 			// Get database
-			var db *storage.EntropyDB
-			if g.dataService != nil {
-				db = g.dataService.GetDatabase()
-			} else {
-				// Initialize data processor service just to get database
-				tempService := service.NewDataProcessorService(g.log, g.config, "")
-				if err := tempService.Initialize(); err != nil {
-					g.log.Error("Failed to initialize data processor: %v", err)
-					fyne.Do(func() {
-						progressDialog.Hide()
-						statsLabel.SetText("Error: Failed to initialize data processor")
-					})
-					return
-				}
-				db = tempService.GetDatabase()
+			db, err := g.getDatabase()
+			if err != nil {
+				g.log.Error("%v", err)
+				fyne.Do(func() {
+					progressDialog.Hide()
+					statsLabel.SetText("Error: Failed to initialize data processor")
+				})
+				return
 			}
 
 			// Initialize stats service
@@ -748,6 +797,13 @@ func (g *MainGUI) createStatsTab() fyne.CanvasObject {
 			statsData := statsService.GenerateStats()
 			statsText := statsService.FormatStatsReport(statsData)
 
+			streakWindow, err := time.ParseDuration(streakWindowSelect.Selected)
+			if err != nil {
+				streakWindow = analysis.DefaultStreakWindow
+			}
+			statsText += "\n" + stats.FormatDroughtReport(statsService.AnalyzeDroughts(streakWindow))
+			statsText += "\n" + stats.FormatRecordBook(statsService.GetRecordBook())
+
 			// Update the stats label on the main thread
 			fyne.Do(func() {
 				progressDialog.Hide()
@@ -760,7 +816,8 @@ func (g *MainGUI) createStatsTab() fyne.CanvasObject {
 	content := container.NewVBox(
 		widget.NewLabelWithStyle("EU-CLAMS Statistics", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
 		statsScroll,
-		container.NewHBox(layout.NewSpacer(), refreshButton),
+		container.NewHBox(widget.NewLabel("Streak window:"), streakWindowSelect, layout.NewSpacer(),
+			widget.NewButtonWithIcon("Export…", theme.DocumentSaveIcon(), g.showExportDialog), refreshButton),
 	)
 
 	return content
@@ -785,6 +842,11 @@ func (g *MainGUI) Close() {
 		g.webService = nil
 	}
 
+	if g.hubClient != nil {
+		g.hubClient.Stop()
+		g.hubClient = nil
+	}
+
 	g.log.Info("Application closing")
 }
 
@@ -895,7 +957,12 @@ func (g *MainGUI) updateUIFromConfig() {
 func (g *MainGUI) updateServicesFromConfig(oldConfig config.Config) {
 	// Restart web server if web server settings changed
 	if oldConfig.EnableWebServer != g.config.EnableWebServer ||
-		oldConfig.WebServerPort != g.config.WebServerPort {
+		oldConfig.WebServerPort != g.config.WebServerPort ||
+		oldConfig.WebBindAddress != g.config.WebBindAddress ||
+		oldConfig.WebTLS != g.config.WebTLS ||
+		oldConfig.WebTLSCert != g.config.WebTLSCert ||
+		oldConfig.WebTLSKey != g.config.WebTLSKey ||
+		oldConfig.WebThemeDir != g.config.WebThemeDir {
 
 		// Stop old web server if it was running
 		if g.webService != nil {
@@ -908,17 +975,43 @@ func (g *MainGUI) updateServicesFromConfig(oldConfig config.Config) {
 			url, err := g.initWebServer()
 			if err != nil {
 				g.log.Error("Failed to restart web server: %v", err)
+				fyne.Do(func() {
+					dialog.ShowError(fmt.Errorf("failed to restart web server: %w", err), g.mainWindow)
+				})
 			} else {
 				g.log.Info("Web server restarted at %s", url)
 			}
 		}
 	}
 
-	// Update data service if it exists and database path changed
-	if g.dataService != nil && oldConfig.DatabasePath != g.config.DatabasePath {
-		// Note: DataProcessorService would need methods to update its config
-		// For now, we'll just log that the database path changed
-		g.log.Info("Database path changed, restart monitoring to apply changes")
+	// Tokens and origins apply to the running web server
+	if g.webService != nil && (!reflect.DeepEqual(oldConfig.WebTokens, g.config.WebTokens) ||
+		!reflect.DeepEqual(oldConfig.WebAllowedOrigins, g.config.WebAllowedOrigins)) {
+		if err := g.webService.SetAuth(g.config.WebTokens, g.config.WebAllowedOrigins); err != nil {
+			g.log.Error("Keeping the previous web server access settings: %v", err)
+		}
+	}
+
+	// The web server and the tabs keep the loaded database
+	if oldConfig.DatabasePath != g.config.DatabasePath {
+		g.log.Info("Database path changed, restart EU-CLAMS to load the new database")
+	}
+
+	db := g.loadedDatabase()
+	if db == nil {
+		return
+	}
+
+	// Universe capture can be switched while monitoring
+	db.Lock()
+	db.SetCaptureUniverse(g.config.CaptureUniverse)
+	db.Unlock()
+
+	// Markups edited in the file revalue the statistics right away
+	if !reflect.DeepEqual(oldConfig.Markups, g.config.Markups) {
+		if err := service.ApplyMarkups(db, g.config, g.log); err != nil {
+			g.log.Warn("Ignoring configured markups: %v", err)
+		}
 	}
 }
 
@@ -0,0 +1,118 @@
+package gui
+
+import (
+	"eu-clams/internal/model"
+	"eu-clams/internal/stats"
+	"eu-clams/src/service"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// maxMissingMarkups is the number of targets without a markup listed in the markups dialog
+const maxMissingMarkups = 10
+
+// showMarkupsDialog edits the markups as CSV lines of item and markup, with the targets that
+// still lack one listed below, and saves them to the configuration
+func (g *MainGUI) showMarkupsDialog() {
+	var lines []string
+	for _, m := range service.ConfigMarkups(g.config) {
+		lines = append(lines, markupCSVLine(m))
+	}
+	markupsEntry := widget.NewMultiLineEntry()
+	markupsEntry.SetText(strings.Join(lines, "\n"))
+	markupsEntry.SetPlaceHolder("Animal Oil Residue,102%\nShrapnel,101%\nMelchi Water,+0.5")
+	markupsEntry.SetMinRowsVisible(10)
+
+	missingLabel := widget.NewLabel("")
+	missingLabel.TextStyle = fyne.TextStyle{Monospace: true}
+	if db, err := g.getDatabase(); err == nil {
+		db.RLock()
+		report := db.GetMarkupReport()
+		db.RUnlock()
+		missingLabel.SetText(stats.FormatMissingMarkups(report.Missing, maxMissingMarkups))
+	}
+
+	importButton := widget.NewButtonWithIcon("Import CSV", theme.FolderOpenIcon(), func() {
+		dialog.ShowFileOpen(func(reader fyne.URIReadCloser, err error) {
+			if err != nil || reader == nil {
+				return
+			}
+			defer reader.Close()
+			imported, err := model.ParseMarkupCSV(reader)
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("%s: %w", reader.URI().Name(), err), g.mainWindow)
+				return
+			}
+			current, err := model.ParseMarkupCSV(strings.NewReader(markupsEntry.Text))
+			if err != nil {
+				dialog.ShowError(err, g.mainWindow)
+				return
+			}
+			lines = lines[:0]
+			for _, m := range model.MergeMarkups(current, imported) {
+				lines = append(lines, markupCSVLine(m))
+			}
+			markupsEntry.SetText(strings.Join(lines, "\n"))
+		}, g.mainWindow)
+	})
+
+	content := container.NewBorder(
+		widget.NewLabel("One item per line: name, then a percentage of TT (125%) or PED on top of TT (+5)"),
+		container.NewVBox(importButton, missingLabel),
+		nil, nil,
+		markupsEntry,
+	)
+
+	d := dialog.NewCustomConfirm("Markups", "Save", "Cancel", content, func(ok bool) {
+		if !ok {
+			return
+		}
+		markups, err := model.ParseMarkupCSV(strings.NewReader(markupsEntry.Text))
+		if err != nil {
+			dialog.ShowError(err, g.mainWindow)
+			return
+		}
+		if _, err := model.NewMarkupTable(markups); err != nil {
+			dialog.ShowError(err, g.mainWindow)
+			return
+		}
+		g.saveMarkups(markups)
+	}, g.mainWindow)
+	d.Resize(fyne.NewSize(600, 500))
+	d.Show()
+}
+
+// saveMarkups stores the markups in the configuration file and revalues the statistics
+func (g *MainGUI) saveMarkups(markups []model.Markup) {
+	service.SetConfigMarkups(&g.config, markups)
+	if err := g.config.SaveConfigToFile(g.configPath); err != nil {
+		dialog.ShowError(err, g.mainWindow)
+		g.log.Error("Failed to save markups: %v", err)
+		return
+	}
+	// Prevent the auto-reload from picking up our own change
+	g.lastConfigHash = g.getConfigFileHash()
+
+	if db := g.loadedDatabase(); db != nil {
+		if err := service.ApplyMarkups(db, g.config, g.log); err != nil {
+			dialog.ShowError(err, g.mainWindow)
+			return
+		}
+	}
+	g.statusLabel.SetText(fmt.Sprintf("Saved %d markups", len(markups)))
+}
+
+// markupCSVLine formats a markup as a line of the markups editor
+func markupCSVLine(m model.Markup) string {
+	item := m.Item
+	if strings.ContainsAny(item, ",\"") {
+		item = `"` + strings.ReplaceAll(item, `"`, `""`) + `"`
+	}
+	return item + "," + m.String()
+}
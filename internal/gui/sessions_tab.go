@@ -0,0 +1,75 @@
+package gui
+
+import (
+	"eu-clams/internal/model"
+	"eu-clams/internal/stats"
+	"eu-clams/internal/storage"
+	"fmt"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// createSessionsTab creates the content for the sessions tab, listing detected play sessions
+func (g *MainGUI) createSessionsTab() fyne.CanvasObject {
+	var db *storage.EntropyDB
+	var sessions []model.SessionSummary
+
+	detailLabel := widget.NewLabel("Select a session to see its globals")
+	detailLabel.TextStyle = fyne.TextStyle{Monospace: true}
+
+	list := widget.NewList(
+		func() int { return len(sessions) },
+		func() fyne.CanvasObject { return widget.NewLabel("") },
+		func(id widget.ListItemID, obj fyne.CanvasObject) {
+			s := sessions[id]
+			open := ""
+			if s.Open {
+				open = " (open)"
+			}
+			obj.(*widget.Label).SetText(fmt.Sprintf("%s  %s%s  %d globals  %.0f PED",
+				s.Start, stats.FormatDuration(s.DurationSeconds), open, s.Globals, s.TotalValue))
+		},
+	)
+
+	reload := func() {
+		var err error
+		db, err = g.getDatabase()
+		if err != nil {
+			dialog.ShowError(err, g.mainWindow)
+			return
+		}
+		db.RLock()
+		sessions = db.GetSessions()
+		db.RUnlock()
+		list.UnselectAll()
+		list.Refresh()
+		detailLabel.SetText("Select a session to see its globals")
+	}
+
+	list.OnSelected = func(id widget.ListItemID) {
+		db.RLock()
+		detail, ok := db.GetSession(sessions[id].ID)
+		db.RUnlock()
+		if !ok {
+			detailLabel.SetText("Session no longer exists, reload the list")
+			return
+		}
+		detailLabel.SetText(stats.FormatSessionDetail(detail))
+	}
+
+	refreshButton := widget.NewButtonWithIcon("Reload", theme.ViewRefreshIcon(), reload)
+
+	split := container.NewHSplit(list, container.NewScroll(detailLabel))
+	split.SetOffset(0.4)
+
+	return container.NewBorder(
+		widget.NewLabelWithStyle("Sessions", fyne.TextAlignCenter, fyne.TextStyle{Bold: true}),
+		container.NewHBox(refreshButton),
+		nil, nil,
+		split,
+	)
+}
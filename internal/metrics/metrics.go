@@ -0,0 +1,357 @@
+// Package metrics keeps counters, gauges and histograms and writes them in the Prometheus
+// text exposition format, without depending on the Prometheus client library.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultBuckets are histogram bucket upper bounds in seconds, suited to request durations
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Registry holds metric families and writes them in the order they were registered
+type Registry struct {
+	mu       sync.Mutex
+	families []*family
+	names    map[string]bool
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// family is a metric with its help text and one child per set of label values
+type family struct {
+	name     string
+	help     string
+	kind     string // counter, gauge or histogram
+	labels   []string
+	mu       sync.Mutex
+	children map[string]child
+	create   func() child
+}
+
+// child is a single time series of a family
+type child interface {
+	write(w io.Writer, name, labels string)
+}
+
+// register adds a family, panicking on invalid or duplicate names like other registration errors
+// made at startup
+func (r *Registry) register(name, help, kind string, labels []string, create func() child) *family {
+	if !validName(name) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", name))
+	}
+	for _, l := range labels {
+		if !validName(l) || l == "le" {
+			panic(fmt.Sprintf("metrics: invalid label name %q for %s", l, name))
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[name] {
+		panic(fmt.Sprintf("metrics: %s registered twice", name))
+	}
+	r.names[name] = true
+	f := &family{name: name, help: help, kind: kind, labels: labels, children: make(map[string]child), create: create}
+	r.families = append(r.families, f)
+	return f
+}
+
+// with returns the child of a set of label values, creating it on first use
+func (f *family) with(values []string) child {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	c, ok := f.children[key]
+	if !ok {
+		c = f.create()
+		f.children[key] = c
+	}
+	return c
+}
+
+// Counter is a value that only goes up
+type Counter struct {
+	bits atomic.Uint64
+}
+
+// Add adds v, which must not be negative
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		panic("metrics: counters cannot decrease")
+	}
+	addFloat(&c.bits, v)
+}
+
+// Inc adds one
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Value returns the current value
+func (c *Counter) Value() float64 {
+	return math.Float64frombits(c.bits.Load())
+}
+
+func (c *Counter) write(w io.Writer, name, labels string) {
+	fmt.Fprintf(w, "%s%s %s\n", name, labels, formatValue(c.Value()))
+}
+
+// Gauge is a value that goes up and down
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+// Set sets the value
+func (g *Gauge) Set(v float64) {
+	g.bits.Store(math.Float64bits(v))
+}
+
+// Add adds v, which may be negative
+func (g *Gauge) Add(v float64) {
+	addFloat(&g.bits, v)
+}
+
+// Inc adds one
+func (g *Gauge) Inc() {
+	g.Add(1)
+}
+
+// Dec subtracts one
+func (g *Gauge) Dec() {
+	g.Add(-1)
+}
+
+// Value returns the current value
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
+
+func (g *Gauge) write(w io.Writer, name, labels string) {
+	fmt.Fprintf(w, "%s%s %s\n", name, labels, formatValue(g.Value()))
+}
+
+// gaugeFunc is a gauge whose value is read when the metrics are written
+type gaugeFunc func() float64
+
+func (f gaugeFunc) write(w io.Writer, name, labels string) {
+	fmt.Fprintf(w, "%s%s %s\n", name, labels, formatValue(f()))
+}
+
+// Histogram counts observations in buckets
+type Histogram struct {
+	mu      sync.Mutex
+	bounds  []float64
+	buckets []uint64 // Observations at or below each bound, not cumulative
+	count   uint64
+	sum     float64
+}
+
+// Observe adds an observation
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.bounds, v)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i < len(h.buckets) {
+		h.buckets[i]++
+	}
+	h.count++
+	h.sum += v
+}
+
+// Count returns the number of observations
+func (h *Histogram) Count() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.count
+}
+
+func (h *Histogram) write(w io.Writer, name, labels string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.buckets[i]
+		fmt.Fprintf(w, "%s_bucket%s %d\n", name, withLabel(labels, "le", formatValue(bound)), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket%s %d\n", name, withLabel(labels, "le", "+Inf"), h.count)
+	fmt.Fprintf(w, "%s_sum%s %s\n", name, labels, formatValue(h.sum))
+	fmt.Fprintf(w, "%s_count%s %d\n", name, labels, h.count)
+}
+
+// newHistogram creates a histogram with sorted bucket bounds
+func newHistogram(buckets []float64) *Histogram {
+	bounds := append([]float64(nil), buckets...)
+	sort.Float64s(bounds)
+	return &Histogram{bounds: bounds, buckets: make([]uint64, len(bounds))}
+}
+
+// CounterVec is a counter with labels
+type CounterVec struct{ f *family }
+
+// With returns the counter of the label values, in the order the labels were declared
+func (v CounterVec) With(values ...string) *Counter {
+	return v.f.with(values).(*Counter)
+}
+
+// GaugeVec is a gauge with labels
+type GaugeVec struct{ f *family }
+
+// With returns the gauge of the label values, in the order the labels were declared
+func (v GaugeVec) With(values ...string) *Gauge {
+	return v.f.with(values).(*Gauge)
+}
+
+// HistogramVec is a histogram with labels
+type HistogramVec struct{ f *family }
+
+// With returns the histogram of the label values, in the order the labels were declared
+func (v HistogramVec) With(values ...string) *Histogram {
+	return v.f.with(values).(*Histogram)
+}
+
+// Counter registers a counter without labels
+func (r *Registry) Counter(name, help string) *Counter {
+	return r.CounterVec(name, help).With()
+}
+
+// CounterVec registers a counter with labels
+func (r *Registry) CounterVec(name, help string, labels ...string) CounterVec {
+	return CounterVec{r.register(name, help, "counter", labels, func() child { return &Counter{} })}
+}
+
+// Gauge registers a gauge without labels
+func (r *Registry) Gauge(name, help string) *Gauge {
+	return r.GaugeVec(name, help).With()
+}
+
+// GaugeVec registers a gauge with labels
+func (r *Registry) GaugeVec(name, help string, labels ...string) GaugeVec {
+	return GaugeVec{r.register(name, help, "gauge", labels, func() child { return &Gauge{} })}
+}
+
+// GaugeFunc registers a gauge whose value is read from fn whenever the metrics are written
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.register(name, help, "gauge", nil, func() child { return gaugeFunc(fn) }).with(nil)
+}
+
+// Histogram registers a histogram without labels; nil buckets use DefaultBuckets
+func (r *Registry) Histogram(name, help string, buckets []float64) *Histogram {
+	return r.HistogramVec(name, help, buckets).With()
+}
+
+// HistogramVec registers a histogram with labels; nil buckets use DefaultBuckets
+func (r *Registry) HistogramVec(name, help string, buckets []float64, labels ...string) HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	return HistogramVec{r.register(name, help, "histogram", labels, func() child { return newHistogram(buckets) })}
+}
+
+// WritePrometheus writes every metric in the Prometheus text exposition format (version 0.0.4).
+// Series of a family are sorted by their label values, so the output is stable.
+func (r *Registry) WritePrometheus(w io.Writer) error {
+	r.mu.Lock()
+	families := append([]*family(nil), r.families...)
+	r.mu.Unlock()
+
+	var b strings.Builder
+	for _, f := range families {
+		f.mu.Lock()
+		keys := make([]string, 0, len(f.children))
+		for k := range f.children {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		children := make([]child, len(keys))
+		for i, k := range keys {
+			children[i] = f.children[k]
+		}
+		f.mu.Unlock()
+
+		fmt.Fprintf(&b, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+		fmt.Fprintf(&b, "# TYPE %s %s\n", f.name, f.kind)
+		for i, c := range children {
+			c.write(&b, f.name, formatLabels(f.labels, strings.Split(keys[i], "\xff")))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatLabels formats label pairs as {name="value",...}, or nothing without labels
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabel(values[i]) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// withLabel adds a label pair to formatted labels
+func withLabel(labels, name, value string) string {
+	pair := name + `="` + escapeLabel(value) + `"`
+	if labels == "" {
+		return "{" + pair + "}"
+	}
+	return labels[:len(labels)-1] + "," + pair + "}"
+}
+
+// formatValue formats a sample value the way Prometheus parses it
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+
+// validName reports whether s is a valid metric or label name
+func validName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !(r == '_' || r == ':' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// addFloat atomically adds v to a float64 stored as bits
+func addFloat(bits *atomic.Uint64, v float64) {
+	for {
+		old := bits.Load()
+		if bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
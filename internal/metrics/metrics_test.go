@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWritePrometheus(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	globals := r.CounterVec("test_globals_total", "Globals by type.", "type")
+	globals.With("kill").Add(2)
+	globals.With("craft").Inc()
+	r.Gauge("test_lag_bytes", "Unread bytes.").Set(128)
+	r.GaugeFunc("test_clients", "Connected clients.", func() float64 { return 3 })
+	durations := r.HistogramVec("test_duration_seconds", "Durations.", []float64{0.1, 1}, "route")
+	durations.With("GET /").Observe(0.05)
+	durations.With("GET /").Observe(0.5)
+	durations.With("GET /").Observe(5)
+	r.CounterVec("test_escaped_total", "Line one\nline two.", "path").With(`a"b\c`).Inc()
+
+	var b strings.Builder
+	if err := r.WritePrometheus(&b); err != nil {
+		t.Fatalf("WritePrometheus() error = %v", err)
+	}
+	want := `# HELP test_globals_total Globals by type.
+# TYPE test_globals_total counter
+test_globals_total{type="craft"} 1
+test_globals_total{type="kill"} 2
+# HELP test_lag_bytes Unread bytes.
+# TYPE test_lag_bytes gauge
+test_lag_bytes 128
+# HELP test_clients Connected clients.
+# TYPE test_clients gauge
+test_clients 3
+# HELP test_duration_seconds Durations.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{route="GET /",le="0.1"} 1
+test_duration_seconds_bucket{route="GET /",le="1"} 2
+test_duration_seconds_bucket{route="GET /",le="+Inf"} 3
+test_duration_seconds_sum{route="GET /"} 5.55
+test_duration_seconds_count{route="GET /"} 3
+# HELP test_escaped_total Line one\nline two.
+# TYPE test_escaped_total counter
+test_escaped_total{path="a\"b\\c"} 1
+`
+	if b.String() != want {
+		t.Errorf("WritePrometheus() =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestRegisterRejectsInvalidMetrics(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		register func(r *Registry)
+	}{
+		{"invalid name", func(r *Registry) { r.Counter("9lives", "") }},
+		{"reserved label", func(r *Registry) { r.HistogramVec("test_seconds", "", nil, "le") }},
+		{"duplicate", func(r *Registry) { r.Gauge("test_gauge", ""); r.Gauge("test_gauge", "") }},
+		{"wrong label count", func(r *Registry) { r.CounterVec("test_total", "", "a", "b").With("a") }},
+		{"negative counter", func(r *Registry) { r.Counter("test_total", "").Add(-1) }},
+	}
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: no panic", tt.name)
+				}
+			}()
+			tt.register(NewRegistry())
+		}()
+	}
+}
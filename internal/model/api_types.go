@@ -2,6 +2,7 @@
 
 // GlobalEntryJSON is a JSON serialization-friendly version of GlobalEntry
 type GlobalEntryJSON struct {
+ID         string  `json:"id"`
 Timestamp  string  `json:"timestamp"`
 Type       string  `json:"type"`
 PlayerName string  `json:"player"`
@@ -11,4 +12,6 @@ Value      float64 `json:"value"`
 Location   string  `json:"location,omitempty"`
 IsHof      bool    `json:"is_hof"`
 RawMessage string  `json:"raw_message,omitempty"`
+Records    []string `json:"records,omitempty"`
+Member     string  `json:"member,omitempty"` // Team member who pushed the global to a hub
 }
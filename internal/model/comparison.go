@@ -0,0 +1,285 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DatasetFilter selects the globals of one side of a comparison. Zero values match everything.
+type DatasetFilter struct {
+	Label  string    `json:"label"`
+	Player string    `json:"player,omitempty"` // Case-insensitive player name
+	Team   string    `json:"team,omitempty"`   // Case-insensitive team name
+	From   time.Time `json:"from,omitempty"`
+	To     time.Time `json:"to,omitempty"`
+}
+
+// Matches returns whether entry belongs to the dataset
+func (f DatasetFilter) Matches(entry GlobalEntry) bool {
+	if f.Player != "" && !strings.EqualFold(entry.PlayerName, f.Player) {
+		return false
+	}
+	if f.Team != "" && !strings.EqualFold(entry.TeamName, f.Team) {
+		return false
+	}
+	if f.From.IsZero() && f.To.IsZero() {
+		return true
+	}
+	t, err := entry.Time()
+	if err != nil {
+		return false
+	}
+	return (f.From.IsZero() || !t.Before(f.From)) && (f.To.IsZero() || !t.After(f.To))
+}
+
+// FilterGlobals returns the globals belonging to the dataset
+func FilterGlobals(globals []GlobalEntry, filter DatasetFilter) []GlobalEntry {
+	result := []GlobalEntry{}
+	for _, entry := range globals {
+		if filter.Matches(entry) {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// MetricDelta compares one metric of both datasets
+type MetricDelta struct {
+	Metric string   `json:"metric"`
+	A      float64  `json:"a"`
+	B      float64  `json:"b"`
+	Delta  float64  `json:"delta"`            // B - A
+	Change *float64 `json:"change,omitempty"` // Relative change from A to B, omitted when A is 0
+}
+
+// BreakdownDelta compares one target or location of both datasets
+type BreakdownDelta struct {
+	Name       string  `json:"name"`
+	Type       string  `json:"type,omitempty"` // Global type, for targets
+	CountA     int     `json:"count_a"`
+	CountB     int     `json:"count_b"`
+	CountDelta int     `json:"count_delta"`
+	ValueA     float64 `json:"value_a"`
+	ValueB     float64 `json:"value_b"`
+	ValueDelta float64 `json:"value_delta"`
+}
+
+// Comparison puts the statistics of two datasets side by side
+type Comparison struct {
+	A         DatasetFilter    `json:"a"`
+	B         DatasetFilter    `json:"b"`
+	StatsA    Stats            `json:"-"`
+	StatsB    Stats            `json:"-"`
+	Metrics   []MetricDelta    `json:"metrics"`
+	Targets   []BreakdownDelta `json:"targets"`   // Largest absolute value change first
+	Locations []BreakdownDelta `json:"locations"` // Largest absolute value change first
+}
+
+// newMetricDelta compares a metric
+func newMetricDelta(metric string, a, b float64) MetricDelta {
+	d := MetricDelta{Metric: metric, A: a, B: b, Delta: b - a}
+	if a != 0 {
+		change := (b - a) / a
+		d.Change = &change
+	}
+	return d
+}
+
+// CompareGlobals compares the statistics of two sets of globals, which must already be
+// limited to their datasets with FilterGlobals. Their market value is their TT value.
+func CompareGlobals(a DatasetFilter, globalsA []GlobalEntry, b DatasetFilter, globalsB []GlobalEntry) Comparison {
+	statsA := GenerateStatsFromGlobals(globalsA)
+	statsB := GenerateStatsFromGlobals(globalsB)
+	ApplyMarkups(&statsA, nil)
+	ApplyMarkups(&statsB, nil)
+	return CompareStats(a, statsA, b, statsB)
+}
+
+// CompareStats compares the statistics of two datasets, such as ones with markups applied
+func CompareStats(a DatasetFilter, statsA Stats, b DatasetFilter, statsB Stats) Comparison {
+	c := Comparison{A: a, B: b, StatsA: statsA, StatsB: statsB}
+
+	average := func(s Stats) float64 {
+		if s.TotalGlobals == 0 {
+			return 0
+		}
+		return s.TotalValue / float64(s.TotalGlobals)
+	}
+	c.Metrics = []MetricDelta{
+		newMetricDelta("total_globals", float64(statsA.TotalGlobals), float64(statsB.TotalGlobals)),
+		newMetricDelta("total_hofs", float64(statsA.TotalHofs), float64(statsB.TotalHofs)),
+		newMetricDelta("total_value", statsA.TotalValue, statsB.TotalValue),
+		newMetricDelta("total_market_value", statsA.TotalMarketValue, statsB.TotalMarketValue),
+		newMetricDelta("average_value", average(statsA), average(statsB)),
+		newMetricDelta("highest_value", statsA.HighestValue, statsB.HighestValue),
+	}
+	for _, typ := range unionKeys(statsA.ByType, statsB.ByType) {
+		c.Metrics = append(c.Metrics, newMetricDelta("globals_"+typ, float64(statsA.ByType[typ]), float64(statsB.ByType[typ])))
+	}
+	c.Metrics = append(c.Metrics,
+		newMetricDelta("targets", float64(len(statsA.ByTarget)), float64(len(statsB.ByTarget))),
+		newMetricDelta("locations", float64(len(statsA.Locations)), float64(len(statsB.Locations))),
+	)
+
+	// Targets are keyed by type and name, as in the per-target statistics
+	targets := make(map[string]*BreakdownDelta)
+	targetDelta := func(ts TargetStats) *BreakdownDelta {
+		key := ts.Type + "\x00" + ts.Target
+		if targets[key] == nil {
+			targets[key] = &BreakdownDelta{Name: ts.Target, Type: ts.Type}
+		}
+		return targets[key]
+	}
+	for _, ts := range statsA.ByTarget {
+		d := targetDelta(ts)
+		d.CountA, d.ValueA = ts.Count, ts.TotalValue
+	}
+	for _, ts := range statsB.ByTarget {
+		d := targetDelta(ts)
+		d.CountB, d.ValueB = ts.Count, ts.TotalValue
+	}
+	c.Targets = sortedBreakdown(targets)
+
+	locations := make(map[string]*BreakdownDelta)
+	locationDelta := func(ls LocationStats) *BreakdownDelta {
+		key := LocationKey(ls.Location)
+		if locations[key] == nil {
+			locations[key] = &BreakdownDelta{Name: ls.Location}
+		}
+		return locations[key]
+	}
+	for _, ls := range statsA.Locations {
+		d := locationDelta(ls)
+		d.CountA, d.ValueA = ls.Count, ls.TotalValue
+	}
+	for _, ls := range statsB.Locations {
+		d := locationDelta(ls)
+		d.CountB, d.ValueB = ls.Count, ls.TotalValue
+	}
+	c.Locations = sortedBreakdown(locations)
+
+	return c
+}
+
+// sortedBreakdown computes the deltas and orders them by largest absolute value change
+func sortedBreakdown(byKey map[string]*BreakdownDelta) []BreakdownDelta {
+	result := make([]BreakdownDelta, 0, len(byKey))
+	for _, d := range byKey {
+		d.CountDelta = d.CountB - d.CountA
+		d.ValueDelta = d.ValueB - d.ValueA
+		result = append(result, *d)
+	}
+	abs := func(v float64) float64 {
+		if v < 0 {
+			return -v
+		}
+		return v
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if abs(result[i].ValueDelta) != abs(result[j].ValueDelta) {
+			return abs(result[i].ValueDelta) > abs(result[j].ValueDelta)
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// unionKeys returns the sorted keys present in either map
+func unionKeys(a, b map[string]int) []string {
+	seen := make(map[string]bool)
+	for k := range a {
+		seen[k] = true
+	}
+	for k := range b {
+		seen[k] = true
+	}
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PresetPeriods returns the current and the previous day, week or month around now, as
+// the datasets A (previous) and B (current) of a comparison
+func PresetPeriods(interval string, now time.Time) (DatasetFilter, DatasetFilter, bool) {
+	if !ValidInterval(interval) {
+		return DatasetFilter{}, DatasetFilter{}, false
+	}
+	current := periodStart(now, interval)
+	next := nextPeriod(current, interval)
+	var previous time.Time
+	switch interval {
+	case IntervalWeek:
+		previous = current.AddDate(0, 0, -7)
+	case IntervalMonth:
+		previous = current.AddDate(0, -1, 0)
+	default:
+		previous = current.AddDate(0, 0, -1)
+	}
+
+	a := DatasetFilter{Label: "Last " + interval + " (" + periodLabel(previous, interval) + ")", From: previous, To: current.Add(-time.Second)}
+	b := DatasetFilter{Label: "This " + interval + " (" + periodLabel(current, interval) + ")", From: current, To: next.Add(-time.Second)}
+	return a, b, true
+}
+
+// DatasetQuery holds a comparison dataset as given on the command line or in a URL query
+type DatasetQuery struct {
+	Label  string
+	Player string
+	Team   string
+	From   string // Same formats as ParseTimeRange
+	To     string
+}
+
+// ParseDatasets builds the two datasets of a comparison. A preset ("day", "week" or "month")
+// compares the previous period (A) with the current one (B); explicit time bounds, identities
+// and labels of the queries override the preset.
+func ParseDatasets(preset string, a, b DatasetQuery, now time.Time) (DatasetFilter, DatasetFilter, error) {
+	var filterA, filterB DatasetFilter
+	if preset != "" {
+		var ok bool
+		filterA, filterB, ok = PresetPeriods(preset, now)
+		if !ok {
+			return DatasetFilter{}, DatasetFilter{}, fmt.Errorf("invalid preset %q: must be day, week or month", preset)
+		}
+	}
+
+	if err := applyDatasetQuery(&filterA, a); err != nil {
+		return DatasetFilter{}, DatasetFilter{}, fmt.Errorf("dataset A: %w", err)
+	}
+	if err := applyDatasetQuery(&filterB, b); err != nil {
+		return DatasetFilter{}, DatasetFilter{}, fmt.Errorf("dataset B: %w", err)
+	}
+	return filterA, filterB, nil
+}
+
+// applyDatasetQuery overrides the fields of a dataset that are set in the query
+func applyDatasetQuery(filter *DatasetFilter, q DatasetQuery) error {
+	from, to, err := ParseTimeRange(q.From, q.To)
+	if err != nil {
+		return err
+	}
+	if q.From != "" {
+		filter.From = from
+	}
+	if q.To != "" {
+		filter.To = to
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return fmt.Errorf("to is before from")
+	}
+	if q.Player != "" {
+		filter.Player = q.Player
+	}
+	if q.Team != "" {
+		filter.Team = q.Team
+	}
+	if q.Label != "" {
+		filter.Label = q.Label
+	}
+	return nil
+}
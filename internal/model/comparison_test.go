@@ -0,0 +1,92 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompareGlobals(t *testing.T) {
+	t.Parallel()
+
+	globals := []GlobalEntry{
+		{Timestamp: "2025-04-10 10:00:00", Type: "kill", PlayerName: "Alice", Target: "Atrox", Value: 100, Location: "Nea"},
+		{Timestamp: "2025-04-12 10:00:00", Type: "kill", PlayerName: "Alice", Target: "Daikiba", Value: 50, Location: "Nea"},
+		{Timestamp: "2025-05-02 10:00:00", Type: "kill", PlayerName: "Alice", Target: "Atrox", Value: 300, Location: "Nea"},
+		{Timestamp: "2025-05-03 10:00:00", Type: "craft", PlayerName: "Alice", Target: "Pistol", Value: 60},
+		{Timestamp: "2025-05-04 10:00:00", Type: "kill", PlayerName: "Bob", Target: "Atrox", Value: 999},
+	}
+
+	a, b, err := ParseDatasets(IntervalMonth, DatasetQuery{Player: "alice"}, DatasetQuery{Player: "Alice"}, time.Date(2025, 5, 16, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("ParseDatasets() error = %v", err)
+	}
+	if a.Label != "Last month (2025-04)" || b.Label != "This month (2025-05)" {
+		t.Errorf("labels = %q, %q, want last and this month", a.Label, b.Label)
+	}
+
+	c := CompareGlobals(a, FilterGlobals(globals, a), b, FilterGlobals(globals, b))
+
+	metrics := make(map[string]MetricDelta)
+	for _, m := range c.Metrics {
+		metrics[m.Metric] = m
+	}
+	if m := metrics["total_globals"]; m.A != 2 || m.B != 2 || m.Delta != 0 {
+		t.Errorf("total_globals = %+v, want 2 vs 2", m)
+	}
+	if m := metrics["total_value"]; m.A != 150 || m.B != 360 || m.Change == nil || *m.Change != 1.4 {
+		t.Errorf("total_value = %+v, want 150 vs 360 (+140%%)", m)
+	}
+	if m := metrics["total_market_value"]; m.A != 150 || m.B != 360 {
+		t.Errorf("total_market_value = %+v, want the TT values 150 vs 360 without markups", m)
+	}
+	// A type without globals in A has no relative change
+	if m := metrics["globals_craft"]; m.A != 0 || m.B != 1 || m.Change != nil {
+		t.Errorf("globals_craft = %+v, want 0 vs 1 without change", m)
+	}
+
+	if len(c.Targets) != 3 || c.Targets[0].Name != "Atrox" || c.Targets[0].ValueDelta != 200 {
+		t.Fatalf("Targets = %+v, want Atrox (+200) first", c.Targets)
+	}
+	if d := c.Targets[1]; d.Name != "Pistol" || d.Type != "craft" || d.CountA != 0 || d.CountB != 1 {
+		t.Errorf("Targets[1] = %+v, want the new Pistol craft", d)
+	}
+	if d := c.Targets[2]; d.Name != "Daikiba" || d.CountDelta != -1 || d.ValueDelta != -50 {
+		t.Errorf("Targets[2] = %+v, want Daikiba gone", d)
+	}
+	if len(c.Locations) == 0 || c.Locations[0].Name != "Nea" || c.Locations[0].ValueDelta != 150 {
+		t.Errorf("Locations = %+v, want Nea (+150) first", c.Locations)
+	}
+}
+
+func TestParseDatasets(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 5, 16, 12, 0, 0, 0, time.UTC)
+
+	a, b, err := ParseDatasets("", DatasetQuery{From: "2025-05-01", To: "2025-05-07"}, DatasetQuery{Team: "Hunters"}, now)
+	if err != nil {
+		t.Fatalf("ParseDatasets() error = %v", err)
+	}
+	if !a.To.Equal(time.Date(2025, 5, 7, 23, 59, 59, 0, time.UTC)) {
+		t.Errorf("A.To = %v, want the end of 2025-05-07", a.To)
+	}
+	if b.Team != "Hunters" || !b.From.IsZero() || !b.To.IsZero() {
+		t.Errorf("B = %+v, want all of team Hunters", b)
+	}
+
+	// Explicit bounds override the preset
+	a, _, err = ParseDatasets(IntervalWeek, DatasetQuery{From: "2025-05-01"}, DatasetQuery{}, now)
+	if err != nil {
+		t.Fatalf("ParseDatasets() error = %v", err)
+	}
+	if !a.From.Equal(time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC)) || !a.To.Equal(time.Date(2025, 5, 11, 23, 59, 59, 0, time.UTC)) {
+		t.Errorf("A = %v to %v, want 2025-05-01 to the end of last week", a.From, a.To)
+	}
+
+	if _, _, err := ParseDatasets("year", DatasetQuery{}, DatasetQuery{}, now); err == nil {
+		t.Error("ParseDatasets() with an invalid preset succeeded")
+	}
+	if _, _, err := ParseDatasets("", DatasetQuery{}, DatasetQuery{From: "2025-05-10", To: "2025-05-01"}, now); err == nil {
+		t.Error("ParseDatasets() with B ending before it starts succeeded")
+	}
+}
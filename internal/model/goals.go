@@ -0,0 +1,249 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Goal metrics
+const (
+	GoalMetricGlobals = "globals" // Number of globals
+	GoalMetricHofs    = "hofs"    // Number of HoFs
+	GoalMetricValue   = "value"   // Total PED
+)
+
+// Goal is a user-defined target such as "50 globals this month", "1,000 PED from Atrox"
+// or "first craft HoF" (1 HoF of type craft)
+type Goal struct {
+	ID        string  `yaml:"id" json:"id"`
+	Name      string  `yaml:"name" json:"name"`
+	Metric    string  `yaml:"metric" json:"metric"`                         // globals, hofs or value
+	Threshold float64 `yaml:"threshold" json:"threshold"`                   // Amount of the metric to reach
+	Type      string  `yaml:"type,omitempty" json:"type,omitempty"`         // Only globals of this type
+	Target    string  `yaml:"target,omitempty" json:"target,omitempty"`     // Only globals of this target, case-insensitive
+	Location  string  `yaml:"location,omitempty" json:"location,omitempty"` // Only globals at this location
+	Period    string  `yaml:"period,omitempty" json:"period,omitempty"`     // day, week or month; empty for all time
+
+	// Progress kept in the database so completions are reported once
+	CreatedAt       string `yaml:"created_at,omitempty" json:"created_at,omitempty"`             // Same layout as GlobalEntry.Timestamp
+	CompletedAt     string `yaml:"completed_at,omitempty" json:"completed_at,omitempty"`         // Last completion
+	CompletedPeriod string `yaml:"completed_period,omitempty" json:"completed_period,omitempty"` // Period of the last completion
+}
+
+// ValidateGoal checks that a goal can be evaluated
+func ValidateGoal(goal Goal) error {
+	switch goal.Metric {
+	case GoalMetricGlobals, GoalMetricHofs, GoalMetricValue:
+	default:
+		return fmt.Errorf("invalid metric %q: must be globals, hofs or value", goal.Metric)
+	}
+	if goal.Threshold <= 0 {
+		return fmt.Errorf("threshold must be positive")
+	}
+	if goal.Period != "" && !ValidInterval(goal.Period) {
+		return fmt.Errorf("invalid period %q: must be day, week or month", goal.Period)
+	}
+	return nil
+}
+
+// DescribeGoal returns a name for a goal without one, e.g. "50 globals (kill, Atrox) this month"
+func DescribeGoal(goal Goal) string {
+	var b strings.Builder
+	if goal.Metric == GoalMetricValue {
+		b.WriteString(fmt.Sprintf("%.0f PED", goal.Threshold))
+	} else {
+		b.WriteString(fmt.Sprintf("%.0f %s", goal.Threshold, goal.Metric))
+	}
+
+	var scope []string
+	for _, s := range []string{goal.Type, goal.Target, goal.Location} {
+		if s != "" {
+			scope = append(scope, s)
+		}
+	}
+	if len(scope) > 0 {
+		b.WriteString(" (" + strings.Join(scope, ", ") + ")")
+	}
+	if goal.Period != "" {
+		b.WriteString(" this " + goal.Period)
+	}
+	return b.String()
+}
+
+// GoalProgress is the progress towards a goal
+type GoalProgress struct {
+	Goal      Goal    `json:"goal"`
+	Period    string  `json:"period,omitempty"` // Current period, for goals with a period
+	Current   float64 `json:"current"`
+	Progress  float64 `json:"progress"` // 0 to 1
+	Completed bool    `json:"completed"`
+}
+
+// EvaluateGoal computes the progress towards a goal from the globals. Goals with a period
+// only count globals of the period containing now.
+func EvaluateGoal(goal Goal, globals []GlobalEntry, now time.Time) GoalProgress {
+	p := GoalProgress{Goal: goal}
+
+	var start, end time.Time
+	if ValidInterval(goal.Period) {
+		start = periodStart(now, goal.Period)
+		end = nextPeriod(start, goal.Period)
+		p.Period = periodLabel(start, goal.Period)
+	}
+
+	for _, entry := range globals {
+		if !goalMatches(goal, entry) {
+			continue
+		}
+		if !start.IsZero() {
+			t, err := entry.Time()
+			if err != nil || t.Before(start) || !t.Before(end) {
+				continue
+			}
+		}
+
+		switch goal.Metric {
+		case GoalMetricGlobals:
+			p.Current++
+		case GoalMetricHofs:
+			if entry.IsHof {
+				p.Current++
+			}
+		case GoalMetricValue:
+			p.Current += entry.Value
+		}
+	}
+
+	p.Progress = 1
+	if goal.Threshold > 0 && p.Current < goal.Threshold {
+		p.Progress = p.Current / goal.Threshold
+	}
+	p.Completed = p.Progress >= 1
+	return p
+}
+
+// goalMatches returns whether a global counts towards a goal
+func goalMatches(goal Goal, entry GlobalEntry) bool {
+	if goal.Type != "" && entry.Type != goal.Type {
+		return false
+	}
+	if goal.Target != "" && !strings.EqualFold(entry.Target, goal.Target) {
+		return false
+	}
+	if goal.Location != "" && LocationKey(entry.Location) != LocationKey(goal.Location) {
+		return false
+	}
+	return true
+}
+
+// Achievement is a built-in milestone
+type Achievement struct {
+	ID          string `yaml:"id" json:"id"`
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
+}
+
+// AchievementUnlock is an achievement with the time it was earned
+type AchievementUnlock struct {
+	Achievement `yaml:",inline"`
+	UnlockedAt  string `yaml:"unlocked_at" json:"unlocked_at"` // Timestamp of the global that earned it
+}
+
+// achievementRule unlocks an achievement once check holds for the globals seen so far
+type achievementRule struct {
+	Achievement
+	check func(t *achievementTally) bool
+}
+
+// achievementTally accumulates the globals seen so far
+type achievementTally struct {
+	globals, hofs, craftHofs int
+	totalValue, lastValue    float64
+	types                    map[string]bool
+	locations                map[string]bool
+}
+
+// achievementRules are the built-in achievements, in the order they are listed
+var achievementRules = []achievementRule{
+	{Achievement{"first_global", "First Global", "Get your first global"}, func(t *achievementTally) bool { return t.globals >= 1 }},
+	{Achievement{"globals_100", "Centurion", "Get 100 globals"}, func(t *achievementTally) bool { return t.globals >= 100 }},
+	{Achievement{"globals_1000", "Global Legend", "Get 1,000 globals"}, func(t *achievementTally) bool { return t.globals >= 1000 }},
+	{Achievement{"first_hof", "Hall of Famer", "Get your first HoF"}, func(t *achievementTally) bool { return t.hofs >= 1 }},
+	{Achievement{"hofs_10", "Famous", "Get 10 HoFs"}, func(t *achievementTally) bool { return t.hofs >= 10 }},
+	{Achievement{"first_craft_hof", "Master Crafter", "Get your first crafting HoF"}, func(t *achievementTally) bool { return t.craftHofs >= 1 }},
+	{Achievement{"all_types", "Jack of All Trades", "Get a kill, a crafting and a mining global"}, func(t *achievementTally) bool {
+		return t.types["kill"] && t.types["craft"] && t.types["find"]
+	}},
+	{Achievement{"single_1000", "Jackpot", "Get a single global worth 1,000 PED or more"}, func(t *achievementTally) bool { return t.lastValue >= 1000 }},
+	{Achievement{"total_10000", "PED Baron", "Get globals worth 10,000 PED in total"}, func(t *achievementTally) bool { return t.totalValue >= 10000 }},
+	{Achievement{"locations_5", "Explorer", "Get globals at 5 different locations"}, func(t *achievementTally) bool { return len(t.locations) >= 5 }},
+}
+
+// newLocationPrefix is the ID prefix of the achievements for a global at a new location
+const newLocationPrefix = "location:"
+
+// Achievements returns the built-in achievements, not including those for new locations
+func Achievements() []Achievement {
+	list := make([]Achievement, len(achievementRules))
+	for i, rule := range achievementRules {
+		list[i] = rule.Achievement
+	}
+	return list
+}
+
+// EvaluateAchievements returns every achievement the globals have earned, oldest first.
+// Besides the built-in achievements, the first global at every location earns a
+// "New location" achievement.
+func EvaluateAchievements(globals []GlobalEntry) []AchievementUnlock {
+	sorted := make([]GlobalEntry, 0, len(globals))
+	for _, entry := range globals {
+		if _, err := entry.Time(); err == nil {
+			sorted = append(sorted, entry)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp < sorted[j].Timestamp })
+
+	tally := &achievementTally{types: make(map[string]bool), locations: make(map[string]bool)}
+	unlocked := make(map[string]bool)
+	var result []AchievementUnlock
+
+	for _, entry := range sorted {
+		tally.globals++
+		if entry.IsHof {
+			tally.hofs++
+			if entry.Type == "craft" {
+				tally.craftHofs++
+			}
+		}
+		tally.totalValue += entry.Value
+		tally.lastValue = entry.Value
+		tally.types[entry.Type] = true
+
+		if entry.Location != "" {
+			key := LocationKey(entry.Location)
+			if !tally.locations[key] {
+				tally.locations[key] = true
+				name := NormalizeLocation(entry.Location)
+				result = append(result, AchievementUnlock{
+					Achievement: Achievement{
+						ID:          newLocationPrefix + key,
+						Name:        "New Location: " + name,
+						Description: "Get a global at " + name,
+					},
+					UnlockedAt: entry.Timestamp,
+				})
+			}
+		}
+
+		for _, rule := range achievementRules {
+			if !unlocked[rule.ID] && rule.check(tally) {
+				unlocked[rule.ID] = true
+				result = append(result, AchievementUnlock{Achievement: rule.Achievement, UnlockedAt: entry.Timestamp})
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEvaluateGoal(t *testing.T) {
+	t.Parallel()
+
+	globals := []GlobalEntry{
+		{Timestamp: "2025-04-30 10:00:00", Type: "kill", Target: "Atrox", Value: 400},
+		{Timestamp: "2025-05-02 10:00:00", Type: "kill", Target: "atrox", Value: 300},
+		{Timestamp: "2025-05-03 10:00:00", Type: "craft", Target: "Pistol", Value: 1500, IsHof: true},
+	}
+	now := time.Date(2025, 5, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		goal      Goal
+		current   float64
+		completed bool
+	}{
+		{"value from target", Goal{Metric: GoalMetricValue, Target: "Atrox", Threshold: 1000}, 700, false},
+		{"globals this month", Goal{Metric: GoalMetricGlobals, Period: IntervalMonth, Threshold: 2}, 2, true},
+		{"first craft HoF", Goal{Metric: GoalMetricHofs, Type: "craft", Threshold: 1}, 1, true},
+	}
+	for _, tt := range tests {
+		p := EvaluateGoal(tt.goal, globals, now)
+		if p.Current != tt.current || p.Completed != tt.completed {
+			t.Errorf("%s: Current = %v, Completed = %v, want %v and %v", tt.name, p.Current, p.Completed, tt.current, tt.completed)
+		}
+	}
+
+	if p := EvaluateGoal(Goal{Metric: GoalMetricGlobals, Period: IntervalMonth, Threshold: 4}, globals, now); p.Period != "2025-05" || p.Progress != 0.5 {
+		t.Errorf("Period = %q, Progress = %v, want 2025-05 and 0.5", p.Period, p.Progress)
+	}
+
+	if err := ValidateGoal(Goal{Metric: "kills", Threshold: 1}); err == nil {
+		t.Error("ValidateGoal() accepted an invalid metric")
+	}
+	if err := ValidateGoal(Goal{Metric: GoalMetricGlobals}); err == nil {
+		t.Error("ValidateGoal() accepted a goal without threshold")
+	}
+}
+
+func TestEvaluateAchievements(t *testing.T) {
+	t.Parallel()
+
+	globals := []GlobalEntry{
+		{Timestamp: "2025-05-03 10:00:00", Type: "craft", Target: "Pistol", Value: 1500, IsHof: true},
+		{Timestamp: "2025-05-01 10:00:00", Type: "kill", Target: "Atrox", Value: 50, Location: "Nea"},
+		{Timestamp: "2025-05-02 10:00:00", Type: "kill", Target: "Atrox", Value: 60, Location: "at nea"},
+	}
+
+	unlocked := make(map[string]string)
+	for _, a := range EvaluateAchievements(globals) {
+		unlocked[a.ID] = a.UnlockedAt
+	}
+
+	// Achievements are earned in timestamp order, not in storage order
+	if unlocked["first_global"] != "2025-05-01 10:00:00" {
+		t.Errorf("first_global unlocked at %q, want the oldest global", unlocked["first_global"])
+	}
+	for _, id := range []string{"first_hof", "first_craft_hof", "single_1000"} {
+		if unlocked[id] != "2025-05-03 10:00:00" {
+			t.Errorf("%s unlocked at %q, want 2025-05-03 10:00:00", id, unlocked[id])
+		}
+	}
+	if _, ok := unlocked["globals_100"]; ok {
+		t.Error("globals_100 unlocked with 3 globals")
+	}
+	// Both spellings are the same location
+	if len(unlocked) != 5 || unlocked["location:nea"] != "2025-05-01 10:00:00" {
+		t.Errorf("unlocked = %v, want 4 built-in achievements and one location", unlocked)
+	}
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// HeatmapWeekdays are the heatmap rows, Monday first
+var HeatmapWeekdays = []string{"Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+// maxHeatmapTargets is the number of targets listed for drilling down
+const maxHeatmapTargets = 25
+
+// HeatmapFilter selects the globals of a heatmap. Zero values match everything.
+type HeatmapFilter struct {
+	Type   string // Exact global type, e.g. "kill"
+	Target string // Case-insensitive target name
+}
+
+// HeatmapTarget is a target that can be drilled down into
+type HeatmapTarget struct {
+	Target string `json:"target"`
+	Count  int    `json:"count"`
+}
+
+// Heatmap holds global counts and PED per weekday and hour of day
+type Heatmap struct {
+	Type       string          `json:"type,omitempty"`
+	Target     string          `json:"target,omitempty"`
+	Globals    int             `json:"globals"`
+	TotalValue float64         `json:"total_value"`
+	Weekdays   []string        `json:"weekdays"`
+	Counts     [7][24]int      `json:"counts"`  // [weekday][hour], Monday first
+	Values     [7][24]float64  `json:"values"`  // PED, same layout as Counts
+	Targets    []HeatmapTarget `json:"targets"` // Most frequent targets of the selection
+}
+
+// GenerateHeatmap counts globals and PED per weekday and hour of day of their timestamps
+func GenerateHeatmap(globals []GlobalEntry, filter HeatmapFilter) Heatmap {
+	h := Heatmap{
+		Type:     filter.Type,
+		Target:   filter.Target,
+		Weekdays: HeatmapWeekdays,
+		Targets:  []HeatmapTarget{},
+	}
+
+	targets := make(map[string]int)
+	for _, entry := range globals {
+		if filter.Type != "" && entry.Type != filter.Type {
+			continue
+		}
+		if filter.Target != "" && !strings.EqualFold(entry.Target, filter.Target) {
+			continue
+		}
+		t, err := entry.Time()
+		if err != nil {
+			continue
+		}
+
+		day := heatmapWeekday(t)
+		h.Counts[day][t.Hour()]++
+		h.Values[day][t.Hour()] += entry.Value
+		h.Globals++
+		h.TotalValue += entry.Value
+		targets[entry.Target]++
+	}
+
+	for target, count := range targets {
+		h.Targets = append(h.Targets, HeatmapTarget{Target: target, Count: count})
+	}
+	sort.Slice(h.Targets, func(i, j int) bool {
+		if h.Targets[i].Count != h.Targets[j].Count {
+			return h.Targets[i].Count > h.Targets[j].Count
+		}
+		return h.Targets[i].Target < h.Targets[j].Target
+	})
+	if len(h.Targets) > maxHeatmapTargets {
+		h.Targets = h.Targets[:maxHeatmapTargets]
+	}
+
+	return h
+}
+
+// heatmapWeekday returns the heatmap row of t, Monday first
+func heatmapWeekday(t time.Time) int {
+	return (int(t.Weekday()) + 6) % 7
+}
@@ -0,0 +1,36 @@
+package model
+
+import "testing"
+
+func TestGenerateHeatmap(t *testing.T) {
+	t.Parallel()
+
+	globals := []GlobalEntry{
+		// 2025-05-12 is a Monday
+		{Timestamp: "2025-05-12 10:15:00", Type: "kill", Target: "Atrox", Value: 50},
+		{Timestamp: "2025-05-12 10:45:00", Type: "kill", Target: "atrox", Value: 70},
+		{Timestamp: "2025-05-18 23:59:00", Type: "find", Target: "Lysterium", Value: 100},
+		{Timestamp: "invalid", Type: "kill", Target: "Atrox", Value: 10},
+	}
+
+	h := GenerateHeatmap(globals, HeatmapFilter{})
+	if h.Globals != 3 || h.TotalValue != 220 {
+		t.Errorf("Globals = %d, TotalValue = %v, want 3 and 220", h.Globals, h.TotalValue)
+	}
+	if h.Counts[0][10] != 2 || h.Values[0][10] != 120 {
+		t.Errorf("Monday 10:00 = %d globals, %v PED, want 2 and 120", h.Counts[0][10], h.Values[0][10])
+	}
+	if h.Counts[6][23] != 1 {
+		t.Errorf("Sunday 23:00 = %d globals, want 1", h.Counts[6][23])
+	}
+
+	kills := GenerateHeatmap(globals, HeatmapFilter{Type: "kill", Target: "ATROX"})
+	if kills.Globals != 2 || kills.Counts[6][23] != 0 {
+		t.Errorf("Heatmap filtered to Atrox kills has %d globals", kills.Globals)
+	}
+
+	finds := GenerateHeatmap(globals, HeatmapFilter{Type: "find"})
+	if len(finds.Targets) != 1 || finds.Targets[0].Target != "Lysterium" {
+		t.Errorf("Targets = %+v, want only Lysterium", finds.Targets)
+	}
+}
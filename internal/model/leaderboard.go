@@ -0,0 +1,138 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DefaultLeaderboardSize is the number of entries per leaderboard when no limit is given
+const DefaultLeaderboardSize = 10
+
+// LeaderboardEntry ranks one player, team or creature
+type LeaderboardEntry struct {
+	Name       string  `json:"name"`
+	Count      int     `json:"count"`
+	TotalValue float64 `json:"total_value"`
+	MaxValue   float64 `json:"max_value"`
+	Hofs       int     `json:"hofs"`
+}
+
+// Leaderboard holds the top players, teams, creatures and HoFs of one period
+type Leaderboard struct {
+	Period     string             `json:"period"` // e.g. "2025-05-16", "2025-W20" or "2025-05"
+	Start      string             `json:"start"`  // First day of the period (2006-01-02)
+	Globals    int                `json:"globals"`
+	TotalValue float64            `json:"total_value"`
+	Players    []LeaderboardEntry `json:"players"`   // Highest total value first
+	Teams      []LeaderboardEntry `json:"teams"`     // Highest total value first
+	Creatures  []LeaderboardEntry `json:"creatures"` // Killed creatures, highest total value first
+	Hofs       []GlobalEntry      `json:"hofs"`      // Highest value first
+}
+
+// GenerateLeaderboards ranks globals per day, ISO week or month, newest period first.
+// Every ranking holds at most limit entries (DefaultLeaderboardSize if limit is not positive).
+func GenerateLeaderboards(globals []GlobalEntry, interval string, limit int) ([]Leaderboard, error) {
+	if !ValidInterval(interval) {
+		return nil, fmt.Errorf("invalid interval %q: must be day, week or month", interval)
+	}
+	if limit <= 0 {
+		limit = DefaultLeaderboardSize
+	}
+
+	byPeriod := make(map[time.Time][]GlobalEntry)
+	for _, entry := range globals {
+		t, err := entry.Time()
+		if err != nil {
+			continue
+		}
+		start := periodStart(t, interval)
+		byPeriod[start] = append(byPeriod[start], entry)
+	}
+
+	starts := make([]time.Time, 0, len(byPeriod))
+	for start := range byPeriod {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].After(starts[j]) })
+
+	boards := make([]Leaderboard, 0, len(starts))
+	for _, start := range starts {
+		board := newLeaderboard(byPeriod[start], limit)
+		board.Period = periodLabel(start, interval)
+		board.Start = start.Format("2006-01-02")
+		boards = append(boards, board)
+	}
+	return boards, nil
+}
+
+// newLeaderboard ranks the globals of one period
+func newLeaderboard(globals []GlobalEntry, limit int) Leaderboard {
+	board := Leaderboard{Globals: len(globals), Hofs: []GlobalEntry{}}
+
+	players := make(map[string]*LeaderboardEntry)
+	teams := make(map[string]*LeaderboardEntry)
+	creatures := make(map[string]*LeaderboardEntry)
+
+	for _, entry := range globals {
+		board.TotalValue += entry.Value
+		if entry.PlayerName != "" {
+			addToLeaderboard(players, entry.PlayerName, entry)
+		}
+		if entry.TeamName != "" {
+			addToLeaderboard(teams, entry.TeamName, entry)
+		}
+		if entry.Type == "kill" {
+			addToLeaderboard(creatures, entry.Target, entry)
+		}
+		if entry.IsHof {
+			board.Hofs = append(board.Hofs, entry)
+		}
+	}
+
+	board.Players = rankLeaderboard(players, limit)
+	board.Teams = rankLeaderboard(teams, limit)
+	board.Creatures = rankLeaderboard(creatures, limit)
+
+	sort.SliceStable(board.Hofs, func(i, j int) bool { return board.Hofs[i].Value > board.Hofs[j].Value })
+	if len(board.Hofs) > limit {
+		board.Hofs = board.Hofs[:limit]
+	}
+
+	return board
+}
+
+// addToLeaderboard counts a global towards the named leaderboard entry
+func addToLeaderboard(entries map[string]*LeaderboardEntry, name string, entry GlobalEntry) {
+	le, ok := entries[name]
+	if !ok {
+		le = &LeaderboardEntry{Name: name}
+		entries[name] = le
+	}
+	le.Count++
+	le.TotalValue += entry.Value
+	if entry.Value > le.MaxValue {
+		le.MaxValue = entry.Value
+	}
+	if entry.IsHof {
+		le.Hofs++
+	}
+}
+
+// rankLeaderboard orders leaderboard entries by total value and keeps the top limit
+func rankLeaderboard(entries map[string]*LeaderboardEntry, limit int) []LeaderboardEntry {
+	ranked := make([]LeaderboardEntry, 0, len(entries))
+	for _, le := range entries {
+		ranked = append(ranked, *le)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].TotalValue != ranked[j].TotalValue {
+			return ranked[i].TotalValue > ranked[j].TotalValue
+		}
+		return ranked[i].Name < ranked[j].Name
+	})
+	if len(ranked) > limit {
+		ranked = ranked[:limit]
+	}
+	return ranked
+}
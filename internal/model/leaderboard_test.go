@@ -0,0 +1,46 @@
+package model
+
+import "testing"
+
+func TestGenerateLeaderboards(t *testing.T) {
+	t.Parallel()
+
+	globals := []GlobalEntry{
+		{Timestamp: "2025-05-12 10:00:00", Type: "kill", PlayerName: "Alice", Target: "Atrox", Value: 100},
+		{Timestamp: "2025-05-13 10:00:00", Type: "kill", PlayerName: "Bob", Target: "Atrox", Value: 60},
+		{Timestamp: "2025-05-14 10:00:00", Type: "kill", PlayerName: "Bob", Target: "Daikiba", Value: 70},
+		{Timestamp: "2025-05-14 11:00:00", Type: "kill", TeamName: "Hunters", Target: "Daikiba", Value: 3000, IsHof: true},
+		{Timestamp: "2025-05-15 12:00:00", Type: "craft", PlayerName: "Alice", Target: "Pistol", Value: 1500, IsHof: true},
+		{Timestamp: "2025-05-20 10:00:00", Type: "find", PlayerName: "Carol", Target: "Lysterium", Value: 50},
+	}
+
+	boards, err := GenerateLeaderboards(globals, IntervalWeek, 2)
+	if err != nil {
+		t.Fatalf("GenerateLeaderboards() error = %v", err)
+	}
+	if len(boards) != 2 || boards[0].Period != "2025-W21" || boards[1].Period != "2025-W20" {
+		t.Fatalf("GenerateLeaderboards() periods = %+v, want 2025-W21 then 2025-W20", boards)
+	}
+
+	week := boards[1]
+	if week.Globals != 5 || week.TotalValue != 4730 {
+		t.Errorf("Globals = %d, TotalValue = %v, want 5 and 4730", week.Globals, week.TotalValue)
+	}
+	if len(week.Players) != 2 || week.Players[0].Name != "Alice" || week.Players[0].TotalValue != 1600 || week.Players[1].Count != 2 {
+		t.Errorf("Players = %+v, want Alice (1600) then Bob (2 globals)", week.Players)
+	}
+	if len(week.Teams) != 1 || week.Teams[0].Name != "Hunters" || week.Teams[0].Hofs != 1 {
+		t.Errorf("Teams = %+v, want Hunters with one HoF", week.Teams)
+	}
+	// Only kills count towards creatures
+	if len(week.Creatures) != 2 || week.Creatures[0].Name != "Daikiba" || week.Creatures[1].Name != "Atrox" {
+		t.Errorf("Creatures = %+v, want Daikiba then Atrox", week.Creatures)
+	}
+	if len(week.Hofs) != 2 || week.Hofs[0].Value != 3000 {
+		t.Errorf("Hofs = %+v, want the 3000 PED HoF first", week.Hofs)
+	}
+
+	if _, err := GenerateLeaderboards(globals, "year", 0); err == nil {
+		t.Errorf("GenerateLeaderboards() with invalid interval should fail")
+	}
+}
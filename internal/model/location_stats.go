@@ -0,0 +1,145 @@
+package model
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxLocationTopTargets is the number of top targets kept per location
+const maxLocationTopTargets = 3
+
+// LocationTarget holds the globals of one target at a location
+type LocationTarget struct {
+	Target     string  `json:"target"`
+	Count      int     `json:"count"`
+	TotalValue float64 `json:"total_value"`
+}
+
+// LocationStats holds statistics for a single hunting, mining or crafting location
+type LocationStats struct {
+	Location     string           `json:"location"`
+	Count        int              `json:"count"`
+	TotalValue   float64          `json:"total_value"`
+	AverageValue float64          `json:"average_value"`
+	MaxValue     float64          `json:"max_value"`
+	Hofs         int              `json:"hofs"`
+	TopTargets   []LocationTarget `json:"top_targets"` // Highest total value first
+	FirstSeen    string           `json:"first_seen"`  // Same layout as GlobalEntry.Timestamp
+	LastSeen     string           `json:"last_seen"`
+}
+
+// NormalizeLocation cleans up a location as captured from a global message or window title:
+// it trims whitespace and trailing punctuation, collapses inner whitespace and drops a leading "at".
+// Case is kept; use LocationKey to compare locations.
+func NormalizeLocation(location string) string {
+	location = strings.Join(strings.Fields(location), " ")
+	location = strings.TrimRight(location, "!. ")
+	for {
+		lower := strings.ToLower(location)
+		if lower == "at" {
+			return ""
+		}
+		if !strings.HasPrefix(lower, "at ") {
+			break
+		}
+		location = strings.TrimSpace(location[3:])
+	}
+	return location
+}
+
+// LocationKey returns the key under which locations are grouped
+func LocationKey(location string) string {
+	return strings.ToLower(NormalizeLocation(location))
+}
+
+// GenerateLocationStats computes statistics per location, ordered by total value (highest first).
+// Spellings that differ only in case, whitespace or an "at" prefix are grouped together and
+// shown with their most frequent spelling.
+func GenerateLocationStats(globals []GlobalEntry) []LocationStats {
+	byKey := make(map[string]*LocationStats)
+	spellings := make(map[string]map[string]int)
+	targets := make(map[string]map[string]*LocationTarget)
+
+	for _, entry := range globals {
+		name := NormalizeLocation(entry.Location)
+		if name == "" {
+			continue
+		}
+		key := strings.ToLower(name)
+
+		ls, ok := byKey[key]
+		if !ok {
+			ls = &LocationStats{FirstSeen: entry.Timestamp, LastSeen: entry.Timestamp}
+			byKey[key] = ls
+			spellings[key] = make(map[string]int)
+			targets[key] = make(map[string]*LocationTarget)
+		}
+		spellings[key][name]++
+
+		ls.Count++
+		ls.TotalValue += entry.Value
+		if entry.Value > ls.MaxValue {
+			ls.MaxValue = entry.Value
+		}
+		if entry.IsHof {
+			ls.Hofs++
+		}
+		// The timestamp layout sorts chronologically as a string
+		if entry.Timestamp < ls.FirstSeen {
+			ls.FirstSeen = entry.Timestamp
+		}
+		if entry.Timestamp > ls.LastSeen {
+			ls.LastSeen = entry.Timestamp
+		}
+
+		lt, ok := targets[key][entry.Target]
+		if !ok {
+			lt = &LocationTarget{Target: entry.Target}
+			targets[key][entry.Target] = lt
+		}
+		lt.Count++
+		lt.TotalValue += entry.Value
+	}
+
+	result := make([]LocationStats, 0, len(byKey))
+	for key, ls := range byKey {
+		ls.Location = mostFrequent(spellings[key])
+		ls.AverageValue = ls.TotalValue / float64(ls.Count)
+
+		top := make([]LocationTarget, 0, len(targets[key]))
+		for _, lt := range targets[key] {
+			top = append(top, *lt)
+		}
+		sort.Slice(top, func(i, j int) bool {
+			if top[i].TotalValue != top[j].TotalValue {
+				return top[i].TotalValue > top[j].TotalValue
+			}
+			return top[i].Target < top[j].Target
+		})
+		if len(top) > maxLocationTopTargets {
+			top = top[:maxLocationTopTargets]
+		}
+		ls.TopTargets = top
+
+		result = append(result, *ls)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].TotalValue != result[j].TotalValue {
+			return result[i].TotalValue > result[j].TotalValue
+		}
+		return result[i].Location < result[j].Location
+	})
+	return result
+}
+
+// mostFrequent returns the most counted string, preferring the alphabetically first on ties
+func mostFrequent(counts map[string]int) string {
+	best, bestCount := "", 0
+	for s, c := range counts {
+		if c > bestCount || (c == bestCount && s < best) {
+			best, bestCount = s, c
+		}
+	}
+	return best
+}
@@ -0,0 +1,59 @@
+package model
+
+import "testing"
+
+func TestNormalizeLocation(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "OLA#63!", expected: "OLA#63"},
+		{input: "  Zone   A  ", expected: "Zone A"},
+		{input: "at Zone A", expected: "Zone A"},
+		{input: "At  at zone a!", expected: "zone a"},
+		{input: "Atrox Plains", expected: "Atrox Plains"},
+		{input: "at", expected: ""},
+		{input: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		if result := NormalizeLocation(tt.input); result != tt.expected {
+			t.Errorf("NormalizeLocation(%q) = %q, want %q", tt.input, result, tt.expected)
+		}
+	}
+}
+
+func TestGenerateLocationStats(t *testing.T) {
+	t.Parallel()
+
+	globals := []GlobalEntry{
+		{Timestamp: "2025-05-02 10:00:00", Target: "Atrox", Value: 100, Location: "Zone A"},
+		{Timestamp: "2025-05-01 10:00:00", Target: "Atrox", Value: 50, Location: "at zone a!", IsHof: true},
+		{Timestamp: "2025-05-03 10:00:00", Target: "Daikiba", Value: 60, Location: "Zone  A"},
+		{Timestamp: "2025-05-04 10:00:00", Target: "Atrox", Value: 40, Location: "Zone B"},
+		{Timestamp: "2025-05-04 11:00:00", Target: "Atrox", Value: 40},
+	}
+
+	locations := GenerateLocationStats(globals)
+	if len(locations) != 2 {
+		t.Fatalf("GenerateLocationStats() returned %d locations, want 2: %+v", len(locations), locations)
+	}
+
+	zoneA := locations[0]
+	if zoneA.Location != "Zone A" || zoneA.Count != 3 || zoneA.TotalValue != 210 || zoneA.AverageValue != 70 || zoneA.Hofs != 1 {
+		t.Errorf("Zone A stats = %+v", zoneA)
+	}
+	if zoneA.FirstSeen != "2025-05-01 10:00:00" || zoneA.LastSeen != "2025-05-03 10:00:00" {
+		t.Errorf("Zone A seen %s to %s", zoneA.FirstSeen, zoneA.LastSeen)
+	}
+	if len(zoneA.TopTargets) != 2 || zoneA.TopTargets[0].Target != "Atrox" || zoneA.TopTargets[0].TotalValue != 150 {
+		t.Errorf("Zone A top targets = %+v", zoneA.TopTargets)
+	}
+
+	stats := GenerateStatsFromGlobals(globals)
+	if stats.ByLocation["Zone A"] != 3 || stats.ByLocation["Zone B"] != 1 || len(stats.ByLocation) != 2 {
+		t.Errorf("GenerateStatsFromGlobals() ByLocation = %v", stats.ByLocation)
+	}
+}
@@ -0,0 +1,201 @@
+package model
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Markup is the market value of an item or resource relative to its TT value, either as a
+// percentage of TT (125 for 125%) or as an absolute amount of PED on top of TT per global
+type Markup struct {
+	Item     string  `json:"item"`
+	Percent  float64 `json:"percent,omitempty"`
+	Absolute float64 `json:"absolute,omitempty"`
+}
+
+// Apply returns the market value of a global worth tt PED
+func (m Markup) Apply(tt float64) float64 {
+	if m.Percent != 0 {
+		return tt * m.Percent / 100
+	}
+	return tt + m.Absolute
+}
+
+// String formats the markup the way ParseMarkup reads it, e.g. "125%" or "+5.00"
+func (m Markup) String() string {
+	if m.Percent != 0 {
+		return strconv.FormatFloat(m.Percent, 'f', -1, 64) + "%"
+	}
+	return fmt.Sprintf("%+.2f", m.Absolute)
+}
+
+// ValidateMarkup checks that a markup names an item and sets either a positive percentage or
+// an absolute amount
+func ValidateMarkup(m Markup) error {
+	if strings.TrimSpace(m.Item) == "" {
+		return errors.New("markup needs an item or resource name")
+	}
+	if m.Percent < 0 {
+		return fmt.Errorf("markup of %s: percentage must be positive", m.Item)
+	}
+	if m.Percent != 0 && m.Absolute != 0 {
+		return fmt.Errorf("markup of %s: set either a percentage or an absolute amount, not both", m.Item)
+	}
+	return nil
+}
+
+// ParseMarkup parses the markup of an item: "125%" for a percentage of TT, "+5" or "5" for an
+// absolute amount of PED on top of TT
+func ParseMarkup(item, value string) (Markup, error) {
+	m := Markup{Item: strings.TrimSpace(item)}
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(value, "%")), 64)
+		if err != nil || percent <= 0 {
+			return Markup{}, fmt.Errorf("invalid markup %q of %s: percentage must be a positive number", value, m.Item)
+		}
+		m.Percent = percent
+	} else {
+		absolute, err := strconv.ParseFloat(strings.TrimPrefix(value, "+"), 64)
+		if err != nil {
+			return Markup{}, fmt.Errorf("invalid markup %q of %s: use e.g. 125%% or +5", value, m.Item)
+		}
+		m.Absolute = absolute
+	}
+	return m, ValidateMarkup(m)
+}
+
+// ParseMarkupCSV reads markups from CSV rows of item and markup, e.g. "Animal Oil Residue,102%".
+// A header row starting with "item" and empty rows are skipped.
+func ParseMarkupCSV(r io.Reader) ([]Markup, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var markups []Markup
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) == 0 || (len(record) == 1 && strings.TrimSpace(record[0]) == "") {
+			continue
+		}
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "item") {
+			continue
+		}
+		if len(record) < 2 {
+			return nil, fmt.Errorf("line %d: expected item and markup", line)
+		}
+		m, err := ParseMarkup(record[0], record[1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		markups = append(markups, m)
+	}
+	return markups, nil
+}
+
+// MergeMarkups returns markups with the updates applied: an update replaces the markup of the
+// same item (ignoring case) or is added at the end
+func MergeMarkups(markups, updates []Markup) []Markup {
+	merged := append([]Markup{}, markups...)
+	for _, u := range updates {
+		replaced := false
+		for i := range merged {
+			if strings.EqualFold(merged[i].Item, u.Item) {
+				merged[i] = u
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, u)
+		}
+	}
+	return merged
+}
+
+// MarkupTable looks up markups by item name, ignoring case
+type MarkupTable map[string]Markup
+
+// NewMarkupTable validates markups and indexes them by item
+func NewMarkupTable(markups []Markup) (MarkupTable, error) {
+	table := make(MarkupTable, len(markups))
+	for _, m := range markups {
+		if err := ValidateMarkup(m); err != nil {
+			return nil, err
+		}
+		key := strings.ToLower(strings.TrimSpace(m.Item))
+		if _, ok := table[key]; ok {
+			return nil, fmt.Errorf("duplicate markup for %s", m.Item)
+		}
+		table[key] = m
+	}
+	return table, nil
+}
+
+// Lookup returns the markup of an item
+func (t MarkupTable) Lookup(item string) (Markup, bool) {
+	m, ok := t[strings.ToLower(strings.TrimSpace(item))]
+	return m, ok
+}
+
+// Markups returns the markups in the table ordered by item
+func (t MarkupTable) Markups() []Markup {
+	markups := make([]Markup, 0, len(t))
+	for _, m := range t {
+		markups = append(markups, m)
+	}
+	sort.Slice(markups, func(i, j int) bool { return strings.ToLower(markups[i].Item) < strings.ToLower(markups[j].Item) })
+	return markups
+}
+
+// MissingMarkup is a target of globals that has no markup, so it is valued at TT
+type MissingMarkup struct {
+	Item       string  `json:"item"`
+	Type       string  `json:"type"`
+	Count      int     `json:"count"`
+	TotalValue float64 `json:"total_value"` // TT value in PED
+}
+
+// MarkupReport lists the markups in use and the targets that have none
+type MarkupReport struct {
+	Markups []Markup        `json:"markups"`
+	Missing []MissingMarkup `json:"missing"`
+}
+
+// ApplyMarkups fills in the market values of stats from the per-target statistics. Targets
+// without a markup count at TT value and are listed in MissingMarkups, highest TT value first.
+func ApplyMarkups(stats *Stats, table MarkupTable) {
+	stats.TotalMarketValue = 0
+	stats.MissingMarkups = []MissingMarkup{}
+	for i := range stats.ByTarget {
+		ts := &stats.ByTarget[i]
+		m, ok := table.Lookup(ts.Target)
+		ts.HasMarkup = ok
+		if ok {
+			ts.MarketValue = m.Apply(ts.AverageValue) * float64(ts.Count)
+		} else {
+			ts.MarketValue = ts.TotalValue
+			stats.MissingMarkups = append(stats.MissingMarkups, MissingMarkup{
+				Item:       ts.Target,
+				Type:       ts.Type,
+				Count:      ts.Count,
+				TotalValue: ts.TotalValue,
+			})
+		}
+		stats.TotalMarketValue += ts.MarketValue
+	}
+	sort.SliceStable(stats.MissingMarkups, func(i, j int) bool {
+		return stats.MissingMarkups[i].TotalValue > stats.MissingMarkups[j].TotalValue
+	})
+}
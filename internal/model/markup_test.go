@@ -0,0 +1,129 @@
+package model
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMarkup(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		value   string
+		want    Markup
+		wantErr bool
+	}{
+		{value: "125%", want: Markup{Item: "Shrapnel", Percent: 125}},
+		{value: " 101.5 % ", want: Markup{Item: "Shrapnel", Percent: 101.5}},
+		{value: "+5", want: Markup{Item: "Shrapnel", Absolute: 5}},
+		{value: "2.5", want: Markup{Item: "Shrapnel", Absolute: 2.5}},
+		{value: "-1", want: Markup{Item: "Shrapnel", Absolute: -1}},
+		{value: "0%", wantErr: true},
+		{value: "abc", wantErr: true},
+		{value: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMarkup(" Shrapnel ", tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseMarkup(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseMarkup(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+
+	if _, err := ParseMarkup("", "110%"); err == nil {
+		t.Error("ParseMarkup() without an item should fail")
+	}
+}
+
+func TestParseMarkupCSV(t *testing.T) {
+	t.Parallel()
+
+	input := "item,markup\nAnimal Oil Residue,102%\n\n\"Nexus, Ltd\",+3\n"
+	got, err := ParseMarkupCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseMarkupCSV() error = %v", err)
+	}
+	want := []Markup{{Item: "Animal Oil Residue", Percent: 102}, {Item: "Nexus, Ltd", Absolute: 3}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseMarkupCSV() = %+v, want %+v", got, want)
+	}
+
+	if _, err := ParseMarkupCSV(strings.NewReader("Shrapnel,101%\nOil\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("ParseMarkupCSV() error = %v, want one on line 2", err)
+	}
+}
+
+func TestMarkupTable(t *testing.T) {
+	t.Parallel()
+
+	merged := MergeMarkups(
+		[]Markup{{Item: "Shrapnel", Percent: 101}, {Item: "Oil", Percent: 110}},
+		[]Markup{{Item: "oil", Percent: 120}, {Item: "Lysterium Ore", Absolute: 1}},
+	)
+	want := []Markup{{Item: "Shrapnel", Percent: 101}, {Item: "oil", Percent: 120}, {Item: "Lysterium Ore", Absolute: 1}}
+	if !reflect.DeepEqual(merged, want) {
+		t.Fatalf("MergeMarkups() = %+v, want %+v", merged, want)
+	}
+
+	table, err := NewMarkupTable(merged)
+	if err != nil {
+		t.Fatalf("NewMarkupTable() error = %v", err)
+	}
+	if m, ok := table.Lookup("OIL"); !ok || m.Percent != 120 {
+		t.Errorf("Lookup(OIL) = %+v, %v, want 120%%", m, ok)
+	}
+	if names := table.Markups(); names[0].Item != "Lysterium Ore" || names[2].Item != "Shrapnel" {
+		t.Errorf("Markups() = %+v, want ordered by item", names)
+	}
+
+	if _, err := NewMarkupTable([]Markup{{Item: "Oil", Percent: 110}, {Item: "OIL", Percent: 120}}); err == nil {
+		t.Error("NewMarkupTable() with a duplicate item should fail")
+	}
+	if _, err := NewMarkupTable([]Markup{{Item: "Oil", Percent: 110, Absolute: 1}}); err == nil {
+		t.Error("NewMarkupTable() with both a percentage and an absolute amount should fail")
+	}
+}
+
+func TestApplyMarkups(t *testing.T) {
+	t.Parallel()
+
+	globals := []GlobalEntry{
+		{Timestamp: "2025-05-16 10:00:00", Type: "find", Target: "Oil", Value: 100},
+		{Timestamp: "2025-05-16 11:00:00", Type: "find", Target: "Oil", Value: 60},
+		{Timestamp: "2025-05-16 12:00:00", Type: "craft", Target: "Explosive Projectiles", Value: 80},
+		{Timestamp: "2025-05-16 13:00:00", Type: "kill", Target: "Atrox", Value: 200},
+	}
+	stats := GenerateStatsFromGlobals(globals)
+	if stats.TotalMarketValue != stats.TotalValue || len(stats.MissingMarkups) != 3 {
+		t.Fatalf("without markups: market %v, missing %d, want TT %v and 3 missing", stats.TotalMarketValue, len(stats.MissingMarkups), stats.TotalValue)
+	}
+
+	table, _ := NewMarkupTable([]Markup{{Item: "oil", Percent: 150}, {Item: "Explosive Projectiles", Absolute: 2}})
+	ApplyMarkups(&stats, table)
+
+	market := make(map[string]TargetStats)
+	for _, ts := range stats.ByTarget {
+		market[ts.Target] = ts
+	}
+	if ts := market["Oil"]; !ts.HasMarkup || math.Abs(ts.MarketValue-240) > 1e-9 {
+		t.Errorf("Oil = %+v, want market value 240", ts)
+	}
+	if ts := market["Explosive Projectiles"]; !ts.HasMarkup || ts.MarketValue != 82 {
+		t.Errorf("Explosive Projectiles = %+v, want market value 82", ts)
+	}
+	if ts := market["Atrox"]; ts.HasMarkup || ts.MarketValue != 200 {
+		t.Errorf("Atrox = %+v, want TT value 200 without a markup", ts)
+	}
+	if math.Abs(stats.TotalMarketValue-522) > 1e-9 || stats.TotalValue != 440 {
+		t.Errorf("TotalMarketValue = %v, TotalValue = %v, want 522 and 440", stats.TotalMarketValue, stats.TotalValue)
+	}
+	want := []MissingMarkup{{Item: "Atrox", Type: "kill", Count: 1, TotalValue: 200}}
+	if !reflect.DeepEqual(stats.MissingMarkups, want) {
+		t.Errorf("MissingMarkups = %+v, want %+v", stats.MissingMarkups, want)
+	}
+}
@@ -0,0 +1,156 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Overlay widgets
+const (
+	OverlayWidgetLatest  = "latest"  // The latest global or HoF, animated in
+	OverlayWidgetSession = "session" // Running totals of the current session
+	OverlayWidgetTicker  = "ticker"  // Scrolling list of recent globals
+)
+
+// Overlay events
+const (
+	OverlayEventGlobal      = "global"      // Globals below the HoF threshold
+	OverlayEventHof         = "hof"         // Hall of Fame globals
+	OverlayEventRecord      = "record"      // Personal records
+	OverlayEventAchievement = "achievement" // Unlocked achievements
+	OverlayEventGoal        = "goal"        // Completed goals
+)
+
+// OverlayWidgets are the widgets an overlay can show, in display order
+var OverlayWidgets = []string{OverlayWidgetLatest, OverlayWidgetSession, OverlayWidgetTicker}
+
+// OverlayEvents are the events an overlay can show
+var OverlayEvents = []string{OverlayEventGlobal, OverlayEventHof, OverlayEventRecord, OverlayEventAchievement, OverlayEventGoal}
+
+// OverlayProfile holds the settings of the streaming overlay, saved under a name so a browser
+// source only needs /overlay?profile=name
+type OverlayProfile struct {
+	Name           string   `yaml:"name" json:"name"`
+	Widgets        []string `yaml:"widgets" json:"widgets"`                 // latest, session and ticker
+	Events         []string `yaml:"events" json:"events"`                   // global, hof, record, achievement and goal
+	MinValue       float64  `yaml:"min_value" json:"min_value"`             // Globals below this PED are not shown
+	HighlightValue float64  `yaml:"highlight_value" json:"highlight_value"` // Globals from this PED are highlighted like HoFs, 0 for HoFs only
+	Background     string   `yaml:"background" json:"background"`           // transparent, or a chroma key colour such as #00ff00
+	TextColor      string   `yaml:"text_color" json:"text_color"`
+	AccentColor    string   `yaml:"accent_color" json:"accent_color"`
+	HofColor       string   `yaml:"hof_color" json:"hof_color"`     // Colour of HoFs and highlighted globals
+	Duration       int      `yaml:"duration" json:"duration"`       // Seconds the latest global stays in view, 0 to keep it
+	TickerSize     int      `yaml:"ticker_size" json:"ticker_size"` // Globals in the ticker
+	FontSize       int      `yaml:"font_size" json:"font_size"`     // Pixels
+}
+
+// DefaultOverlayProfile returns the settings used for anything a profile or query does not set
+func DefaultOverlayProfile() OverlayProfile {
+	return OverlayProfile{
+		Widgets:     slices.Clone(OverlayWidgets),
+		Events:      []string{OverlayEventGlobal, OverlayEventHof},
+		Background:  "transparent",
+		TextColor:   "#ffffff",
+		AccentColor: "#4caf50",
+		HofColor:    "#ffc107",
+		Duration:    10,
+		TickerSize:  10,
+		FontSize:    24,
+	}
+}
+
+var (
+	overlayNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,40}$`)
+	// Colours end up in CSS, so only hex colours, names and rgb()/hsl() forms are accepted
+	overlayColorPattern = regexp.MustCompile(`^(#[0-9A-Fa-f]{3,8}|[A-Za-z]{3,20}|(rgb|rgba|hsl|hsla)\([0-9., %]+\))$`)
+)
+
+// ValidateOverlayProfile checks that a profile can be saved and shown. Names are used in
+// URLs, so they are limited to letters, digits, dashes and underscores.
+func ValidateOverlayProfile(p OverlayProfile) error {
+	if !overlayNamePattern.MatchString(p.Name) {
+		return fmt.Errorf("invalid overlay name %q: use up to 40 letters, digits, dashes or underscores", p.Name)
+	}
+	return ValidateOverlaySettings(p)
+}
+
+// ValidateOverlaySettings checks the settings of a profile, whatever its name
+func ValidateOverlaySettings(p OverlayProfile) error {
+	for _, w := range p.Widgets {
+		if !slices.Contains(OverlayWidgets, w) {
+			return fmt.Errorf("invalid overlay widget %q: must be one of %s", w, strings.Join(OverlayWidgets, ", "))
+		}
+	}
+	for _, e := range p.Events {
+		if !slices.Contains(OverlayEvents, e) {
+			return fmt.Errorf("invalid overlay event %q: must be one of %s", e, strings.Join(OverlayEvents, ", "))
+		}
+	}
+	if p.MinValue < 0 || p.HighlightValue < 0 {
+		return fmt.Errorf("overlay values must not be negative")
+	}
+	for _, c := range []string{p.Background, p.TextColor, p.AccentColor, p.HofColor} {
+		if !overlayColorPattern.MatchString(c) {
+			return fmt.Errorf("invalid overlay colour %q: use a name such as transparent or a hex colour such as #00ff00", c)
+		}
+	}
+	if p.Duration < 0 || p.Duration > 3600 {
+		return fmt.Errorf("overlay duration must be between 0 and 3600 seconds")
+	}
+	if p.TickerSize < 1 || p.TickerSize > 50 {
+		return fmt.Errorf("overlay ticker size must be between 1 and 50")
+	}
+	if p.FontSize < 8 || p.FontSize > 200 {
+		return fmt.Errorf("overlay font size must be between 8 and 200 pixels")
+	}
+	return nil
+}
+
+// NormalizeOverlayColor turns a hex colour without # into one with it, since # has to be
+// escaped in URLs
+func NormalizeOverlayColor(c string) string {
+	c = strings.TrimSpace(c)
+	if len(c) >= 3 && len(c) <= 8 && strings.Trim(strings.ToLower(c), "0123456789abcdef") == "" {
+		return "#" + c
+	}
+	return c
+}
+
+// WithDefaults fills the colours, sizes, widgets and events a profile leaves unset with those
+// of DefaultOverlayProfile. A duration of 0 is kept, as it means the latest global stays.
+func (p OverlayProfile) WithDefaults() OverlayProfile {
+	d := DefaultOverlayProfile()
+	if p.Widgets == nil {
+		p.Widgets = d.Widgets
+	}
+	if p.Events == nil {
+		p.Events = d.Events
+	}
+	colors := []*string{&p.Background, &p.TextColor, &p.AccentColor, &p.HofColor}
+	defaults := []string{d.Background, d.TextColor, d.AccentColor, d.HofColor}
+	for i, c := range colors {
+		if *c == "" {
+			*c = defaults[i]
+		} else {
+			*c = NormalizeOverlayColor(*c)
+		}
+	}
+	if p.TickerSize == 0 {
+		p.TickerSize = d.TickerSize
+	}
+	if p.FontSize == 0 {
+		p.FontSize = d.FontSize
+	}
+	return p
+}
+
+// ShowsGlobal returns whether a global passes the profile's event and value filters
+func (p OverlayProfile) ShowsGlobal(g GlobalEntry) bool {
+	event := OverlayEventGlobal
+	if g.IsHof {
+		event = OverlayEventHof
+	}
+	return slices.Contains(p.Events, event) && g.Value >= p.MinValue
+}
@@ -0,0 +1,70 @@
+package model
+
+import "testing"
+
+func TestValidateOverlayProfile(t *testing.T) {
+	t.Parallel()
+
+	valid := DefaultOverlayProfile()
+	valid.Name = "stream-1"
+	if err := ValidateOverlayProfile(valid); err != nil {
+		t.Fatalf("ValidateOverlayProfile(default) error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(p *OverlayProfile)
+	}{
+		{"name with a slash", func(p *OverlayProfile) { p.Name = "a/b" }},
+		{"empty name", func(p *OverlayProfile) { p.Name = "" }},
+		{"unknown widget", func(p *OverlayProfile) { p.Widgets = []string{"clock"} }},
+		{"unknown event", func(p *OverlayProfile) { p.Events = []string{"loot"} }},
+		{"negative value", func(p *OverlayProfile) { p.MinValue = -1 }},
+		{"css injection", func(p *OverlayProfile) { p.Background = "red; background: url(x)" }},
+		{"zero ticker", func(p *OverlayProfile) { p.TickerSize = 0 }},
+		{"tiny font", func(p *OverlayProfile) { p.FontSize = 2 }},
+	}
+	for _, tt := range tests {
+		p := valid
+		tt.change(&p)
+		if err := ValidateOverlayProfile(p); err == nil {
+			t.Errorf("%s: ValidateOverlayProfile() accepted %+v", tt.name, p)
+		}
+	}
+
+	for _, c := range []string{"transparent", "#0f0", "#00ff0080", "rgba(0, 0, 0, 0.5)", "Lime"} {
+		p := valid
+		p.HofColor = c
+		if err := ValidateOverlayProfile(p); err != nil {
+			t.Errorf("colour %q rejected: %v", c, err)
+		}
+	}
+}
+
+func TestOverlayProfileWithDefaults(t *testing.T) {
+	t.Parallel()
+
+	p := OverlayProfile{Name: "green", Widgets: []string{}, Background: "00ff00"}.WithDefaults()
+	if p.Background != "#00ff00" || p.TextColor != "#ffffff" || p.TickerSize != 10 || p.FontSize != 24 {
+		t.Errorf("WithDefaults() = %+v, want #00ff00 and default colours and sizes", p)
+	}
+	if len(p.Widgets) != 0 || len(p.Events) != 2 {
+		t.Errorf("WithDefaults() widgets %v, events %v, want no widgets and the default events", p.Widgets, p.Events)
+	}
+
+	p.MinValue = 50
+	tests := []struct {
+		global GlobalEntry
+		want   bool
+	}{
+		{GlobalEntry{Value: 60}, true},
+		{GlobalEntry{Value: 40}, false},
+		{GlobalEntry{Value: 40, IsHof: true}, false},
+		{GlobalEntry{Value: 1000, IsHof: true}, true},
+	}
+	for _, tt := range tests {
+		if got := p.ShowsGlobal(tt.global); got != tt.want {
+			t.Errorf("ShowsGlobal(%+v) = %v, want %v", tt.global, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package model
+
+import (
+	"sort"
+)
+
+// Record scopes: a global can beat the previous best overall, of its type and of its target
+const (
+	RecordOverall = "overall"
+	RecordType    = "type"
+	RecordTarget  = "target"
+)
+
+// RecordTracker keeps the best values seen so far to detect personal records. Globals must be
+// added in timestamp order.
+type RecordTracker struct {
+	overall  float64
+	seen     bool
+	byType   map[string]float64
+	byTarget map[string]float64
+}
+
+// NewRecordTracker creates a tracker without any globals seen
+func NewRecordTracker() *RecordTracker {
+	return &RecordTracker{
+		byType:   make(map[string]float64),
+		byTarget: make(map[string]float64),
+	}
+}
+
+// Add counts a global and returns the scopes in which it beats the previous best, with the
+// previous best of each. The first global of a scope sets no record, as there is nothing to beat.
+func (t *RecordTracker) Add(typ, target string, value float64) ([]string, map[string]float64) {
+	var scopes []string
+	previous := make(map[string]float64)
+
+	if t.seen && value > t.overall {
+		scopes = append(scopes, RecordOverall)
+		previous[RecordOverall] = t.overall
+	}
+	if !t.seen || value > t.overall {
+		t.overall = value
+		t.seen = true
+	}
+
+	if best, ok := t.byType[typ]; !ok || value > best {
+		if ok {
+			scopes = append(scopes, RecordType)
+			previous[RecordType] = best
+		}
+		t.byType[typ] = value
+	}
+
+	key := typ + "\x00" + target
+	if best, ok := t.byTarget[key]; !ok || value > best {
+		if ok {
+			scopes = append(scopes, RecordTarget)
+			previous[RecordTarget] = best
+		}
+		t.byTarget[key] = value
+	}
+
+	return scopes, previous
+}
+
+// RecordBook holds the current personal bests and the globals that set a record
+type RecordBook struct {
+	Overall  *GlobalEntry  `json:"overall"`   // Highest global, nil without globals
+	ByType   []GlobalEntry `json:"by_type"`   // Highest global per type, ordered by type
+	ByTarget []GlobalEntry `json:"by_target"` // Highest global per target, highest value first
+	History  []GlobalEntry `json:"history"`   // Globals flagged as records, newest first
+}
+
+// GenerateRecordBook finds the highest global overall, per type and per target. On ties the
+// earliest global holds the record.
+func GenerateRecordBook(globals []GlobalEntry) RecordBook {
+	sorted := make([]GlobalEntry, len(globals))
+	copy(sorted, globals)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp < sorted[j].Timestamp })
+
+	book := RecordBook{ByType: []GlobalEntry{}, ByTarget: []GlobalEntry{}, History: []GlobalEntry{}}
+	byType := make(map[string]int)
+	byTarget := make(map[string]int)
+
+	for _, entry := range sorted {
+		if book.Overall == nil || entry.Value > book.Overall.Value {
+			e := entry
+			book.Overall = &e
+		}
+		if i, ok := byType[entry.Type]; !ok {
+			byType[entry.Type] = len(book.ByType)
+			book.ByType = append(book.ByType, entry)
+		} else if entry.Value > book.ByType[i].Value {
+			book.ByType[i] = entry
+		}
+		key := entry.Type + "\x00" + entry.Target
+		if i, ok := byTarget[key]; !ok {
+			byTarget[key] = len(book.ByTarget)
+			book.ByTarget = append(book.ByTarget, entry)
+		} else if entry.Value > book.ByTarget[i].Value {
+			book.ByTarget[i] = entry
+		}
+		if len(entry.Records) > 0 {
+			book.History = append(book.History, entry)
+		}
+	}
+
+	sort.Slice(book.ByType, func(i, j int) bool { return book.ByType[i].Type < book.ByType[j].Type })
+	sort.SliceStable(book.ByTarget, func(i, j int) bool { return book.ByTarget[i].Value > book.ByTarget[j].Value })
+	for i, j := 0, len(book.History)-1; i < j; i, j = i+1, j-1 {
+		book.History[i], book.History[j] = book.History[j], book.History[i]
+	}
+
+	return book
+}
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRecordTracker(t *testing.T) {
+	t.Parallel()
+
+	tracker := NewRecordTracker()
+	steps := []struct {
+		typ, target string
+		value       float64
+		scopes      []string
+	}{
+		{"kill", "Atrox", 100, nil},                                               // Nothing to beat yet
+		{"kill", "Atrox", 80, nil},                                                // Lower
+		{"kill", "Daikiba", 90, nil},                                              // First Daikiba
+		{"kill", "Daikiba", 95, []string{RecordTarget}},                           // Best Daikiba only
+		{"craft", "Pistol", 150, []string{RecordOverall}},                         // First craft, but the best global
+		{"kill", "Atrox", 200, []string{RecordOverall, RecordType, RecordTarget}}, // Beats everything
+		{"kill", "Atrox", 200, nil},                                               // Ties are no record
+	}
+	for i, step := range steps {
+		scopes, previous := tracker.Add(step.typ, step.target, step.value)
+		if !reflect.DeepEqual(scopes, step.scopes) {
+			t.Errorf("step %d: scopes = %v, want %v", i, scopes, step.scopes)
+		}
+		for _, scope := range scopes {
+			if previous[scope] >= step.value {
+				t.Errorf("step %d: previous %s best %v not below %v", i, scope, previous[scope], step.value)
+			}
+		}
+	}
+}
+
+func TestGenerateRecordBook(t *testing.T) {
+	t.Parallel()
+
+	globals := []GlobalEntry{
+		{Timestamp: "2025-05-02 10:00:00", Type: "kill", Target: "Atrox", Value: 300, Records: []string{RecordOverall}},
+		{Timestamp: "2025-05-01 10:00:00", Type: "kill", Target: "Atrox", Value: 100},
+		{Timestamp: "2025-05-03 10:00:00", Type: "craft", Target: "Pistol", Value: 300},
+		{Timestamp: "2025-05-04 10:00:00", Type: "kill", Target: "Daikiba", Value: 50},
+	}
+
+	book := GenerateRecordBook(globals)
+	// The earliest of two equal globals holds the record
+	if book.Overall == nil || book.Overall.Target != "Atrox" {
+		t.Errorf("Overall = %+v, want the 300 PED Atrox", book.Overall)
+	}
+	if len(book.ByType) != 2 || book.ByType[0].Type != "craft" || book.ByType[1].Value != 300 {
+		t.Errorf("ByType = %+v, want craft then kill (300)", book.ByType)
+	}
+	if len(book.ByTarget) != 3 || book.ByTarget[2].Target != "Daikiba" {
+		t.Errorf("ByTarget = %+v, want Daikiba last", book.ByTarget)
+	}
+	if len(book.History) != 1 || book.History[0].Timestamp != "2025-05-02 10:00:00" {
+		t.Errorf("History = %+v, want the flagged Atrox", book.History)
+	}
+
+	if empty := GenerateRecordBook(nil); empty.Overall != nil || empty.History == nil {
+		t.Errorf("GenerateRecordBook(nil) = %+v, want no overall and empty lists", empty)
+	}
+}
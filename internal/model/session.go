@@ -0,0 +1,62 @@
+package model
+
+import "time"
+
+// SessionSummary summarizes the globals of one play session
+type SessionSummary struct {
+	ID              string   `json:"id"`
+	Start           string   `json:"start"` // Same layout as GlobalEntry.Timestamp
+	End             string   `json:"end"`   // Last activity so far for open sessions
+	DurationSeconds float64  `json:"duration_seconds"`
+	Open            bool     `json:"open"`
+	Lines           int      `json:"lines"` // Chat lines seen during the session
+	Globals         int      `json:"globals"`
+	Hofs            int      `json:"hofs"`
+	TotalValue      float64  `json:"total_value"`
+	Locations       []string `json:"locations"` // Highest total value first
+}
+
+// SessionDetail holds a session summary with its globals and per-target statistics
+type SessionDetail struct {
+	SessionSummary
+	Entries []GlobalEntry `json:"entries"` // Oldest first
+	Targets []TargetStats `json:"targets"`
+}
+
+// SessionGlobals returns the globals between start and end (inclusive)
+func SessionGlobals(globals []GlobalEntry, start, end time.Time) []GlobalEntry {
+	result := []GlobalEntry{}
+	for _, entry := range globals {
+		t, err := entry.Time()
+		if err != nil || t.Before(start) || t.After(end) {
+			continue
+		}
+		result = append(result, entry)
+	}
+	return result
+}
+
+// SummarizeSession summarizes the globals of a session, which must already be limited to the
+// session with SessionGlobals
+func SummarizeSession(id string, start, end time.Time, open bool, lines int, globals []GlobalEntry) SessionSummary {
+	summary := SessionSummary{
+		ID:              id,
+		Start:           start.Format(TimestampLayout),
+		End:             end.Format(TimestampLayout),
+		DurationSeconds: end.Sub(start).Seconds(),
+		Open:            open,
+		Lines:           lines,
+		Globals:         len(globals),
+		Locations:       []string{},
+	}
+	for _, entry := range globals {
+		summary.TotalValue += entry.Value
+		if entry.IsHof {
+			summary.Hofs++
+		}
+	}
+	for _, ls := range GenerateLocationStats(globals) {
+		summary.Locations = append(summary.Locations, ls.Location)
+	}
+	return summary
+}
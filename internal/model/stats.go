@@ -7,18 +7,24 @@ type Stats struct {
 	HighestValue     float64
 	HighestValueItem string
 	TotalValue       float64
+	TotalMarketValue float64 // TotalValue with markups applied
 	ByType           map[string]int
 	ByLocation       map[string]int
+	ByTarget         []TargetStats   // Per creature, item and deposit, highest total value first
+	Locations        []LocationStats // Per location, highest total value first
+	Values           ValueDistribution
+	MissingMarkups   []MissingMarkup // Targets valued at TT for lack of a markup
 }
 
 // GlobalEntry represents a single global message (copied for model independence)
 type GlobalEntry struct {
-	Timestamp  string  `json:"timestamp"`
-	Type       string  `json:"type"`
-	PlayerName string  `json:"playerName"`
-	TeamName   string  `json:"teamName,omitempty"`
-	Target     string  `json:"target"`
-	Value      float64 `json:"value"`
-	Location   string  `json:"location,omitempty"`
-	IsHof      bool    `json:"isHof"`
+	Timestamp  string   `json:"timestamp"`
+	Type       string   `json:"type"`
+	PlayerName string   `json:"playerName"`
+	TeamName   string   `json:"teamName,omitempty"`
+	Target     string   `json:"target"`
+	Value      float64  `json:"value"`
+	Location   string   `json:"location,omitempty"`
+	IsHof      bool     `json:"isHof"`
+	Records    []string `json:"records,omitempty"` // Record scopes the global set, see RecordTracker
 }
@@ -1,9 +1,5 @@
 package model
 
-import (
-	"strings"
-)
-
 // GenerateStatsFromGlobals generates statistics based on a slice of GlobalEntry
 func GenerateStatsFromGlobals(globals []GlobalEntry) Stats {
 	stats := Stats{
@@ -30,13 +26,21 @@ func GenerateStatsFromGlobals(globals []GlobalEntry) Stats {
 
 		// Count by type
 		stats.ByType[entry.Type]++
+	}
 
-		// Count by location
-		if entry.Location != "" {
-			location := strings.TrimSuffix(entry.Location, "!")
-			stats.ByLocation[location]++
-		}
+	stats.ByTarget = GenerateTargetStats(globals)
+
+	// Count by location, with spelling variants grouped together
+	stats.Locations = GenerateLocationStats(globals)
+	for _, ls := range stats.Locations {
+		stats.ByLocation[ls.Location] = ls.Count
 	}
 
+	// The default edges and brackets are always valid
+	stats.Values, _ = GenerateValueDistribution(globals, nil, nil, "")
+
+	// Without a markup table everything is valued at TT
+	ApplyMarkups(&stats, nil)
+
 	return stats
 }
@@ -0,0 +1,45 @@
+package model
+
+// StatsJSON is Stats with the JSON field names of the versioned API
+type StatsJSON struct {
+	TotalGlobals     int               `json:"total_globals"`
+	TotalHofs        int               `json:"total_hofs"`
+	HighestValue     float64           `json:"highest_value"`
+	HighestValueItem string            `json:"highest_value_item"`
+	TotalValue       float64           `json:"total_value"`
+	TotalMarketValue float64           `json:"total_market_value"`
+	ByType           map[string]int    `json:"by_type"`
+	ByLocation       map[string]int    `json:"by_location"`
+	ByTarget         []TargetStats     `json:"by_target"`
+	Locations        []LocationStats   `json:"locations"`
+	Values           ValueDistribution `json:"values"`
+	MissingMarkups   []MissingMarkup   `json:"missing_markups"`
+}
+
+// ToStatsJSON converts stats for the versioned API, with empty lists instead of null
+func ToStatsJSON(s Stats) StatsJSON {
+	j := StatsJSON{
+		TotalGlobals:     s.TotalGlobals,
+		TotalHofs:        s.TotalHofs,
+		HighestValue:     s.HighestValue,
+		HighestValueItem: s.HighestValueItem,
+		TotalValue:       s.TotalValue,
+		TotalMarketValue: s.TotalMarketValue,
+		ByType:           s.ByType,
+		ByLocation:       s.ByLocation,
+		ByTarget:         s.ByTarget,
+		Locations:        s.Locations,
+		Values:           s.Values,
+		MissingMarkups:   s.MissingMarkups,
+	}
+	if j.ByTarget == nil {
+		j.ByTarget = []TargetStats{}
+	}
+	if j.Locations == nil {
+		j.Locations = []LocationStats{}
+	}
+	if j.MissingMarkups == nil {
+		j.MissingMarkups = []MissingMarkup{}
+	}
+	return j
+}
@@ -0,0 +1,141 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TargetStats holds statistics for a single creature, crafted item or deposit
+type TargetStats struct {
+	Target       string  `json:"target"`
+	Type         string  `json:"type"`
+	Count        int     `json:"count"`
+	TotalValue   float64 `json:"total_value"`
+	AverageValue float64 `json:"average_value"`
+	MedianValue  float64 `json:"median_value"`
+	MaxValue     float64 `json:"max_value"`
+	Hofs         int     `json:"hofs"`
+	HofRate      float64 `json:"hof_rate"`     // Share of globals that were HoFs, 0 to 1
+	MarketValue  float64 `json:"market_value"` // TotalValue with the markup applied
+	HasMarkup    bool    `json:"has_markup"`   // Whether a markup was found; without one MarketValue is TT
+}
+
+// TargetSortFields lists the fields target statistics can be sorted by
+var TargetSortFields = []string{"target", "type", "count", "total_value", "average_value", "median_value", "max_value", "hofs", "hof_rate", "market_value"}
+
+// GenerateTargetStats computes statistics per target, ordered by total value (highest first)
+func GenerateTargetStats(globals []GlobalEntry) []TargetStats {
+	type key struct{ typ, target string }
+	values := make(map[key][]float64)
+	byKey := make(map[key]*TargetStats)
+
+	for _, entry := range globals {
+		k := key{typ: entry.Type, target: entry.Target}
+		ts, ok := byKey[k]
+		if !ok {
+			ts = &TargetStats{Target: entry.Target, Type: entry.Type}
+			byKey[k] = ts
+		}
+		ts.Count++
+		ts.TotalValue += entry.Value
+		if entry.Value > ts.MaxValue {
+			ts.MaxValue = entry.Value
+		}
+		if entry.IsHof {
+			ts.Hofs++
+		}
+		values[k] = append(values[k], entry.Value)
+	}
+
+	result := make([]TargetStats, 0, len(byKey))
+	for k, ts := range byKey {
+		ts.AverageValue = ts.TotalValue / float64(ts.Count)
+		ts.MedianValue = median(values[k])
+		ts.HofRate = float64(ts.Hofs) / float64(ts.Count)
+		result = append(result, *ts)
+	}
+
+	SortTargetStats(result, "total_value", true)
+	return result
+}
+
+// median returns the median of values, which it sorts in place
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
+
+// SortTargetStats sorts target statistics in place by one of TargetSortFields.
+// Ties are broken by target name so the order is stable between calls.
+func SortTargetStats(stats []TargetStats, field string, descending bool) error {
+	var less func(a, b TargetStats) bool
+	switch field {
+	case "target":
+		less = func(a, b TargetStats) bool { return strings.ToLower(a.Target) < strings.ToLower(b.Target) }
+	case "type":
+		less = func(a, b TargetStats) bool { return a.Type < b.Type }
+	case "count":
+		less = func(a, b TargetStats) bool { return a.Count < b.Count }
+	case "", "total_value":
+		less = func(a, b TargetStats) bool { return a.TotalValue < b.TotalValue }
+	case "average_value":
+		less = func(a, b TargetStats) bool { return a.AverageValue < b.AverageValue }
+	case "median_value":
+		less = func(a, b TargetStats) bool { return a.MedianValue < b.MedianValue }
+	case "max_value":
+		less = func(a, b TargetStats) bool { return a.MaxValue < b.MaxValue }
+	case "hofs":
+		less = func(a, b TargetStats) bool { return a.Hofs < b.Hofs }
+	case "hof_rate":
+		less = func(a, b TargetStats) bool { return a.HofRate < b.HofRate }
+	case "market_value":
+		less = func(a, b TargetStats) bool { return a.MarketValue < b.MarketValue }
+	default:
+		return fmt.Errorf("invalid sort field %q: must be one of %s", field, strings.Join(TargetSortFields, ", "))
+	}
+
+	sort.SliceStable(stats, func(i, j int) bool {
+		if less(stats[i], stats[j]) {
+			return !descending
+		}
+		if less(stats[j], stats[i]) {
+			return descending
+		}
+		return strings.ToLower(stats[i].Target) < strings.ToLower(stats[j].Target)
+	})
+	return nil
+}
+
+// TargetFilter selects target statistics. Zero values match everything.
+type TargetFilter struct {
+	Type     string // Exact global type, e.g. "kill"
+	Search   string // Case-insensitive substring of the target name
+	MinCount int    // Minimum number of globals
+}
+
+// FilterTargetStats returns the target statistics matching filter
+func FilterTargetStats(stats []TargetStats, filter TargetFilter) []TargetStats {
+	search := strings.ToLower(filter.Search)
+	result := make([]TargetStats, 0, len(stats))
+	for _, ts := range stats {
+		if filter.Type != "" && ts.Type != filter.Type {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(ts.Target), search) {
+			continue
+		}
+		if ts.Count < filter.MinCount {
+			continue
+		}
+		result = append(result, ts)
+	}
+	return result
+}
@@ -0,0 +1,49 @@
+package model
+
+import "testing"
+
+func TestGenerateTargetStats(t *testing.T) {
+	t.Parallel()
+
+	globals := []GlobalEntry{
+		{Type: "kill", Target: "Atrox Young", Value: 50},
+		{Type: "kill", Target: "Atrox Young", Value: 70},
+		{Type: "kill", Target: "Atrox Young", Value: 300, IsHof: true},
+		{Type: "kill", Target: "Atrox Young", Value: 60},
+		{Type: "find", Target: "Lysterium Stone", Value: 500, IsHof: true},
+		{Type: "craft", Target: "Explosive Projectiles", Value: 80},
+	}
+
+	targets := GenerateTargetStats(globals)
+	if len(targets) != 3 {
+		t.Fatalf("GenerateTargetStats() returned %d targets, want 3", len(targets))
+	}
+	if targets[0].Target != "Lysterium Stone" {
+		t.Errorf("GenerateTargetStats() first target = %q, want highest total first", targets[0].Target)
+	}
+
+	atrox := targets[1]
+	if atrox.Target != "Atrox Young" || atrox.Count != 4 || atrox.TotalValue != 480 ||
+		atrox.AverageValue != 120 || atrox.MedianValue != 65 || atrox.MaxValue != 300 ||
+		atrox.Hofs != 1 || atrox.HofRate != 0.25 {
+		t.Errorf("Atrox Young stats = %+v", atrox)
+	}
+
+	if err := SortTargetStats(targets, "count", true); err != nil || targets[0].Target != "Atrox Young" {
+		t.Errorf("SortTargetStats(count) = %v, first %q", err, targets[0].Target)
+	}
+	if err := SortTargetStats(targets, "target", false); err != nil || targets[0].Target != "Atrox Young" || targets[2].Target != "Lysterium Stone" {
+		t.Errorf("SortTargetStats(target) = %v, order %q..%q", err, targets[0].Target, targets[2].Target)
+	}
+	if err := SortTargetStats(targets, "bogus", false); err == nil {
+		t.Errorf("SortTargetStats() with invalid field should fail")
+	}
+
+	filtered := FilterTargetStats(targets, TargetFilter{Search: "atrox", MinCount: 2})
+	if len(filtered) != 1 || filtered[0].Target != "Atrox Young" {
+		t.Errorf("FilterTargetStats() = %+v", filtered)
+	}
+	if filtered := FilterTargetStats(targets, TargetFilter{Type: "craft"}); len(filtered) != 1 {
+		t.Errorf("FilterTargetStats(type craft) returned %d targets, want 1", len(filtered))
+	}
+}
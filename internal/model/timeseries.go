@@ -0,0 +1,212 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// TimestampLayout is the layout of GlobalEntry.Timestamp
+const TimestampLayout = "2006-01-02 15:04:05"
+
+// Supported time series intervals
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// TimeSeriesBucket holds the aggregated globals of one period, optionally for a single type
+type TimeSeriesBucket struct {
+	Period     string  `json:"period"` // e.g. "2025-05-16", "2025-W20" or "2025-05"
+	Start      string  `json:"start"`  // First day of the period (2006-01-02)
+	Type       string  `json:"type,omitempty"`
+	Count      int     `json:"count"`
+	Hofs       int     `json:"hofs"`
+	TotalValue float64 `json:"total_value"`
+	MeanValue  float64 `json:"mean_value"`
+	MaxValue   float64 `json:"max_value"`
+}
+
+// TimeSeries holds bucketed aggregates for a time window
+type TimeSeries struct {
+	Interval string             `json:"interval"`
+	ByType   bool               `json:"by_type"`
+	Buckets  []TimeSeriesBucket `json:"buckets"`
+}
+
+// Time returns the parsed timestamp of the entry
+func (e GlobalEntry) Time() (time.Time, error) {
+	return time.Parse(TimestampLayout, e.Timestamp)
+}
+
+// periodStart returns the start of the period containing t
+func periodStart(t time.Time, interval string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch interval {
+	case IntervalWeek:
+		// ISO weeks start on Monday
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case IntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	default:
+		return day
+	}
+}
+
+// nextPeriod returns the start of the period following start
+func nextPeriod(start time.Time, interval string) time.Time {
+	switch interval {
+	case IntervalWeek:
+		return start.AddDate(0, 0, 7)
+	case IntervalMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
+
+// periodLabel returns the display label of the period starting at start
+func periodLabel(start time.Time, interval string) string {
+	switch interval {
+	case IntervalWeek:
+		year, week := start.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case IntervalMonth:
+		return start.Format("2006-01")
+	default:
+		return start.Format("2006-01-02")
+	}
+}
+
+// ValidInterval returns whether interval is a supported time series interval
+func ValidInterval(interval string) bool {
+	return interval == IntervalDay || interval == IntervalWeek || interval == IntervalMonth
+}
+
+// GenerateTimeSeries aggregates globals per day, ISO week or month. Only globals between
+// from and to (inclusive) are counted; zero times leave the window open. Periods without
+// globals between the first and last period are included with zero values so the series
+// can be charted directly. With byType, every period has one bucket per global type.
+func GenerateTimeSeries(globals []GlobalEntry, interval string, from, to time.Time, byType bool) (TimeSeries, error) {
+	if !ValidInterval(interval) {
+		return TimeSeries{}, fmt.Errorf("invalid interval %q: must be day, week or month", interval)
+	}
+
+	series := TimeSeries{Interval: interval, ByType: byType, Buckets: []TimeSeriesBucket{}}
+
+	type key struct {
+		start time.Time
+		typ   string
+	}
+	buckets := make(map[key]*TimeSeriesBucket)
+	types := make(map[string]bool)
+	var first, last time.Time
+
+	for _, entry := range globals {
+		t, err := entry.Time()
+		if err != nil {
+			continue
+		}
+		if (!from.IsZero() && t.Before(from)) || (!to.IsZero() && t.After(to)) {
+			continue
+		}
+
+		start := periodStart(t, interval)
+		if first.IsZero() || start.Before(first) {
+			first = start
+		}
+		if start.After(last) {
+			last = start
+		}
+
+		k := key{start: start}
+		if byType {
+			k.typ = entry.Type
+			types[entry.Type] = true
+		}
+		b, ok := buckets[k]
+		if !ok {
+			b = &TimeSeriesBucket{}
+			buckets[k] = b
+		}
+		b.Count++
+		b.TotalValue += entry.Value
+		if entry.Value > b.MaxValue {
+			b.MaxValue = entry.Value
+		}
+		if entry.IsHof {
+			b.Hofs++
+		}
+	}
+
+	if first.IsZero() {
+		return series, nil
+	}
+
+	typeList := []string{""}
+	if byType {
+		typeList = typeList[:0]
+		for typ := range types {
+			typeList = append(typeList, typ)
+		}
+		sort.Strings(typeList)
+	}
+
+	for start := first; !start.After(last); start = nextPeriod(start, interval) {
+		for _, typ := range typeList {
+			b := TimeSeriesBucket{}
+			if found, ok := buckets[key{start: start, typ: typ}]; ok {
+				b = *found
+			}
+			b.Period = periodLabel(start, interval)
+			b.Start = start.Format("2006-01-02")
+			b.Type = typ
+			if b.Count > 0 {
+				b.MeanValue = b.TotalValue / float64(b.Count)
+			}
+			series.Buckets = append(series.Buckets, b)
+		}
+	}
+
+	return series, nil
+}
+
+// ParseTimeRange parses the bounds of a time window given as "2006-01-02", "2006-01-02 15:04:05"
+// or RFC3339. Empty bounds are returned as zero times. A date-only upper bound covers the whole day.
+func ParseTimeRange(from, to string) (time.Time, time.Time, error) {
+	start, _, err := parseTimeBound(from)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %w", err)
+	}
+	end, dateOnly, err := parseTimeBound(to)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %w", err)
+	}
+	if dateOnly {
+		end = end.AddDate(0, 0, 1).Add(-time.Second)
+	}
+	if !start.IsZero() && !end.IsZero() && end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("to is before from")
+	}
+	return start, end, nil
+}
+
+// parseTimeBound parses a single time window bound and reports whether it was a plain date
+func parseTimeBound(value string) (time.Time, bool, error) {
+	if value == "" {
+		return time.Time{}, false, nil
+	}
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.Parse(TimestampLayout, value); err == nil {
+		return t, false, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		// Stored timestamps carry the chat log's wall clock without a zone
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), 0, time.UTC), false, nil
+	}
+	return time.Time{}, false, fmt.Errorf("%q is not a date or time", value)
+}
@@ -0,0 +1,89 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGenerateTimeSeries(t *testing.T) {
+	t.Parallel()
+
+	globals := []GlobalEntry{
+		{Timestamp: "2025-05-01 10:00:00", Type: "kill", Value: 50},
+		{Timestamp: "2025-05-01 12:00:00", Type: "craft", Value: 150, IsHof: true},
+		{Timestamp: "2025-05-03 09:00:00", Type: "kill", Value: 100},
+		{Timestamp: "2025-06-10 09:00:00", Type: "find", Value: 70},
+	}
+
+	tests := []struct {
+		name        string
+		interval    string
+		from, to    string
+		byType      bool
+		wantPeriods []string
+		wantCounts  []int
+	}{
+		{
+			name:        "Daily buckets fill gaps",
+			interval:    IntervalDay,
+			to:          "2025-05-03",
+			wantPeriods: []string{"2025-05-01", "2025-05-02", "2025-05-03"},
+			wantCounts:  []int{2, 0, 1},
+		},
+		{
+			name:        "ISO weeks start on Monday",
+			interval:    IntervalWeek,
+			to:          "2025-05-31",
+			wantPeriods: []string{"2025-W18"},
+			wantCounts:  []int{3},
+		},
+		{
+			name:        "Monthly buckets",
+			interval:    IntervalMonth,
+			wantPeriods: []string{"2025-05", "2025-06"},
+			wantCounts:  []int{3, 1},
+		},
+		{
+			name:        "Split by type",
+			interval:    IntervalMonth,
+			from:        "2025-05-01",
+			to:          "2025-05-31",
+			byType:      true,
+			wantPeriods: []string{"2025-05", "2025-05"},
+			wantCounts:  []int{1, 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			from, to, err := ParseTimeRange(tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("ParseTimeRange() error = %v", err)
+			}
+			series, err := GenerateTimeSeries(globals, tt.interval, from, to, tt.byType)
+			if err != nil {
+				t.Fatalf("GenerateTimeSeries() error = %v", err)
+			}
+			if len(series.Buckets) != len(tt.wantPeriods) {
+				t.Fatalf("GenerateTimeSeries() returned %d buckets, want %d: %+v", len(series.Buckets), len(tt.wantPeriods), series.Buckets)
+			}
+			for i, b := range series.Buckets {
+				if b.Period != tt.wantPeriods[i] || b.Count != tt.wantCounts[i] {
+					t.Errorf("bucket %d = %s/%d, want %s/%d", i, b.Period, b.Count, tt.wantPeriods[i], tt.wantCounts[i])
+				}
+			}
+		})
+	}
+
+	series, _ := GenerateTimeSeries(globals, IntervalDay, time.Time{}, time.Time{}, false)
+	first := series.Buckets[0]
+	if first.TotalValue != 200 || first.MeanValue != 100 || first.MaxValue != 150 || first.Hofs != 1 {
+		t.Errorf("first bucket = %+v, want total 200, mean 100, max 150, 1 HoF", first)
+	}
+
+	if _, err := GenerateTimeSeries(globals, "year", time.Time{}, time.Time{}, false); err == nil {
+		t.Errorf("GenerateTimeSeries() with invalid interval should fail")
+	}
+}
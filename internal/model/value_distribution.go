@@ -0,0 +1,232 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultHistogramEdges are the histogram bucket edges in PED used when none are configured
+var DefaultHistogramEdges = []float64{50, 100, 200, 500, 1000, 2000, 5000, 10000}
+
+// ValueBracket is a named value range starting at Min and ending at the next bracket's Min
+type ValueBracket struct {
+	Name string  `json:"name"`
+	Min  float64 `json:"min"`
+}
+
+// DefaultValueBrackets are the value brackets used when none are configured
+var DefaultValueBrackets = []ValueBracket{
+	{Name: "Small", Min: 0},
+	{Name: "Medium", Min: 50},
+	{Name: "Large", Min: 200},
+	{Name: "HoF territory", Min: 1000},
+}
+
+// ValueBin counts the globals whose value is at least Min and below Max
+type ValueBin struct {
+	Label      string  `json:"label"`
+	Min        float64 `json:"min"`
+	Max        float64 `json:"max,omitempty"` // 0 for the open-ended last bin
+	Count      int     `json:"count"`
+	Share      float64 `json:"share"` // Share of the group's globals, 0 to 1
+	TotalValue float64 `json:"total_value"`
+}
+
+// ValueHistogram holds the value histogram and brackets of one group of globals
+type ValueHistogram struct {
+	Type      string     `json:"type,omitempty"`
+	Period    string     `json:"period,omitempty"` // e.g. "2025-05-16", "2025-W20" or "2025-05"
+	Count     int        `json:"count"`
+	Histogram []ValueBin `json:"histogram"`
+	Brackets  []ValueBin `json:"brackets"`
+}
+
+// ValueDistribution holds value histograms overall, per type and optionally per period
+type ValueDistribution struct {
+	Edges    []float64        `json:"edges"`
+	Overall  ValueHistogram   `json:"overall"`
+	ByType   []ValueHistogram `json:"by_type"`
+	Interval string           `json:"interval,omitempty"`
+	ByPeriod []ValueHistogram `json:"by_period,omitempty"`
+}
+
+// ValidateHistogramEdges checks that edges are positive and strictly ascending
+func ValidateHistogramEdges(edges []float64) error {
+	if len(edges) == 0 {
+		return fmt.Errorf("at least one histogram edge is required")
+	}
+	for i, edge := range edges {
+		if edge <= 0 {
+			return fmt.Errorf("histogram edge %g must be positive", edge)
+		}
+		if i > 0 && edge <= edges[i-1] {
+			return fmt.Errorf("histogram edges must be ascending: %g follows %g", edge, edges[i-1])
+		}
+	}
+	return nil
+}
+
+// ValidateValueBrackets checks that brackets are named, start at 0 or more and are ascending
+func ValidateValueBrackets(brackets []ValueBracket) error {
+	if len(brackets) == 0 {
+		return fmt.Errorf("at least one value bracket is required")
+	}
+	for i, bracket := range brackets {
+		if strings.TrimSpace(bracket.Name) == "" {
+			return fmt.Errorf("value bracket %d has no name", i+1)
+		}
+		if bracket.Min < 0 {
+			return fmt.Errorf("value bracket %q cannot start below 0", bracket.Name)
+		}
+		if i > 0 && bracket.Min <= brackets[i-1].Min {
+			return fmt.Errorf("value brackets must be ascending: %q starts at or below %q", bracket.Name, brackets[i-1].Name)
+		}
+	}
+	return nil
+}
+
+// ParseHistogramEdges parses comma-separated histogram edges such as "50,100,500"
+func ParseHistogramEdges(value string) ([]float64, error) {
+	var edges []float64
+	for _, field := range strings.Split(value, ",") {
+		var edge float64
+		if _, err := fmt.Sscanf(strings.TrimSpace(field), "%g", &edge); err != nil {
+			return nil, fmt.Errorf("invalid histogram edge %q", field)
+		}
+		edges = append(edges, edge)
+	}
+	if err := ValidateHistogramEdges(edges); err != nil {
+		return nil, err
+	}
+	return edges, nil
+}
+
+// histogramBins returns the empty bins below, between and above the edges
+func histogramBins(edges []float64) []ValueBin {
+	bins := make([]ValueBin, 0, len(edges)+1)
+	bins = append(bins, ValueBin{Label: fmt.Sprintf("<%g", edges[0]), Max: edges[0]})
+	for i := 1; i < len(edges); i++ {
+		bins = append(bins, ValueBin{Label: fmt.Sprintf("%g-%g", edges[i-1], edges[i]), Min: edges[i-1], Max: edges[i]})
+	}
+	last := edges[len(edges)-1]
+	return append(bins, ValueBin{Label: fmt.Sprintf("%g+", last), Min: last})
+}
+
+// bracketBins returns the empty bins of the brackets
+func bracketBins(brackets []ValueBracket) []ValueBin {
+	bins := make([]ValueBin, len(brackets))
+	for i, bracket := range brackets {
+		bins[i] = ValueBin{Label: bracket.Name, Min: bracket.Min}
+		if i+1 < len(brackets) {
+			bins[i].Max = brackets[i+1].Min
+		}
+	}
+	return bins
+}
+
+// binIndex returns the bin value falls into. Values below the first bin count towards it.
+func binIndex(bins []ValueBin, value float64) int {
+	for i := len(bins) - 1; i > 0; i-- {
+		if value >= bins[i].Min {
+			return i
+		}
+	}
+	return 0
+}
+
+// newValueHistogram counts the values of globals into histogram and bracket bins
+func newValueHistogram(globals []GlobalEntry, edges []float64, brackets []ValueBracket) ValueHistogram {
+	h := ValueHistogram{
+		Count:     len(globals),
+		Histogram: histogramBins(edges),
+		Brackets:  bracketBins(brackets),
+	}
+	for _, entry := range globals {
+		for _, bins := range [][]ValueBin{h.Histogram, h.Brackets} {
+			bin := &bins[binIndex(bins, entry.Value)]
+			bin.Count++
+			bin.TotalValue += entry.Value
+		}
+	}
+	if h.Count > 0 {
+		for _, bins := range [][]ValueBin{h.Histogram, h.Brackets} {
+			for i := range bins {
+				bins[i].Share = float64(bins[i].Count) / float64(h.Count)
+			}
+		}
+	}
+	return h
+}
+
+// GenerateValueDistribution computes value histograms with the given bucket edges and brackets,
+// overall and per type. With an interval ("day", "week" or "month") it also computes one
+// histogram per period that has globals. Nil edges or brackets fall back to the defaults.
+func GenerateValueDistribution(globals []GlobalEntry, edges []float64, brackets []ValueBracket, interval string) (ValueDistribution, error) {
+	if len(edges) == 0 {
+		edges = DefaultHistogramEdges
+	}
+	if len(brackets) == 0 {
+		brackets = DefaultValueBrackets
+	}
+	if err := ValidateHistogramEdges(edges); err != nil {
+		return ValueDistribution{}, err
+	}
+	if err := ValidateValueBrackets(brackets); err != nil {
+		return ValueDistribution{}, err
+	}
+	if interval != "" && !ValidInterval(interval) {
+		return ValueDistribution{}, fmt.Errorf("invalid interval %q: must be day, week or month", interval)
+	}
+
+	dist := ValueDistribution{
+		Edges:    edges,
+		Overall:  newValueHistogram(globals, edges, brackets),
+		ByType:   []ValueHistogram{},
+		Interval: interval,
+	}
+
+	byType := make(map[string][]GlobalEntry)
+	for _, entry := range globals {
+		byType[entry.Type] = append(byType[entry.Type], entry)
+	}
+	types := make([]string, 0, len(byType))
+	for typ := range byType {
+		types = append(types, typ)
+	}
+	sort.Strings(types)
+	for _, typ := range types {
+		h := newValueHistogram(byType[typ], edges, brackets)
+		h.Type = typ
+		dist.ByType = append(dist.ByType, h)
+	}
+
+	if interval == "" {
+		return dist, nil
+	}
+
+	byPeriod := make(map[time.Time][]GlobalEntry)
+	for _, entry := range globals {
+		t, err := entry.Time()
+		if err != nil {
+			continue
+		}
+		start := periodStart(t, interval)
+		byPeriod[start] = append(byPeriod[start], entry)
+	}
+	starts := make([]time.Time, 0, len(byPeriod))
+	for start := range byPeriod {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	dist.ByPeriod = []ValueHistogram{}
+	for _, start := range starts {
+		h := newValueHistogram(byPeriod[start], edges, brackets)
+		h.Period = periodLabel(start, interval)
+		dist.ByPeriod = append(dist.ByPeriod, h)
+	}
+
+	return dist, nil
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestGenerateValueDistribution(t *testing.T) {
+	t.Parallel()
+
+	globals := []GlobalEntry{
+		{Timestamp: "2025-05-01 10:00:00", Type: "kill", Value: 40},
+		{Timestamp: "2025-05-01 12:00:00", Type: "kill", Value: 50},
+		{Timestamp: "2025-05-20 09:00:00", Type: "craft", Value: 150},
+		{Timestamp: "2025-06-10 09:00:00", Type: "find", Value: 2500},
+	}
+	brackets := []ValueBracket{{Name: "Small", Min: 0}, {Name: "Large", Min: 100}}
+
+	dist, err := GenerateValueDistribution(globals, []float64{50, 100}, brackets, IntervalMonth)
+	if err != nil {
+		t.Fatalf("GenerateValueDistribution() error = %v", err)
+	}
+
+	wantLabels := []string{"<50", "50-100", "100+"}
+	wantCounts := []int{1, 1, 2}
+	if len(dist.Overall.Histogram) != len(wantLabels) {
+		t.Fatalf("Histogram has %d bins, want %d", len(dist.Overall.Histogram), len(wantLabels))
+	}
+	for i, bin := range dist.Overall.Histogram {
+		if bin.Label != wantLabels[i] || bin.Count != wantCounts[i] {
+			t.Errorf("Histogram[%d] = %s/%d, want %s/%d", i, bin.Label, bin.Count, wantLabels[i], wantCounts[i])
+		}
+	}
+
+	large := dist.Overall.Brackets[1]
+	if large.Label != "Large" || large.Count != 2 || large.Share != 0.5 || large.TotalValue != 2650 || large.Max != 0 {
+		t.Errorf("Brackets[1] = %+v, want 2 open-ended Large globals worth 2650", large)
+	}
+
+	if len(dist.ByType) != 3 || dist.ByType[2].Type != "kill" || dist.ByType[2].Brackets[0].Share != 1 {
+		t.Errorf("ByType = %+v, want craft, find and kill with all kills Small", dist.ByType)
+	}
+	if len(dist.ByPeriod) != 2 || dist.ByPeriod[0].Period != "2025-05" || dist.ByPeriod[0].Count != 3 {
+		t.Errorf("ByPeriod = %+v, want 2025-05 with 3 globals and 2025-06", dist.ByPeriod)
+	}
+
+	if _, err := GenerateValueDistribution(globals, []float64{100, 50}, nil, ""); err == nil {
+		t.Errorf("GenerateValueDistribution() with descending edges should fail")
+	}
+	if _, err := GenerateValueDistribution(globals, nil, []ValueBracket{{Name: "", Min: 0}}, ""); err == nil {
+		t.Errorf("GenerateValueDistribution() with unnamed bracket should fail")
+	}
+	if edges, err := ParseHistogramEdges("50, 100,500"); err != nil || len(edges) != 3 || edges[2] != 500 {
+		t.Errorf("ParseHistogramEdges() = %v, %v", edges, err)
+	}
+}
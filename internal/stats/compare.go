@@ -0,0 +1,90 @@
+package stats
+
+import (
+	"eu-clams/internal/model"
+	"fmt"
+	"strings"
+)
+
+// maxComparisonRows is the number of targets and locations listed in a comparison report
+const maxComparisonRows = 20
+
+// FormatComparison formats two datasets side by side with the change of every metric
+// and the targets and locations that changed most
+func FormatComparison(c model.Comparison) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("Comparison\n  A: %s\n  B: %s\n\n", DatasetDescription(c.A), DatasetDescription(c.B)))
+
+	b.WriteString(fmt.Sprintf("  %-20s %12s %12s %12s %9s\n", "Metric", "A", "B", "Delta", "Change"))
+	for _, m := range c.Metrics {
+		b.WriteString(fmt.Sprintf("  %-20s %12.2f %12.2f %+12.2f %9s\n", m.Metric, m.A, m.B, m.Delta, FormatChange(m.Change)))
+	}
+
+	b.WriteString(formatBreakdownDeltas("Targets", c.Targets, true))
+	b.WriteString(formatBreakdownDeltas("Locations", c.Locations, false))
+
+	return b.String()
+}
+
+// formatBreakdownDeltas formats the targets or locations that changed most
+func formatBreakdownDeltas(title string, deltas []model.BreakdownDelta, withType bool) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("\n%s (largest change first):\n", title))
+	if len(deltas) == 0 {
+		b.WriteString("  None\n")
+		return b.String()
+	}
+	b.WriteString(fmt.Sprintf("  %-30s %-6s %7s %7s %7s %10s %10s %11s\n", "Name", "Type", "A", "B", "Delta", "A PED", "B PED", "Delta PED"))
+	for i, d := range deltas {
+		if i == maxComparisonRows {
+			b.WriteString(fmt.Sprintf("  ... and %d more\n", len(deltas)-maxComparisonRows))
+			break
+		}
+		typ := ""
+		if withType {
+			typ = d.Type
+		}
+		b.WriteString(fmt.Sprintf("  %-30s %-6s %7d %7d %+7d %10.2f %10.2f %+11.2f\n",
+			d.Name, typ, d.CountA, d.CountB, d.CountDelta, d.ValueA, d.ValueB, d.ValueDelta))
+	}
+
+	return b.String()
+}
+
+// DatasetDescription describes a comparison dataset by its label, identity and time range
+func DatasetDescription(f model.DatasetFilter) string {
+	var parts []string
+	if f.Label != "" {
+		parts = append(parts, f.Label)
+	}
+	if f.Player != "" {
+		parts = append(parts, "player "+f.Player)
+	}
+	if f.Team != "" {
+		parts = append(parts, "team "+f.Team)
+	}
+	if !f.From.IsZero() || !f.To.IsZero() {
+		from, to := "start", "now"
+		if !f.From.IsZero() {
+			from = f.From.Format(model.TimestampLayout)
+		}
+		if !f.To.IsZero() {
+			to = f.To.Format(model.TimestampLayout)
+		}
+		parts = append(parts, from+" to "+to)
+	}
+	if len(parts) == 0 {
+		return "all globals"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FormatChange formats a relative change as a signed percentage, or "n/a" if there is none
+func FormatChange(change *float64) string {
+	if change == nil {
+		return "n/a"
+	}
+	return fmt.Sprintf("%+.1f%%", *change*100)
+}
@@ -0,0 +1,93 @@
+package stats
+
+import (
+	"eu-clams/internal/analysis"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// FormatDroughtReport formats gap and streak statistics overall, per type and for the most
+// frequent targets
+func FormatDroughtReport(report analysis.DroughtReport) string {
+	var b strings.Builder
+
+	window := time.Duration(report.StreakWindowSeconds * float64(time.Second))
+	b.WriteString(fmt.Sprintf("Droughts and streaks (streak window %s):\n", FormatDuration(window.Seconds())))
+	if report.Overall.Globals == 0 {
+		b.WriteString("  No globals recorded\n")
+		return b.String()
+	}
+
+	o := report.Overall
+	b.WriteString(fmt.Sprintf("  Current drought: %s (last global %s)\n", FormatDuration(o.CurrentDroughtSeconds), o.LastGlobal))
+	if o.LongestDrought != nil {
+		b.WriteString(fmt.Sprintf("  Longest drought: %s (%s to %s)\n",
+			FormatDuration(o.LongestDrought.Seconds), o.LongestDrought.Start, o.LongestDrought.End))
+		b.WriteString(fmt.Sprintf("  Gap average: %s, median: %s, 90th percentile: %s\n",
+			FormatDuration(o.AverageGapSeconds), FormatDuration(o.MedianGapSeconds), FormatDuration(o.P90GapSeconds)))
+	}
+	b.WriteString(fmt.Sprintf("  Streaks: %d", o.Streaks))
+	if o.LongestStreak != nil {
+		b.WriteString(fmt.Sprintf(", longest %d globals worth %.2f PED (%s to %s)",
+			o.LongestStreak.Count, o.LongestStreak.TotalValue, o.LongestStreak.Start, o.LongestStreak.End))
+	}
+	b.WriteString("\n\n")
+
+	b.WriteString(formatGapTable("Type", report.ByType, 0))
+	b.WriteString("\n")
+	b.WriteString(formatGapTable("Target", report.ByTarget, maxReportTargets))
+
+	return b.String()
+}
+
+// formatGapTable formats gap statistics per group, most globals first, listing at most limit
+// groups (0 for all)
+func formatGapTable(label string, groups map[string]analysis.GapStats, limit int) string {
+	var b strings.Builder
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if groups[names[i]].Globals != groups[names[j]].Globals {
+			return groups[names[i]].Globals > groups[names[j]].Globals
+		}
+		return names[i] < names[j]
+	})
+	if limit > 0 && len(names) > limit {
+		names = names[:limit]
+	}
+
+	b.WriteString(fmt.Sprintf("  %-30s %6s %10s %10s %10s %10s %10s %7s\n",
+		label, "Count", "Current", "Longest", "Average", "Median", "P90", "Streaks"))
+	for _, name := range names {
+		g := groups[name]
+		longest := 0.0
+		if g.LongestDrought != nil {
+			longest = g.LongestDrought.Seconds
+		}
+		b.WriteString(fmt.Sprintf("  %-30s %6d %10s %10s %10s %10s %10s %7d\n",
+			name, g.Globals, FormatDuration(g.CurrentDroughtSeconds), FormatDuration(longest),
+			FormatDuration(g.AverageGapSeconds), FormatDuration(g.MedianGapSeconds), FormatDuration(g.P90GapSeconds), g.Streaks))
+	}
+
+	return b.String()
+}
+
+// FormatDuration formats a number of seconds compactly, e.g. "45s", "12m", "3h20m" or "2d4h"
+func FormatDuration(seconds float64) string {
+	d := time.Duration(seconds) * time.Second
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+}
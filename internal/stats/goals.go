@@ -0,0 +1,53 @@
+package stats
+
+import (
+	"eu-clams/internal/model"
+	"fmt"
+	"strings"
+)
+
+// progressBarWidth is the number of characters of a goal's progress bar
+const progressBarWidth = 20
+
+// FormatGoalProgress formats the progress towards every goal with a progress bar
+func FormatGoalProgress(progress []model.GoalProgress) string {
+	var b strings.Builder
+
+	b.WriteString("Goals:\n")
+	if len(progress) == 0 {
+		b.WriteString("  No goals set\n")
+		return b.String()
+	}
+
+	for _, p := range progress {
+		filled := int(p.Progress * progressBarWidth)
+		bar := strings.Repeat("#", filled) + strings.Repeat(".", progressBarWidth-filled)
+		status := ""
+		if p.Completed {
+			status = "  done"
+		}
+		period := ""
+		if p.Period != "" {
+			period = " [" + p.Period + "]"
+		}
+		b.WriteString(fmt.Sprintf("  %-4s %s%s\n       [%s] %.2f / %.2f (%.0f%%)%s\n",
+			p.Goal.ID, p.Goal.Name, period, bar, p.Current, p.Goal.Threshold, p.Progress*100, status))
+	}
+
+	return b.String()
+}
+
+// FormatAchievements formats the unlocked and the remaining built-in achievements
+func FormatAchievements(unlocked []model.AchievementUnlock, locked []model.Achievement) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("Achievements (%d unlocked):\n", len(unlocked)))
+	for _, a := range unlocked {
+		b.WriteString(fmt.Sprintf("  [x] %-30s %s  %s\n", a.Name, a.UnlockedAt, a.Description))
+	}
+	for _, a := range locked {
+		b.WriteString(fmt.Sprintf("  [ ] %-30s %-19s  %s\n", a.Name, "", a.Description))
+	}
+
+	return b.String()
+}
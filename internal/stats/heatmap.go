@@ -0,0 +1,80 @@
+package stats
+
+import (
+	"eu-clams/internal/model"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// Heatmap SVG layout in pixels
+const (
+	heatmapCell   = 28
+	heatmapLeft   = 90
+	heatmapTop    = 40
+	heatmapBottom = 30
+)
+
+// RenderHeatmapSVG renders a heatmap as an SVG image. metric is "count" for the number of
+// globals or "value" for PED.
+func RenderHeatmapSVG(h model.Heatmap, metric string) string {
+	var b strings.Builder
+
+	cell := func(day, hour int) float64 {
+		if metric == "value" {
+			return h.Values[day][hour]
+		}
+		return float64(h.Counts[day][hour])
+	}
+	var max float64
+	for day := range h.Counts {
+		for hour := range h.Counts[day] {
+			if v := cell(day, hour); v > max {
+				max = v
+			}
+		}
+	}
+
+	width := heatmapLeft + 24*heatmapCell + 10
+	height := heatmapTop + 7*heatmapCell + heatmapBottom
+	b.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`,
+		width, height, width, height))
+	b.WriteString("\n")
+
+	title := "Globals by weekday and hour"
+	if metric == "value" {
+		title = "PED by weekday and hour"
+	}
+	if h.Type != "" {
+		title += " - " + h.Type
+	}
+	if h.Target != "" {
+		title += " - " + h.Target
+	}
+	b.WriteString(fmt.Sprintf(`<text x="%d" y="20" font-size="14" font-weight="bold">%s</text>`, heatmapLeft, html.EscapeString(title)))
+	b.WriteString("\n")
+
+	for hour := 0; hour < 24; hour++ {
+		b.WriteString(fmt.Sprintf(`<text x="%d" y="%d" text-anchor="middle">%02d</text>`,
+			heatmapLeft+hour*heatmapCell+heatmapCell/2, heatmapTop+7*heatmapCell+15, hour))
+	}
+	b.WriteString("\n")
+
+	for day, name := range h.Weekdays {
+		y := heatmapTop + day*heatmapCell
+		b.WriteString(fmt.Sprintf(`<text x="%d" y="%d" text-anchor="end">%s</text>`, heatmapLeft-6, y+heatmapCell/2+4, name))
+		for hour := 0; hour < 24; hour++ {
+			v := cell(day, hour)
+			opacity := 0.0
+			if v > 0 {
+				opacity = 0.1 + 0.9*v/max
+			}
+			b.WriteString(fmt.Sprintf(`<rect x="%d" y="%d" width="%d" height="%d" fill="#4CAF50" fill-opacity="%.2f" stroke="#ddd"><title>%s %02d:00 - %d globals, %.2f PED</title></rect>`,
+				heatmapLeft+hour*heatmapCell, y, heatmapCell, heatmapCell, opacity, name, hour, h.Counts[day][hour], h.Values[day][hour]))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("</svg>\n")
+	return b.String()
+}
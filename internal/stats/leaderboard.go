@@ -0,0 +1,55 @@
+package stats
+
+import (
+	"eu-clams/internal/model"
+	"fmt"
+	"strings"
+)
+
+// FormatLeaderboard formats the top players, teams, creatures and HoFs of one period
+func FormatLeaderboard(board model.Leaderboard) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("Leaderboard %s: %d globals, %.2f PED\n", board.Period, board.Globals, board.TotalValue))
+	b.WriteString(formatLeaderboardEntries("Top players", board.Players))
+	b.WriteString(formatLeaderboardEntries("Top teams", board.Teams))
+	b.WriteString(formatLeaderboardEntries("Top creatures", board.Creatures))
+
+	b.WriteString("\nTop HoFs:\n")
+	if len(board.Hofs) == 0 {
+		b.WriteString("  None\n")
+	}
+	for i, hof := range board.Hofs {
+		b.WriteString(fmt.Sprintf("  %2d. %9.2f PED  %-25s %-30s %s\n",
+			i+1, hof.Value, valueOrDash(hof.PlayerName, hof.TeamName), hof.Target, hof.Timestamp))
+	}
+
+	return b.String()
+}
+
+// formatLeaderboardEntries formats one ranking as a table
+func formatLeaderboardEntries(title string, entries []model.LeaderboardEntry) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("\n%s:\n", title))
+	if len(entries) == 0 {
+		b.WriteString("  None\n")
+		return b.String()
+	}
+	b.WriteString(fmt.Sprintf("  %3s  %-30s %7s %10s %9s %5s\n", "#", "Name", "Globals", "Total", "Max", "HoFs"))
+	for i, e := range entries {
+		b.WriteString(fmt.Sprintf("  %3d  %-30s %7d %10.2f %9.2f %5d\n", i+1, e.Name, e.Count, e.TotalValue, e.MaxValue, e.Hofs))
+	}
+
+	return b.String()
+}
+
+// valueOrDash returns the first non-empty value, or "-"
+func valueOrDash(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return "-"
+}
@@ -0,0 +1,50 @@
+package stats
+
+import (
+	"eu-clams/internal/model"
+	"fmt"
+	"strings"
+)
+
+// FormatMarkupReport formats the markups in use and the targets without a markup, listing at
+// most limit missing targets (0 for all)
+func FormatMarkupReport(report model.MarkupReport, limit int) string {
+	var b strings.Builder
+
+	if len(report.Markups) == 0 {
+		b.WriteString("No markups configured, everything is valued at TT\n")
+	} else {
+		b.WriteString("Markups:\n")
+		for _, m := range report.Markups {
+			b.WriteString(fmt.Sprintf("  %-30s %10s\n", m.Item, m.String()))
+		}
+	}
+
+	if len(report.Missing) > 0 {
+		b.WriteString("\n")
+		b.WriteString(FormatMissingMarkups(report.Missing, limit))
+	}
+	return b.String()
+}
+
+// FormatMissingMarkups formats the targets without a markup, listing at most limit (0 for all)
+func FormatMissingMarkups(missing []model.MissingMarkup, limit int) string {
+	var b strings.Builder
+
+	if len(missing) == 0 {
+		return "Every target has a markup\n"
+	}
+	b.WriteString("Missing markups (valued at TT):\n")
+	b.WriteString(fmt.Sprintf("  %-30s %-6s %6s %10s\n", "Item", "Type", "Count", "TT value"))
+	shown := missing
+	if limit > 0 && len(shown) > limit {
+		shown = shown[:limit]
+	}
+	for _, m := range shown {
+		b.WriteString(fmt.Sprintf("  %-30s %-6s %6d %10.2f\n", m.Item, m.Type, m.Count, m.TotalValue))
+	}
+	if len(shown) < len(missing) {
+		b.WriteString(fmt.Sprintf("  ... and %d more\n", len(missing)-len(shown)))
+	}
+	return b.String()
+}
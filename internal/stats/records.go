@@ -0,0 +1,59 @@
+package stats
+
+import (
+	"eu-clams/internal/model"
+	"fmt"
+	"strings"
+)
+
+// maxRecordRows is the number of target records and record history entries listed
+const maxRecordRows = 15
+
+// FormatRecordBook formats the personal bests overall, per type and per target and the latest
+// globals that set a record
+func FormatRecordBook(book model.RecordBook) string {
+	var b strings.Builder
+
+	b.WriteString("Personal Records:\n")
+	if book.Overall == nil {
+		b.WriteString("  No globals recorded\n")
+		return b.String()
+	}
+	b.WriteString(fmt.Sprintf("  Overall: %s\n", formatRecordEntry(*book.Overall)))
+
+	b.WriteString("\nBest per type:\n")
+	for _, e := range book.ByType {
+		b.WriteString(fmt.Sprintf("  %-6s %s\n", e.Type, formatRecordEntry(e)))
+	}
+
+	b.WriteString("\nBest per target:\n")
+	for i, e := range book.ByTarget {
+		if i == maxRecordRows {
+			b.WriteString(fmt.Sprintf("  ... and %d more\n", len(book.ByTarget)-maxRecordRows))
+			break
+		}
+		b.WriteString(fmt.Sprintf("  %-6s %s\n", e.Type, formatRecordEntry(e)))
+	}
+
+	b.WriteString("\nLatest records set:\n")
+	if len(book.History) == 0 {
+		b.WriteString("  None\n")
+	}
+	for i, e := range book.History {
+		if i == maxRecordRows {
+			break
+		}
+		b.WriteString(fmt.Sprintf("  %s  [%s]\n", formatRecordEntry(e), strings.Join(e.Records, ", ")))
+	}
+
+	return b.String()
+}
+
+// formatRecordEntry formats a global holding or setting a record
+func formatRecordEntry(e model.GlobalEntry) string {
+	hof := ""
+	if e.IsHof {
+		hof = " (HoF)"
+	}
+	return fmt.Sprintf("%9.2f PED  %-30s %s%s", e.Value, e.Target, e.Timestamp, hof)
+}
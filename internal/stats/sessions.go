@@ -0,0 +1,68 @@
+package stats
+
+import (
+	"eu-clams/internal/model"
+	"fmt"
+	"strings"
+)
+
+// FormatSessionList formats session summaries as a table
+func FormatSessionList(sessions []model.SessionSummary) string {
+	var b strings.Builder
+
+	b.WriteString("Sessions:\n")
+	if len(sessions) == 0 {
+		b.WriteString("  No sessions recorded\n")
+		return b.String()
+	}
+
+	b.WriteString(fmt.Sprintf("  %-15s %-19s %9s %7s %5s %10s  %s\n",
+		"ID", "Start", "Duration", "Globals", "HoFs", "Total", "Locations"))
+	for _, s := range sessions {
+		duration := FormatDuration(s.DurationSeconds)
+		if s.Open {
+			duration += "*"
+		}
+		b.WriteString(fmt.Sprintf("  %-15s %-19s %9s %7d %5d %10.2f  %s\n",
+			s.ID, s.Start, duration, s.Globals, s.Hofs, s.TotalValue, strings.Join(s.Locations, ", ")))
+	}
+	b.WriteString("\n  * session still open\n")
+
+	return b.String()
+}
+
+// FormatSessionDetail formats a session with its globals and per-target statistics
+func FormatSessionDetail(detail model.SessionDetail) string {
+	var b strings.Builder
+
+	state := "ended"
+	if detail.Open {
+		state = "open"
+	}
+	b.WriteString(fmt.Sprintf("Session %s (%s)\n", detail.ID, state))
+	b.WriteString(fmt.Sprintf("Start: %s\n", detail.Start))
+	b.WriteString(fmt.Sprintf("End: %s\n", detail.End))
+	b.WriteString(fmt.Sprintf("Duration: %s\n", FormatDuration(detail.DurationSeconds)))
+	b.WriteString(fmt.Sprintf("Chat lines: %d\n", detail.Lines))
+	b.WriteString(fmt.Sprintf("Globals: %d\n", detail.Globals))
+	b.WriteString(fmt.Sprintf("HoFs: %d\n", detail.Hofs))
+	b.WriteString(fmt.Sprintf("Total PED value: %.2f\n", detail.TotalValue))
+	if len(detail.Locations) > 0 {
+		b.WriteString(fmt.Sprintf("Locations: %s\n", strings.Join(detail.Locations, ", ")))
+	}
+
+	if len(detail.Entries) > 0 {
+		b.WriteString("\nGlobals:\n")
+		for _, entry := range detail.Entries {
+			hof := ""
+			if entry.IsHof {
+				hof = " (HoF)"
+			}
+			b.WriteString(fmt.Sprintf("  %s  %-5s %-30s %9.2f PED%s\n", entry.Timestamp, entry.Type, entry.Target, entry.Value, hof))
+		}
+		b.WriteString("\n")
+		b.WriteString(FormatTargetStats(detail.Targets, 0))
+	}
+
+	return b.String()
+}
@@ -3,7 +3,6 @@ package stats
 import (
 	"eu-clams/internal/model"
 	"fmt"
-	"sort"
 	"strings"
 	"time"
 )
@@ -26,6 +25,9 @@ func GenerateStats(dbInterface interface{}) Stats {
 	}
 }
 
+// maxReportTargets is the number of targets listed in the statistics report
+const maxReportTargets = 20
+
 // FormatStatsReport formats statistics into a readable report
 func FormatStatsReport(stats Stats, playerName string, teamName string) string {
 	var b strings.Builder
@@ -39,6 +41,7 @@ func FormatStatsReport(stats Stats, playerName string, teamName string) string {
 	b.WriteString(fmt.Sprintf("Total globals: %d\n", stats.TotalGlobals))
 	b.WriteString(fmt.Sprintf("Total HoFs: %d\n", stats.TotalHofs))
 	b.WriteString(fmt.Sprintf("Total PED value: %.2f\n", stats.TotalValue))
+	b.WriteString(fmt.Sprintf("Total market value: %.2f\n", stats.TotalMarketValue))
 
 	if stats.HighestValue > 0 {
 		b.WriteString(fmt.Sprintf("Highest value: %.2f PED (%s)\n\n", stats.HighestValue, stats.HighestValueItem))
@@ -52,27 +55,71 @@ func FormatStatsReport(stats Stats, playerName string, teamName string) string {
 		b.WriteString("\n")
 	}
 
-	if len(stats.ByLocation) > 0 {
-		b.WriteString("Globals by location:\n")
+	if stats.TotalGlobals > 0 {
+		b.WriteString(FormatValueDistribution(stats.Values))
+		b.WriteString("\n")
+	}
 
-		// Sort locations by count
-		type locCount struct {
-			Location string
-			Count    int
-		}
+	if len(stats.ByTarget) > 0 {
+		b.WriteString(FormatTargetStats(stats.ByTarget, maxReportTargets))
+		b.WriteString("\n")
+	}
+
+	if len(stats.Locations) > 0 {
+		b.WriteString(FormatLocationStats(stats.Locations))
+	}
+
+	return b.String()
+}
+
+// FormatTargetStats formats per-target statistics as a table, listing at most limit targets (0 for all)
+func FormatTargetStats(targets []model.TargetStats, limit int) string {
+	var b strings.Builder
+
+	b.WriteString("Globals by target:\n")
+	b.WriteString(fmt.Sprintf("  %-30s %-6s %6s %10s %10s %9s %9s %9s %5s %7s\n",
+		"Target", "Type", "Count", "Total", "Market", "Average", "Median", "Max", "HoFs", "HoF %"))
 
-		var locations []locCount
-		for loc, count := range stats.ByLocation {
-			locations = append(locations, locCount{loc, count})
+	shown := targets
+	if limit > 0 && len(shown) > limit {
+		shown = shown[:limit]
+	}
+	for _, ts := range shown {
+		market := fmt.Sprintf("%.2f", ts.MarketValue)
+		if !ts.HasMarkup {
+			market += "*" // Valued at TT
+		}
+		b.WriteString(fmt.Sprintf("  %-30s %-6s %6d %10.2f %10s %9.2f %9.2f %9.2f %5d %6.1f%%\n",
+			ts.Target, ts.Type, ts.Count, ts.TotalValue, market, ts.AverageValue, ts.MedianValue, ts.MaxValue, ts.Hofs, ts.HofRate*100))
+	}
+	if len(shown) < len(targets) {
+		b.WriteString(fmt.Sprintf("  ... and %d more\n", len(targets)-len(shown)))
+	}
+	for _, ts := range shown {
+		if !ts.HasMarkup {
+			b.WriteString("  * no markup, valued at TT\n")
+			break
 		}
+	}
+
+	return b.String()
+}
+
+// FormatLocationStats formats per-location statistics as a table
+func FormatLocationStats(locations []model.LocationStats) string {
+	var b strings.Builder
 
-		sort.Slice(locations, func(i, j int) bool {
-			return locations[i].Count > locations[j].Count
-		})
+	b.WriteString("Globals by location:\n")
+	b.WriteString(fmt.Sprintf("  %-25s %6s %10s %9s %5s  %-19s  %-19s  %s\n",
+		"Location", "Count", "Total", "Average", "HoFs", "First seen", "Last seen", "Top targets"))
 
-		for _, lc := range locations {
-			b.WriteString(fmt.Sprintf("  %s: %d\n", lc.Location, lc.Count))
+	for _, ls := range locations {
+		top := make([]string, len(ls.TopTargets))
+		for i, lt := range ls.TopTargets {
+			top[i] = fmt.Sprintf("%s (%.0f)", lt.Target, lt.TotalValue)
 		}
+		b.WriteString(fmt.Sprintf("  %-25s %6d %10.2f %9.2f %5d  %-19s  %-19s  %s\n",
+			ls.Location, ls.Count, ls.TotalValue, ls.AverageValue, ls.Hofs, ls.FirstSeen, ls.LastSeen, strings.Join(top, ", ")))
 	}
 
 	return b.String()
@@ -0,0 +1,43 @@
+package stats
+
+import (
+	"eu-clams/internal/model"
+	"fmt"
+	"strings"
+)
+
+// FormatTimeSeriesTable formats a time series as a text table
+func FormatTimeSeriesTable(series model.TimeSeries) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("Globals per %s\n\n", series.Interval))
+	if len(series.Buckets) == 0 {
+		b.WriteString("No globals in this time range\n")
+		return b.String()
+	}
+
+	if series.ByType {
+		b.WriteString(fmt.Sprintf("%-12s %-6s %7s %5s %12s %10s %10s\n", "Period", "Type", "Globals", "HoFs", "Total PED", "Mean PED", "Max PED"))
+	} else {
+		b.WriteString(fmt.Sprintf("%-12s %7s %5s %12s %10s %10s\n", "Period", "Globals", "HoFs", "Total PED", "Mean PED", "Max PED"))
+	}
+
+	var count, hofs int
+	var total float64
+	for _, bucket := range series.Buckets {
+		if series.ByType {
+			b.WriteString(fmt.Sprintf("%-12s %-6s ", bucket.Period, bucket.Type))
+		} else {
+			b.WriteString(fmt.Sprintf("%-12s ", bucket.Period))
+		}
+		b.WriteString(fmt.Sprintf("%7d %5d %12.2f %10.2f %10.2f\n",
+			bucket.Count, bucket.Hofs, bucket.TotalValue, bucket.MeanValue, bucket.MaxValue))
+
+		count += bucket.Count
+		hofs += bucket.Hofs
+		total += bucket.TotalValue
+	}
+
+	b.WriteString(fmt.Sprintf("\nTotal: %d globals, %d HoFs, %.2f PED\n", count, hofs, total))
+	return b.String()
+}
@@ -0,0 +1,49 @@
+package stats
+
+import (
+	"eu-clams/internal/model"
+	"fmt"
+	"strings"
+)
+
+// FormatValueDistribution formats the value histogram and brackets overall and per type
+func FormatValueDistribution(dist model.ValueDistribution) string {
+	var b strings.Builder
+
+	b.WriteString("Value distribution:\n")
+	b.WriteString(formatValueBins("Value (PED)", dist.Overall.Histogram))
+	b.WriteString("\n")
+
+	b.WriteString("Value brackets:\n")
+	b.WriteString(formatValueBins("Bracket", dist.Overall.Brackets))
+	for _, h := range dist.ByType {
+		b.WriteString(fmt.Sprintf("  %s:", strings.Title(h.Type)))
+		for _, bin := range h.Brackets {
+			b.WriteString(fmt.Sprintf("  %s %d (%.1f%%)", bin.Label, bin.Count, bin.Share*100))
+		}
+		b.WriteString("\n")
+	}
+
+	for _, h := range dist.ByPeriod {
+		b.WriteString(fmt.Sprintf("  %-12s", h.Period))
+		for _, bin := range h.Brackets {
+			b.WriteString(fmt.Sprintf("  %s %d (%.1f%%)", bin.Label, bin.Count, bin.Share*100))
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// formatValueBins formats value bins as a table with a proportional bar per bin
+func formatValueBins(label string, bins []model.ValueBin) string {
+	var b strings.Builder
+
+	b.WriteString(fmt.Sprintf("  %-20s %6s %7s %10s\n", label, "Count", "Share", "Total"))
+	for _, bin := range bins {
+		b.WriteString(fmt.Sprintf("  %-20s %6d %6.1f%% %10.2f  %s\n",
+			bin.Label, bin.Count, bin.Share*100, bin.TotalValue, strings.Repeat("#", int(bin.Share*40+0.5))))
+	}
+
+	return b.String()
+}
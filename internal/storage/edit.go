@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"errors"
+	"eu-clams/internal/model"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrGlobalNotFound is returned when a mutation refers to a global that is not stored
+var ErrGlobalNotFound = errors.New("global not found")
+
+// GlobalEdit holds the fields to change on a stored global. Nil fields are left untouched.
+type GlobalEdit struct {
+	Type       *string  `json:"type,omitempty"`
+	PlayerName *string  `json:"player,omitempty"`
+	TeamName   *string  `json:"team,omitempty"`
+	Target     *string  `json:"target,omitempty"`
+	Value      *float64 `json:"value,omitempty"`
+	Location   *string  `json:"location,omitempty"`
+	IsHof      *bool    `json:"is_hof,omitempty"`
+}
+
+// IsEmpty returns whether the edit changes nothing
+func (e GlobalEdit) IsEmpty() bool {
+	return e.Type == nil && e.PlayerName == nil && e.TeamName == nil && e.Target == nil &&
+		e.Value == nil && e.Location == nil && e.IsHof == nil
+}
+
+// ChangeRecord records who edited or deleted a stored global and what it looked like before
+type ChangeRecord struct {
+	Time   time.Time    `yaml:"time" json:"time"`
+	Action string       `yaml:"action" json:"action"` // "edit" or "delete"
+	Author string       `yaml:"author" json:"author"` // e.g. "gui", "cli", "web laptop" (the token name)
+	Before GlobalEntry  `yaml:"before" json:"before"`
+	After  *GlobalEntry `yaml:"after,omitempty" json:"after,omitempty"`
+}
+
+// validGlobalTypes lists the types ParseChatLine can produce
+var validGlobalTypes = map[string]bool{"kill": true, "craft": true, "find": true}
+
+// EditGlobal applies edit to the stored global with the given ID and records the change
+func (db *EntropyDB) EditGlobal(id string, edit GlobalEdit, author string) (*GlobalEntry, error) {
+	if db == nil {
+		return nil, fmt.Errorf("database is nil")
+	}
+
+	i, ok := db.FindGlobal(id)
+	if !ok {
+		return nil, ErrGlobalNotFound
+	}
+	if edit.IsEmpty() {
+		return nil, fmt.Errorf("no changes given")
+	}
+
+	before := db.Globals[i]
+	after := before
+	if edit.Type != nil {
+		typ := strings.ToLower(strings.TrimSpace(*edit.Type))
+		if !validGlobalTypes[typ] {
+			return nil, fmt.Errorf("invalid type %q: must be kill, craft or find", *edit.Type)
+		}
+		after.Type = typ
+	}
+	if edit.PlayerName != nil {
+		after.PlayerName = strings.TrimSpace(*edit.PlayerName)
+	}
+	if edit.TeamName != nil {
+		after.TeamName = normalizeTeamName(*edit.TeamName)
+	}
+	if edit.Target != nil {
+		after.Target = strings.TrimSpace(*edit.Target)
+		if after.Target == "" {
+			return nil, fmt.Errorf("target cannot be empty")
+		}
+	}
+	if edit.Value != nil {
+		if *edit.Value < 0 {
+			return nil, fmt.Errorf("value cannot be negative")
+		}
+		after.Value = *edit.Value
+	}
+	if edit.Location != nil {
+		after.Location = model.NormalizeLocation(*edit.Location)
+	}
+	if edit.IsHof != nil {
+		after.IsHof = *edit.IsHof
+	}
+	if after.PlayerName == "" && after.TeamName == "" {
+		return nil, fmt.Errorf("a global needs a player or a team")
+	}
+
+	db.Globals[i] = after
+	db.dirty = true
+
+	db.recordChange(ChangeRecord{
+		Time:   time.Now(),
+		Action: "edit",
+		Author: author,
+		Before: before,
+		After:  &after,
+	})
+
+	// Return the global as stored, with the records computed again for the edit
+	stored := db.Globals[i]
+	return &stored, nil
+}
+
+// DeleteGlobal removes the stored global with the given ID and records the change
+func (db *EntropyDB) DeleteGlobal(id string, author string) error {
+	if db == nil {
+		return fmt.Errorf("database is nil")
+	}
+
+	i, ok := db.FindGlobal(id)
+	if !ok {
+		return ErrGlobalNotFound
+	}
+
+	before := db.Globals[i]
+	db.Globals = append(db.Globals[:i], db.Globals[i+1:]...)
+	if db.ids != nil {
+		delete(db.ids, id)
+	}
+	db.dirty = true
+
+	db.recordChange(ChangeRecord{
+		Time:   time.Now(),
+		Action: "delete",
+		Author: author,
+		Before: before,
+	})
+
+	return nil
+}
+
+// recordChange appends a change to the history. Personal records depend on every earlier
+// global, so they are computed again first and an edited global is logged with its new ones.
+func (db *EntropyDB) recordChange(change ChangeRecord) {
+	db.ComputeRecords()
+	if change.After != nil {
+		if i, ok := db.FindGlobal(change.After.ID); ok {
+			after := db.Globals[i]
+			change.After = &after
+		}
+	}
+	db.Changes = append(db.Changes, change)
+}
@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestEditGlobal(t *testing.T) {
+	t.Parallel()
+
+	entry, err := ParseChatLine("2025-05-16 10:01:00 [Globals] [] Test Player Name Long killed a creature (Atrox Young) with a value of 50 PED at Zone A!")
+	if err != nil || entry == nil {
+		t.Fatalf("failed to parse test line: %v", err)
+	}
+
+	db := NewEntropyDB("", "")
+	db.addGlobal(*entry)
+
+	player := "Test Player"
+	value := 55.0
+	updated, err := db.EditGlobal(entry.ID, GlobalEdit{PlayerName: &player, Value: &value}, "test")
+	if err != nil {
+		t.Fatalf("EditGlobal() error = %v", err)
+	}
+	if updated.PlayerName != "Test Player" || updated.Value != 55 {
+		t.Errorf("EditGlobal() = %+v, want player %q and value 55", updated, player)
+	}
+	if db.Globals[0].Target != "Atrox Young" {
+		t.Errorf("EditGlobal() changed untouched field Target to %q", db.Globals[0].Target)
+	}
+	if len(db.Changes) != 1 || db.Changes[0].Author != "test" || db.Changes[0].Before.PlayerName != "Test Player Name Long" {
+		t.Errorf("EditGlobal() recorded changes %+v", db.Changes)
+	}
+
+	badType := "loot"
+	if _, err := db.EditGlobal(entry.ID, GlobalEdit{Type: &badType}, "test"); err == nil {
+		t.Errorf("EditGlobal() with invalid type should fail")
+	}
+	if _, err := db.EditGlobal(entry.ID, GlobalEdit{}, "test"); err == nil {
+		t.Errorf("EditGlobal() without changes should fail")
+	}
+
+	if _, err := db.EditGlobal("unknown", GlobalEdit{Value: &value}, "test"); !errors.Is(err, ErrGlobalNotFound) {
+		t.Errorf("EditGlobal() on unknown global error = %v, want ErrGlobalNotFound", err)
+	}
+
+	if err := db.DeleteGlobal(entry.ID, "test"); err != nil {
+		t.Fatalf("DeleteGlobal() error = %v", err)
+	}
+	if len(db.Globals) != 0 {
+		t.Errorf("DeleteGlobal() left %d globals", len(db.Globals))
+	}
+	if last := db.Changes[len(db.Changes)-1]; last.Action != "delete" || last.After != nil {
+		t.Errorf("DeleteGlobal() recorded %+v", last)
+	}
+
+	// The change history is persisted with the database
+	path := filepath.Join(t.TempDir(), "db.yaml")
+	if err := db.SaveDatabase(path, nil); err != nil {
+		t.Fatalf("SaveDatabase() error = %v", err)
+	}
+	loaded, err := LoadDatabase(path, nil)
+	if err != nil {
+		t.Fatalf("LoadDatabase() error = %v", err)
+	}
+	if len(loaded.Changes) != 2 || loaded.Path() != path {
+		t.Errorf("LoadDatabase() changes = %d, path = %q", len(loaded.Changes), loaded.Path())
+	}
+}
+
+func TestEditGlobalRecords(t *testing.T) {
+	t.Parallel()
+
+	db := NewEntropyDB("Test Player", "")
+	for _, line := range []string{
+		"2025-05-16 10:00:00 [Globals] [] Test Player killed a creature (Atrox) with a value of 100 PED",
+		"2025-05-16 10:05:00 [Globals] [] Test Player killed a creature (Atrox) with a value of 50 PED",
+	} {
+		entry, err := ParseChatLine(line)
+		if err != nil || entry == nil {
+			t.Fatalf("failed to parse test line: %v", err)
+		}
+		db.addGlobal(*entry)
+	}
+	db.ComputeRecords()
+	if len(db.Globals[1].Records) != 0 {
+		t.Fatalf("second global records = %v, want none before the edit", db.Globals[1].Records)
+	}
+
+	// Raising the second global above the first makes it a record, which the result and the
+	// change history show
+	value := 150.0
+	updated, err := db.EditGlobal(db.Globals[1].ID, GlobalEdit{Value: &value}, "test")
+	if err != nil {
+		t.Fatalf("EditGlobal() error = %v", err)
+	}
+	if len(updated.Records) == 0 || !equalScopes(updated.Records, db.Globals[1].Records) {
+		t.Errorf("EditGlobal() records = %v, stored %v", updated.Records, db.Globals[1].Records)
+	}
+	if after := db.Changes[0].After; after == nil || !equalScopes(after.Records, db.Globals[1].Records) {
+		t.Errorf("EditGlobal() recorded %+v, want the stored records %v", after, db.Globals[1].Records)
+	}
+}
+
+func TestGlobalIDs(t *testing.T) {
+	t.Parallel()
+
+	line := "2025-05-16 10:00:00 [Globals] [] Test Player killed a creature (Test Beast) with a value of 100 PED"
+	first, _ := ParseChatLine(line)
+	second, _ := ParseChatLine(line)
+	if first.ID == "" || first.ID != second.ID {
+		t.Fatalf("ParseChatLine() IDs = %q and %q, want the same non-empty ID", first.ID, second.ID)
+	}
+
+	// The same chat line stored twice gets distinct IDs
+	db := NewEntropyDB("", "")
+	db.addGlobal(*first)
+	db.addGlobal(*second)
+	if db.Globals[0].ID == db.Globals[1].ID {
+		t.Errorf("addGlobal() gave duplicate ID %q", db.Globals[0].ID)
+	}
+
+	// Databases without IDs are backfilled with the same deterministic IDs
+	legacy := NewEntropyDB("", "")
+	legacy.Globals = []GlobalEntry{*first, *second}
+	legacy.Globals[0].ID = ""
+	legacy.Globals[1].ID = ""
+	if assigned := legacy.EnsureIDs(); assigned != 2 {
+		t.Errorf("EnsureIDs() assigned %d IDs, want 2", assigned)
+	}
+	if legacy.Globals[0].ID != db.Globals[0].ID || legacy.Globals[1].ID != db.Globals[1].ID {
+		t.Errorf("EnsureIDs() = %q, %q, want %q, %q",
+			legacy.Globals[0].ID, legacy.Globals[1].ID, db.Globals[0].ID, db.Globals[1].ID)
+	}
+
+	if entry, ok := legacy.GetGlobal(first.ID); !ok || entry.Target != "Test Beast" {
+		t.Errorf("GetGlobal(%q) = %+v, %v", first.ID, entry, ok)
+	}
+}
@@ -1,22 +1,41 @@
 package storage
 
 import (
+	"bytes"
 	"eu-clams/internal/logger"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 
 	"gopkg.in/yaml.v3"
 )
 
-// SaveDatabase saves the database to a YAML file
+// SaveDatabase saves the database to a YAML file, and everyone's captured globals to the
+// universe file next to it. Files are replaced atomically, so a crash never leaves half a file.
 func (db *EntropyDB) SaveDatabase(path string, logger *logger.Logger) error {
 	if db == nil {
 		return fmt.Errorf("database is nil")
 	}
+	return db.save(path)
+}
+
+// SaveChanges saves the database like SaveDatabase, but only if something changed since it
+// was loaded or last saved to path
+func (db *EntropyDB) SaveChanges(path string, logger *logger.Logger) error {
+	if db == nil {
+		return fmt.Errorf("database is nil")
+	}
+	if !db.dirty && !db.universeDirty && path == db.path {
+		return nil
+	}
+	return db.save(path)
+}
 
+// save writes the database files
+func (db *EntropyDB) save(path string) error {
 	// Ensure the directory exists
 	dir := filepath.Dir(path)
 	if err := os.MkdirAll(dir, 0755); err != nil {
@@ -28,20 +47,70 @@ func (db *EntropyDB) SaveDatabase(path string, logger *logger.Logger) error {
 	if err != nil {
 		return fmt.Errorf("failed to marshal data: %w", err)
 	}
-
-	// Write the file
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if err := writeFileAtomic(path, data); err != nil {
 		return fmt.Errorf("failed to write file %s: %w", path, err)
 	}
 
-	// Verify the file was written
-	if _, err := os.Stat(path); err != nil {
-		return fmt.Errorf("failed to verify file was written: %w", err)
+	// The universe only grows with capture on, so it is rewritten only when it changed
+	if db.universeDirty || (path != db.path && len(db.Universe) > 0) {
+		data, err := yaml.Marshal(universeFile{Universe: db.Universe})
+		if err != nil {
+			return fmt.Errorf("failed to marshal universe: %w", err)
+		}
+		if err := writeFileAtomic(UniversePath(path), data); err != nil {
+			return fmt.Errorf("failed to write file %s: %w", UniversePath(path), err)
+		}
 	}
 
+	db.path = path
+	db.dirty = false
+	db.universeDirty = false
 	return nil
 }
 
+// MarkChanged records that exported fields were set directly, so the next SaveChanges
+// writes them
+func (db *EntropyDB) MarkChanged() {
+	db.dirty = true
+}
+
+// universeFile is the content of the universe file
+type universeFile struct {
+	Universe []GlobalEntry `yaml:"universe"`
+}
+
+// UniversePath returns the file keeping everyone's captured globals for a database file,
+// e.g. data/db.universe.yaml for data/db.yaml
+func UniversePath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".universe.yaml"
+}
+
+// writeFileAtomic writes data to a temporary file in the same directory and renames it over
+// path
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Path returns the file the database was loaded from or last saved to
+func (db *EntropyDB) Path() string {
+	return db.path
+}
+
 // LoadDatabase loads the database from a YAML file
 func LoadDatabase(path string, logger *logger.Logger) (*EntropyDB, error) {
 	// Check if the file exists
@@ -50,7 +119,9 @@ func LoadDatabase(path string, logger *logger.Logger) (*EntropyDB, error) {
 		if logger != nil {
 			logger.Info("Database file does not exist at: %s. Creating new database.", path)
 		}
-		return NewEntropyDB("", ""), nil
+		db := NewEntropyDB("", "")
+		db.path = path
+		return db, nil
 	}
 
 	// Read the file
@@ -65,10 +136,37 @@ func LoadDatabase(path string, logger *logger.Logger) (*EntropyDB, error) {
 		return nil, fmt.Errorf("failed to unmarshal data: %w", err)
 	}
 
+	db.path = path
+	db.mu = &sync.RWMutex{}
+
+	// Databases from before the universe file kept everyone's globals inline; they move
+	// to the universe file on the next save
+	if bytes.Contains(data, []byte("\nuniverse:")) {
+		var legacy universeFile
+		if err := yaml.Unmarshal(data, &legacy); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal data: %w", err)
+		}
+		db.Universe = legacy.Universe
+		db.dirty, db.universeDirty = true, true
+	} else if data, err := os.ReadFile(UniversePath(path)); err == nil {
+		var universe universeFile
+		if err := yaml.Unmarshal(data, &universe); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal universe: %w", err)
+		}
+		db.Universe = universe.Universe
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read universe: %w", err)
+	}
+
 	if logger != nil {
 		logger.Info("Loaded database from: %s (%d globals)", path, len(db.Globals))
 	}
 
+	// Backfill IDs for databases written before globals had them
+	if assigned := db.EnsureIDs(); assigned > 0 && logger != nil {
+		logger.Info("Assigned IDs to %d stored globals", assigned)
+	}
+
 	return &db, nil
 }
 
@@ -88,7 +186,7 @@ func (db *EntropyDB) MergeDatabase(other *EntropyDB) int {
 	// Add new entries
 	for _, entry := range other.Globals {
 		if !existing[entry.RawMessage] {
-			db.Globals = append(db.Globals, entry)
+			db.addGlobal(entry)
 			existing[entry.RawMessage] = true
 			added++
 		}
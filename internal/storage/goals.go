@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"eu-clams/internal/model"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AddGoal validates a goal, gives it an ID and stores it
+func (db *EntropyDB) AddGoal(goal model.Goal, now time.Time) (model.Goal, error) {
+	goal.Name = strings.TrimSpace(goal.Name)
+	goal.Type = strings.TrimSpace(goal.Type)
+	goal.Target = strings.TrimSpace(goal.Target)
+	goal.Location = strings.TrimSpace(goal.Location)
+	if err := model.ValidateGoal(goal); err != nil {
+		return model.Goal{}, err
+	}
+	if goal.Name == "" {
+		goal.Name = model.DescribeGoal(goal)
+	}
+
+	// IDs are "g1", "g2", ... and never reused within a database. Databases from before the
+	// counter was stored continue after their highest ID.
+	next := max(db.NextGoalID, 1)
+	for _, g := range db.Goals {
+		if n, err := strconv.Atoi(strings.TrimPrefix(g.ID, "g")); err == nil && n >= next {
+			next = n + 1
+		}
+	}
+	goal.ID = fmt.Sprintf("g%d", next)
+	db.NextGoalID = next + 1
+	goal.CreatedAt = now.Format(model.TimestampLayout)
+	goal.CompletedAt = ""
+	goal.CompletedPeriod = ""
+
+	db.Goals = append(db.Goals, goal)
+	db.dirty = true
+	return goal, nil
+}
+
+// RemoveGoal deletes the goal with the given ID and reports whether it existed
+func (db *EntropyDB) RemoveGoal(id string) bool {
+	for i, g := range db.Goals {
+		if g.ID == id {
+			db.Goals = append(db.Goals[:i], db.Goals[i+1:]...)
+			db.dirty = true
+			return true
+		}
+	}
+	return false
+}
+
+// GetGoalProgress computes the progress towards every goal from the player's globals
+func (db *EntropyDB) GetGoalProgress(now time.Time) []model.GoalProgress {
+	globals := db.modelEntries()
+	progress := make([]model.GoalProgress, len(db.Goals))
+	for i, goal := range db.Goals {
+		progress[i] = model.EvaluateGoal(goal, globals, now)
+	}
+	return progress
+}
+
+// GetAchievements returns the unlocked achievements, oldest first, and the built-in
+// achievements that are still locked
+func (db *EntropyDB) GetAchievements() ([]model.AchievementUnlock, []model.Achievement) {
+	unlocked := make(map[string]bool, len(db.Achievements))
+	for _, a := range db.Achievements {
+		unlocked[a.ID] = true
+	}
+	locked := []model.Achievement{}
+	for _, a := range model.Achievements() {
+		if !unlocked[a.ID] {
+			locked = append(locked, a)
+		}
+	}
+	return append([]model.AchievementUnlock{}, db.Achievements...), locked
+}
+
+// UpdateProgress stores the achievements the player's globals have earned and marks goals
+// that are completed. It returns only what was unlocked or completed since the last update,
+// so every unlock is reported once; a goal with a period can be completed again in every period.
+func (db *EntropyDB) UpdateProgress(now time.Time) ([]model.AchievementUnlock, []model.GoalProgress) {
+	globals := db.modelEntries()
+
+	stored := make(map[string]bool, len(db.Achievements))
+	for _, a := range db.Achievements {
+		stored[a.ID] = true
+	}
+	var unlocked []model.AchievementUnlock
+	for _, a := range model.EvaluateAchievements(globals) {
+		if !stored[a.ID] {
+			db.Achievements = append(db.Achievements, a)
+			unlocked = append(unlocked, a)
+			db.dirty = true
+		}
+	}
+
+	var completed []model.GoalProgress
+	for i := range db.Goals {
+		goal := &db.Goals[i]
+		p := model.EvaluateGoal(*goal, globals, now)
+		if !p.Completed || (goal.CompletedAt != "" && goal.CompletedPeriod == p.Period) {
+			continue
+		}
+		goal.CompletedAt = now.Format(model.TimestampLayout)
+		goal.CompletedPeriod = p.Period
+		p.Goal = *goal
+		completed = append(completed, p)
+		db.dirty = true
+	}
+
+	return unlocked, completed
+}
@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"eu-clams/internal/model"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestUpdateProgress(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2025, 5, 16, 12, 0, 0, 0, time.UTC)
+	db := NewEntropyDB("Test Player", "")
+	db.Globals = []GlobalEntry{
+		{ID: "a", Timestamp: now.Add(-time.Hour), Type: "kill", PlayerName: "Test Player", Target: "Atrox", Value: 60},
+	}
+
+	goal, err := db.AddGoal(model.Goal{Metric: model.GoalMetricGlobals, Threshold: 2, Period: model.IntervalMonth}, now)
+	if err != nil {
+		t.Fatalf("AddGoal() error = %v", err)
+	}
+	if goal.ID != "g1" || goal.Name != "2 globals this month" {
+		t.Errorf("AddGoal() = %+v, want ID g1 with a generated name", goal)
+	}
+	if _, err := db.AddGoal(model.Goal{Metric: model.GoalMetricValue}, now); err == nil {
+		t.Error("AddGoal() accepted a goal without threshold")
+	}
+
+	unlocked, completed := db.UpdateProgress(now)
+	if len(unlocked) != 1 || unlocked[0].ID != "first_global" || len(completed) != 0 {
+		t.Fatalf("UpdateProgress() = %v, %v, want only first_global", unlocked, completed)
+	}
+
+	db.Globals = append(db.Globals, GlobalEntry{ID: "b", Timestamp: now, Type: "kill", PlayerName: "Test Player", Target: "Atrox", Value: 70})
+	unlocked, completed = db.UpdateProgress(now)
+	if len(unlocked) != 0 || len(completed) != 1 || completed[0].Goal.CompletedPeriod != "2025-05" {
+		t.Fatalf("UpdateProgress() = %v, %v, want the goal completed for 2025-05", unlocked, completed)
+	}
+
+	// Completions are reported once per period
+	if _, completed = db.UpdateProgress(now); len(completed) != 0 {
+		t.Errorf("UpdateProgress() reported the goal again: %v", completed)
+	}
+
+	if !db.RemoveGoal("g1") || db.RemoveGoal("g1") {
+		t.Error("RemoveGoal() did not remove the goal exactly once")
+	}
+
+	// The ID of a removed goal is not given out again, also after a reload
+	path := filepath.Join(t.TempDir(), "db.yaml")
+	if err := db.SaveDatabase(path, nil); err != nil {
+		t.Fatalf("SaveDatabase() error = %v", err)
+	}
+	loaded, err := LoadDatabase(path, nil)
+	if err != nil {
+		t.Fatalf("LoadDatabase() error = %v", err)
+	}
+	if goal, err := loaded.AddGoal(model.Goal{Metric: model.GoalMetricHofs, Threshold: 1}, now); err != nil || goal.ID != "g2" {
+		t.Errorf("AddGoal() after removing g1 = %+v, %v; want ID g2", goal, err)
+	}
+	if _, locked := db.GetAchievements(); len(locked) != len(model.Achievements())-1 {
+		t.Errorf("GetAchievements() locked = %d, want all but first_global", len(locked))
+	}
+}
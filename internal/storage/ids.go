@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// globalID derives the deterministic identifier of a global from its timestamp and raw message,
+// so the same chat line gets the same ID in every database it is imported into
+func globalID(timestamp time.Time, rawMessage string) string {
+	sum := sha1.Sum([]byte(timestamp.UTC().Format(time.RFC3339) + "\n" + rawMessage))
+	return hex.EncodeToString(sum[:8])
+}
+
+// addGlobal appends an entry to the database, giving it an ID that is unique within the
+// database, and returns the stored entry
+func (db *EntropyDB) addGlobal(entry GlobalEntry) GlobalEntry {
+	if db.ids == nil {
+		db.indexIDs()
+	}
+
+	assignID(&entry, db.ids)
+	db.Globals = append(db.Globals, entry)
+	db.dirty = true
+	return entry
+}
+
+// assignID gives entry an ID that is not yet in ids and adds it there
+func assignID(entry *GlobalEntry, ids map[string]bool) {
+	if entry.ID == "" {
+		entry.ID = globalID(entry.Timestamp, entry.RawMessage)
+	}
+	// The same chat line can legitimately appear twice, suffix the later ones
+	base := entry.ID
+	for n := 2; ids[entry.ID]; n++ {
+		entry.ID = fmt.Sprintf("%s-%d", base, n)
+	}
+	ids[entry.ID] = true
+}
+
+// indexIDs rebuilds the set of IDs in use
+func (db *EntropyDB) indexIDs() {
+	db.ids = make(map[string]bool, len(db.Globals))
+	for _, g := range db.Globals {
+		if g.ID != "" {
+			db.ids[g.ID] = true
+		}
+	}
+}
+
+// EnsureIDs gives every stored global without an ID one, and returns how many were assigned.
+// Databases written before IDs existed are backfilled this way when they are loaded.
+func (db *EntropyDB) EnsureIDs() int {
+	globals := db.Globals
+	db.Globals = make([]GlobalEntry, 0, len(globals))
+	db.ids = make(map[string]bool, len(globals))
+
+	dirty := db.dirty
+	assigned := 0
+	for _, g := range globals {
+		if g.ID == "" || db.ids[g.ID] {
+			g.ID = ""
+			assigned++
+		}
+		db.addGlobal(g)
+	}
+
+	db.dirty = dirty || assigned > 0
+	return assigned
+}
+
+// FindGlobal returns the index of the stored global with the given ID
+func (db *EntropyDB) FindGlobal(id string) (int, bool) {
+	for i := range db.Globals {
+		if db.Globals[i].ID == id {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// GetGlobal returns the stored global with the given ID
+func (db *EntropyDB) GetGlobal(id string) (GlobalEntry, bool) {
+	if i, ok := db.FindGlobal(id); ok {
+		return db.Globals[i], true
+	}
+	return GlobalEntry{}, false
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"eu-clams/internal/model"
+	"testing"
+	"time"
+)
+
+func TestMarkups(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 5, 16, 10, 0, 0, 0, time.UTC)
+	db := NewEntropyDB("Test Player", "")
+	db.Globals = []GlobalEntry{
+		{ID: "a", Timestamp: start, Type: "find", PlayerName: "Test Player", Target: "Oil", Value: 100},
+		{ID: "b", Timestamp: start.Add(time.Hour), Type: "kill", PlayerName: "Test Player", Target: "Atrox", Value: 50},
+		{ID: "x", Timestamp: start.Add(2 * time.Hour), Type: "kill", PlayerName: "Other Player", Target: "Daikiba", Value: 70},
+	}
+
+	if err := db.SetMarkups([]model.Markup{{Item: "Oil", Percent: -5}}); err == nil {
+		t.Fatal("SetMarkups() with a negative percentage should fail")
+	}
+	if err := db.SetMarkups([]model.Markup{{Item: "Oil", Percent: 120}, {Item: "Daikiba", Absolute: 10}}); err != nil {
+		t.Fatalf("SetMarkups() error = %v", err)
+	}
+
+	stats := db.GetStatsData()
+	if stats.TotalValue != 150 || stats.TotalMarketValue != 170 {
+		t.Errorf("TotalValue = %v, TotalMarketValue = %v, want 150 and 170", stats.TotalValue, stats.TotalMarketValue)
+	}
+
+	// Comparisons value both datasets with the markups
+	c := db.CompareDatasets(model.DatasetFilter{Label: "Me"}, model.DatasetFilter{Label: "Other", Player: "Other Player"})
+	for _, m := range c.Metrics {
+		if m.Metric == "total_market_value" && (m.A != 170 || m.B != 80) {
+			t.Errorf("CompareDatasets() total_market_value = %+v, want 170 vs 80", m)
+		}
+	}
+	if c.StatsB.TotalMarketValue != 80 {
+		t.Errorf("CompareDatasets() B market value = %v, want 80", c.StatsB.TotalMarketValue)
+	}
+
+	// Only the player's targets can be missing a markup
+	report := db.GetMarkupReport()
+	if len(report.Markups) != 2 || len(report.Missing) != 1 || report.Missing[0].Item != "Atrox" {
+		t.Errorf("GetMarkupReport() = %+v, want 2 markups and Atrox missing", report)
+	}
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"eu-clams/internal/model"
+	"sort"
+	"strings"
+)
+
+// SaveOverlay validates an overlay profile and stores it, replacing the profile of the same
+// name. It returns the stored profile and whether it is new.
+func (db *EntropyDB) SaveOverlay(profile model.OverlayProfile) (model.OverlayProfile, bool, error) {
+	profile.Name = strings.TrimSpace(profile.Name)
+	profile = profile.WithDefaults()
+	if err := model.ValidateOverlayProfile(profile); err != nil {
+		return model.OverlayProfile{}, false, err
+	}
+
+	db.dirty = true
+	for i, p := range db.Overlays {
+		if p.Name == profile.Name {
+			db.Overlays[i] = profile
+			return profile, false, nil
+		}
+	}
+	db.Overlays = append(db.Overlays, profile)
+	sort.Slice(db.Overlays, func(i, j int) bool { return db.Overlays[i].Name < db.Overlays[j].Name })
+	return profile, true, nil
+}
+
+// RemoveOverlay deletes the overlay profile with the given name and reports whether it existed
+func (db *EntropyDB) RemoveOverlay(name string) bool {
+	for i, p := range db.Overlays {
+		if p.Name == name {
+			db.Overlays = append(db.Overlays[:i], db.Overlays[i+1:]...)
+			db.dirty = true
+			return true
+		}
+	}
+	return false
+}
+
+// GetOverlay returns the overlay profile with the given name
+func (db *EntropyDB) GetOverlay(name string) (model.OverlayProfile, bool) {
+	for _, p := range db.Overlays {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return model.OverlayProfile{}, false
+}
+
+// GetOverlays returns the saved overlay profiles by name
+func (db *EntropyDB) GetOverlays() []model.OverlayProfile {
+	return append([]model.OverlayProfile{}, db.Overlays...)
+}
+
+// GetOverlayGlobals returns the player's latest globals an overlay profile shows, newest
+// first and at most the profile's ticker size
+func (db *EntropyDB) GetOverlayGlobals(profile model.OverlayProfile) []GlobalEntry {
+	shown := []GlobalEntry{}
+	for _, g := range db.GetPlayerGlobals() {
+		if len(shown) == profile.TickerSize {
+			break
+		}
+		if profile.ShowsGlobal(toModelEntry(g)) {
+			shown = append(shown, g)
+		}
+	}
+	return shown
+}
+
+// GetOpenSession returns the summary of the session that is still receiving activity, if any
+func (db *EntropyDB) GetOpenSession() (model.SessionSummary, bool) {
+	session, ok := db.OpenSession()
+	if !ok {
+		return model.SessionSummary{}, false
+	}
+	return summarizeSession(session, db.modelEntries()), true
+}
@@ -0,0 +1,58 @@
+package storage
+
+import (
+	"eu-clams/internal/model"
+	"testing"
+	"time"
+)
+
+func TestSaveOverlay(t *testing.T) {
+	t.Parallel()
+	db := NewEntropyDB("Test Player", "")
+
+	saved, created, err := db.SaveOverlay(model.OverlayProfile{Name: " stream ", Widgets: []string{model.OverlayWidgetTicker}})
+	if err != nil || !created {
+		t.Fatalf("SaveOverlay() = %v, %v, want a new profile", created, err)
+	}
+	if saved.Name != "stream" || saved.Background != "transparent" {
+		t.Errorf("SaveOverlay() = %+v, want a trimmed name and default colours", saved)
+	}
+	if _, created, _ := db.SaveOverlay(model.OverlayProfile{Name: "stream", MinValue: 100}); created {
+		t.Error("saving a profile of the same name created another")
+	}
+	if p, ok := db.GetOverlay("stream"); !ok || p.MinValue != 100 || len(db.GetOverlays()) != 1 {
+		t.Errorf("GetOverlay() = %+v, %v, want the replaced profile", p, ok)
+	}
+	if _, _, err := db.SaveOverlay(model.OverlayProfile{Name: "bad name"}); err == nil {
+		t.Error("SaveOverlay() accepted a name with a space")
+	}
+
+	if !db.RemoveOverlay("stream") || db.RemoveOverlay("stream") {
+		t.Error("RemoveOverlay() did not remove the profile exactly once")
+	}
+}
+
+func TestGetOverlayGlobals(t *testing.T) {
+	t.Parallel()
+	start := time.Date(2025, 5, 16, 10, 0, 0, 0, time.UTC)
+	db := NewEntropyDB("Test Player", "")
+	db.Globals = []GlobalEntry{
+		{ID: "a", Timestamp: start, PlayerName: "Test Player", Target: "Atrox", Value: 100},
+		{ID: "b", Timestamp: start.Add(time.Hour), PlayerName: "Test Player", Target: "Daikiba", Value: 20},
+		{ID: "c", Timestamp: start.Add(2 * time.Hour), PlayerName: "Other", Target: "Atrox", Value: 500},
+		{ID: "d", Timestamp: start.Add(3 * time.Hour), PlayerName: "Test Player", Target: "Oratan", Value: 1200, IsHof: true},
+	}
+
+	profile := model.DefaultOverlayProfile()
+	profile.MinValue = 50
+	profile.TickerSize = 5
+	globals := db.GetOverlayGlobals(profile)
+	if len(globals) != 2 || globals[0].ID != "d" || globals[1].ID != "a" {
+		t.Errorf("GetOverlayGlobals() = %v, want d then a", globals)
+	}
+
+	profile.Events = []string{model.OverlayEventHof}
+	if globals := db.GetOverlayGlobals(profile); len(globals) != 1 || globals[0].ID != "d" {
+		t.Errorf("GetOverlayGlobals(hof) = %v, want only d", globals)
+	}
+}
@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tiers a global can be filtered by
+const (
+	TierGlobal = "global" // Globals that did not make the Hall of Fame
+	TierHof    = "hof"
+)
+
+// GlobalSortFields lists the fields QueryGlobals can sort by
+var GlobalSortFields = []string{"timestamp", "value", "target"}
+
+// ErrInvalidCursor is returned for a cursor that was not issued for the same sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// GlobalQuery selects a page of the player's globals. Zero values match everything.
+type GlobalQuery struct {
+	From, To   time.Time // Inclusive time range
+	Type       string    // Exact global type, e.g. "kill"
+	Target     string    // Case-insensitive substring of the target name
+	Location   string    // Case-insensitive substring of the location
+	MinValue   float64   // Minimum value in PED
+	MaxValue   float64   // Maximum value in PED, zero for no maximum
+	Tier       string    // TierGlobal or TierHof
+	Sort       string    // One of GlobalSortFields, timestamp by default
+	Descending bool
+	Limit      int    // Maximum number of globals on the page, zero for all
+	Cursor     string // NextCursor of the previous page
+}
+
+// GlobalPage is one page of a global query
+type GlobalPage struct {
+	Globals    []GlobalEntry
+	Total      int    // Number of globals matching the filters on all pages
+	NextCursor string // Cursor of the next page, empty on the last page
+}
+
+// pageCursor is the position after the last global of a page. Pages are ordered by the sort
+// field and then by ID, so the position stays valid when globals are added or removed.
+type pageCursor struct {
+	Sort       string    `json:"s"`
+	Descending bool      `json:"d"`
+	Timestamp  time.Time `json:"t,omitempty"`
+	Value      float64   `json:"v,omitempty"`
+	Target     string    `json:"g,omitempty"`
+	ID         string    `json:"i"`
+}
+
+// QueryGlobals filters, sorts and pages the player's globals
+func (db *EntropyDB) QueryGlobals(q GlobalQuery) (GlobalPage, error) {
+	if q.Sort == "" {
+		q.Sort = "timestamp"
+	}
+	compare, err := globalComparer(q.Sort)
+	if err != nil {
+		return GlobalPage{}, err
+	}
+	if q.Tier != "" && q.Tier != TierGlobal && q.Tier != TierHof {
+		return GlobalPage{}, fmt.Errorf("invalid tier %q: must be %s or %s", q.Tier, TierGlobal, TierHof)
+	}
+	less := func(a, b GlobalEntry) bool {
+		c := compare(a, b)
+		if c == 0 {
+			c = strings.Compare(a.ID, b.ID)
+		}
+		if q.Descending {
+			return c > 0
+		}
+		return c < 0
+	}
+
+	var matches []GlobalEntry
+	for _, entry := range db.GetPlayerGlobals() {
+		if q.matches(entry) {
+			matches = append(matches, entry)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return less(matches[i], matches[j]) })
+	page := GlobalPage{Total: len(matches)}
+
+	if q.Cursor != "" {
+		cursor, err := decodeCursor(q.Cursor)
+		if err != nil || cursor.Sort != q.Sort || cursor.Descending != q.Descending {
+			return GlobalPage{}, ErrInvalidCursor
+		}
+		last := GlobalEntry{ID: cursor.ID, Timestamp: cursor.Timestamp, Value: cursor.Value, Target: cursor.Target}
+		start := sort.Search(len(matches), func(i int) bool { return less(last, matches[i]) })
+		matches = matches[start:]
+	}
+
+	if q.Limit > 0 && len(matches) > q.Limit {
+		matches = matches[:q.Limit]
+		last := matches[len(matches)-1]
+		page.NextCursor = encodeCursor(pageCursor{
+			Sort:       q.Sort,
+			Descending: q.Descending,
+			Timestamp:  last.Timestamp,
+			Value:      last.Value,
+			Target:     last.Target,
+			ID:         last.ID,
+		})
+	}
+	page.Globals = matches
+	return page, nil
+}
+
+// matches returns whether a global passes the filters of the query
+func (q GlobalQuery) matches(entry GlobalEntry) bool {
+	if !q.From.IsZero() && entry.Timestamp.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && entry.Timestamp.After(q.To) {
+		return false
+	}
+	if q.Type != "" && entry.Type != q.Type {
+		return false
+	}
+	if q.Target != "" && !strings.Contains(strings.ToLower(entry.Target), strings.ToLower(q.Target)) {
+		return false
+	}
+	if q.Location != "" && !strings.Contains(strings.ToLower(entry.Location), strings.ToLower(q.Location)) {
+		return false
+	}
+	if entry.Value < q.MinValue || (q.MaxValue > 0 && entry.Value > q.MaxValue) {
+		return false
+	}
+	switch q.Tier {
+	case TierGlobal:
+		return !entry.IsHof
+	case TierHof:
+		return entry.IsHof
+	}
+	return true
+}
+
+// globalComparer returns a three-way comparison of globals by one of GlobalSortFields
+func globalComparer(field string) (func(a, b GlobalEntry) int, error) {
+	switch field {
+	case "timestamp":
+		return func(a, b GlobalEntry) int { return a.Timestamp.Compare(b.Timestamp) }, nil
+	case "value":
+		return func(a, b GlobalEntry) int {
+			switch {
+			case a.Value < b.Value:
+				return -1
+			case a.Value > b.Value:
+				return 1
+			}
+			return 0
+		}, nil
+	case "target":
+		return func(a, b GlobalEntry) int {
+			return strings.Compare(strings.ToLower(a.Target), strings.ToLower(b.Target))
+		}, nil
+	}
+	return nil, fmt.Errorf("invalid sort field %q: must be one of %s", field, strings.Join(GlobalSortFields, ", "))
+}
+
+// encodeCursor makes an opaque cursor string
+func encodeCursor(c pageCursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reads a cursor made by encodeCursor
+func decodeCursor(s string) (pageCursor, error) {
+	var c pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	err = json.Unmarshal(data, &c)
+	return c, err
+}
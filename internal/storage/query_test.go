@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueryGlobals(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 5, 16, 10, 0, 0, 0, time.UTC)
+	db := NewEntropyDB("Test Player", "")
+	db.Globals = []GlobalEntry{
+		{ID: "a", Timestamp: start, Type: "kill", PlayerName: "Test Player", Target: "Atrox", Value: 100, Location: "Nomad Outpost"},
+		{ID: "b", Timestamp: start.Add(time.Hour), Type: "kill", PlayerName: "Test Player", Target: "Daikiba", Value: 60},
+		{ID: "c", Timestamp: start.Add(2 * time.Hour), Type: "craft", PlayerName: "Test Player", Target: "Explosive Projectiles", Value: 1500, IsHof: true},
+		{ID: "d", Timestamp: start.Add(3 * time.Hour), Type: "kill", PlayerName: "Test Player", Target: "Atrox Young", Value: 60},
+		{ID: "x", Timestamp: start.Add(4 * time.Hour), Type: "kill", PlayerName: "Other Player", Target: "Atrox", Value: 999},
+	}
+
+	ids := func(page GlobalPage) string {
+		var s string
+		for _, g := range page.Globals {
+			s += g.ID
+		}
+		return s
+	}
+
+	tests := []struct {
+		name  string
+		query GlobalQuery
+		want  string
+	}{
+		{name: "all, oldest first", query: GlobalQuery{}, want: "abcd"},
+		{name: "newest first", query: GlobalQuery{Descending: true}, want: "dcba"},
+		{name: "type", query: GlobalQuery{Type: "kill"}, want: "abd"},
+		{name: "target substring", query: GlobalQuery{Target: "atrox"}, want: "ad"},
+		{name: "location", query: GlobalQuery{Location: "nomad"}, want: "a"},
+		{name: "value range", query: GlobalQuery{MinValue: 60, MaxValue: 100}, want: "abd"},
+		{name: "time range", query: GlobalQuery{From: start.Add(time.Hour), To: start.Add(2 * time.Hour)}, want: "bc"},
+		{name: "hofs", query: GlobalQuery{Tier: TierHof}, want: "c"},
+		{name: "globals without hofs", query: GlobalQuery{Tier: TierGlobal}, want: "abd"},
+		{name: "value, ties by ID", query: GlobalQuery{Sort: "value"}, want: "bdac"},
+		{name: "target descending", query: GlobalQuery{Sort: "target", Descending: true}, want: "cbda"},
+	}
+	for _, tt := range tests {
+		page, err := db.QueryGlobals(tt.query)
+		if err != nil {
+			t.Errorf("%s: QueryGlobals() error = %v", tt.name, err)
+			continue
+		}
+		if got := ids(page); got != tt.want || page.Total != len(tt.want) {
+			t.Errorf("%s: QueryGlobals() = %s (total %d), want %s", tt.name, got, page.Total, tt.want)
+		}
+	}
+
+	// Walk the pages by value; a global stored between pages does not shift the next page
+	query := GlobalQuery{Sort: "value", Descending: true, Limit: 2}
+	page, err := db.QueryGlobals(query)
+	if err != nil || ids(page) != "ca" || page.NextCursor == "" || page.Total != 4 {
+		t.Fatalf("first page = %s, cursor %q, error %v, want ca with a cursor", ids(page), page.NextCursor, err)
+	}
+	db.Globals = append(db.Globals, GlobalEntry{ID: "e", Timestamp: start.Add(5 * time.Hour), Type: "find", PlayerName: "Test Player", Target: "Oil", Value: 2000})
+	query.Cursor = page.NextCursor
+	page, err = db.QueryGlobals(query)
+	if err != nil || ids(page) != "db" || page.NextCursor != "" {
+		t.Fatalf("second page = %s, cursor %q, error %v, want db as the last page", ids(page), page.NextCursor, err)
+	}
+
+	// A cursor only works with the sort order it was issued for
+	if _, err := db.QueryGlobals(GlobalQuery{Sort: "timestamp", Cursor: query.Cursor}); err != ErrInvalidCursor {
+		t.Errorf("QueryGlobals() with another sort order error = %v, want ErrInvalidCursor", err)
+	}
+	if _, err := db.QueryGlobals(GlobalQuery{Cursor: "not a cursor"}); err != ErrInvalidCursor {
+		t.Errorf("QueryGlobals() with a garbled cursor error = %v, want ErrInvalidCursor", err)
+	}
+	if _, err := db.QueryGlobals(GlobalQuery{Sort: "player"}); err == nil {
+		t.Error("QueryGlobals() with an unknown sort field should fail")
+	}
+	if _, err := db.QueryGlobals(GlobalQuery{Tier: "ath"}); err == nil {
+		t.Error("QueryGlobals() with an unknown tier should fail")
+	}
+}
@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"eu-clams/internal/model"
+	"sort"
+)
+
+// PersonalRecord is a global that beat the player's previous best
+type PersonalRecord struct {
+	Global   GlobalEntry        `json:"global"`
+	Scopes   []string           `json:"scopes"`   // overall, type and/or target
+	Previous map[string]float64 `json:"previous"` // Previous best per scope
+}
+
+// isPersonal returns whether a stored global counts towards the player's own statistics
+func (db *EntropyDB) isPersonal(entry GlobalEntry) bool {
+	return db.PlayerName == "" || entry.PlayerName == db.PlayerName
+}
+
+// personalIndices returns the indices of the player's globals in timestamp order
+func (db *EntropyDB) personalIndices() []int {
+	var indices []int
+	for i, entry := range db.Globals {
+		if db.isPersonal(entry) {
+			indices = append(indices, i)
+		}
+	}
+	sort.SliceStable(indices, func(a, b int) bool {
+		return db.Globals[indices[a]].Timestamp.Before(db.Globals[indices[b]].Timestamp)
+	})
+	return indices
+}
+
+// ComputeRecords flags every global of the player that beat the previous best overall, of its
+// type or of its target, e.g. after importing a history. It returns the number of flagged globals.
+func (db *EntropyDB) ComputeRecords() int {
+	tracker := model.NewRecordTracker()
+	count := 0
+	for _, i := range db.personalIndices() {
+		entry := &db.Globals[i]
+		scopes, _ := tracker.Add(entry.Type, entry.Target, entry.Value)
+		if len(scopes) > 0 {
+			count++
+		}
+		if !equalScopes(entry.Records, scopes) {
+			entry.Records = scopes
+			db.dirty = true
+		}
+	}
+	return count
+}
+
+// DetectRecords flags the given newly stored globals that beat the player's previous best, both
+// in the database and in newEntries, and returns them as records. All other stored globals
+// count as previous ones.
+func (db *EntropyDB) DetectRecords(newEntries []GlobalEntry) []PersonalRecord {
+	isNew := make(map[string]int, len(newEntries))
+	for k, entry := range newEntries {
+		isNew[entry.ID] = k
+	}
+
+	tracker := model.NewRecordTracker()
+	var added []int
+	for _, i := range db.personalIndices() {
+		entry := db.Globals[i]
+		if _, ok := isNew[entry.ID]; ok {
+			added = append(added, i)
+			continue
+		}
+		tracker.Add(entry.Type, entry.Target, entry.Value)
+	}
+
+	var records []PersonalRecord
+	for _, i := range added {
+		entry := &db.Globals[i]
+		scopes, previous := tracker.Add(entry.Type, entry.Target, entry.Value)
+		if len(scopes) == 0 {
+			continue
+		}
+		entry.Records = scopes
+		newEntries[isNew[entry.ID]].Records = scopes
+		db.dirty = true
+		records = append(records, PersonalRecord{Global: *entry, Scopes: scopes, Previous: previous})
+	}
+	return records
+}
+
+// GetRecordBook returns the player's current bests and the globals that set a record
+func (db *EntropyDB) GetRecordBook() model.RecordBook {
+	return model.GenerateRecordBook(db.modelEntries())
+}
+
+// equalScopes returns whether two lists of record scopes are the same
+func equalScopes(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"eu-clams/internal/model"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestComputeAndDetectRecords(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 5, 16, 10, 0, 0, 0, time.UTC)
+	db := NewEntropyDB("Test Player", "")
+	db.Globals = []GlobalEntry{
+		{ID: "b", Timestamp: start.Add(time.Hour), Type: "kill", PlayerName: "Test Player", Target: "Atrox", Value: 120},
+		{ID: "a", Timestamp: start, Type: "kill", PlayerName: "Test Player", Target: "Atrox", Value: 100},
+		{ID: "x", Timestamp: start.Add(2 * time.Hour), Type: "kill", PlayerName: "Other Player", Target: "Atrox", Value: 999},
+	}
+
+	// Stored order does not matter, only the timestamps
+	if count := db.ComputeRecords(); count != 1 {
+		t.Fatalf("ComputeRecords() = %d, want 1", count)
+	}
+	want := []string{model.RecordOverall, model.RecordType, model.RecordTarget}
+	if !reflect.DeepEqual(db.Globals[0].Records, want) || db.Globals[1].Records != nil || db.Globals[2].Records != nil {
+		t.Errorf("Records = %v, %v, %v, want only b flagged", db.Globals[0].Records, db.Globals[1].Records, db.Globals[2].Records)
+	}
+
+	db.Globals = append(db.Globals,
+		GlobalEntry{ID: "c", Timestamp: start.Add(3 * time.Hour), Type: "kill", PlayerName: "Test Player", Target: "Daikiba", Value: 110},
+		GlobalEntry{ID: "d", Timestamp: start.Add(4 * time.Hour), Type: "kill", PlayerName: "Test Player", Target: "Daikiba", Value: 115},
+	)
+	records := db.DetectRecords(db.Globals[3:])
+	if len(records) != 1 || records[0].Global.ID != "d" || !reflect.DeepEqual(records[0].Scopes, []string{model.RecordTarget}) {
+		t.Fatalf("DetectRecords() = %+v, want d as a Daikiba record", records)
+	}
+	if records[0].Previous[model.RecordTarget] != 110 {
+		t.Errorf("Previous = %v, want 110 for the target", records[0].Previous)
+	}
+	if !reflect.DeepEqual(db.Globals[4].Records, []string{model.RecordTarget}) {
+		t.Errorf("stored Records = %v, want the target flag", db.Globals[4].Records)
+	}
+
+	// Editing a value recomputes the flags of every global
+	value := 90.0
+	if _, err := db.EditGlobal("b", GlobalEdit{Value: &value}, "test"); err != nil {
+		t.Fatalf("EditGlobal() error = %v", err)
+	}
+	if db.Globals[0].Records != nil || len(db.Globals[3].Records) == 0 {
+		t.Errorf("after edit: b = %v, c = %v, want b no longer a record and c one", db.Globals[0].Records, db.Globals[3].Records)
+	}
+}
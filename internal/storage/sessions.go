@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"bufio"
+	"eu-clams/internal/model"
+	"fmt"
+	"os"
+	"time"
+)
+
+// DefaultSessionIdleGap is the chat log inactivity after which a session ends
+const DefaultSessionIdleGap = 30 * time.Minute
+
+// Session is a period of continuous chat log activity
+type Session struct {
+	ID    string    `yaml:"id" json:"id"`
+	Start time.Time `yaml:"start" json:"start"` // First chat line, in the chat log's wall clock
+	End   time.Time `yaml:"end" json:"end"`     // Last chat line so far
+	Lines int       `yaml:"lines" json:"lines"`
+	Open  bool      `yaml:"open,omitempty" json:"open"` // Still receiving activity
+}
+
+// sessionID returns the ID of a session starting at start
+func sessionID(start time.Time) string {
+	return start.Format("20060102-150405")
+}
+
+// SetSessionIdleGap sets the inactivity after which a session ends. Zero selects the default.
+func (db *EntropyDB) SetSessionIdleGap(gap time.Duration) {
+	db.sessionIdleGap = gap
+}
+
+// idleGap returns the configured session idle gap
+func (db *EntropyDB) idleGap() time.Duration {
+	if db.sessionIdleGap <= 0 {
+		return DefaultSessionIdleGap
+	}
+	return db.sessionIdleGap
+}
+
+// recordActivity extends the open session with a chat line, or starts a new session when the
+// line follows the last activity by more than the idle gap. Lines without a timestamp are ignored.
+func (db *EntropyDB) recordActivity(line string) {
+	if len(line) < len(model.TimestampLayout) {
+		return
+	}
+	t, err := time.Parse(model.TimestampLayout, line[:len(model.TimestampLayout)])
+	if err != nil {
+		return
+	}
+
+	if n := len(db.Sessions); n > 0 {
+		last := &db.Sessions[n-1]
+		if last.Open && !t.After(last.End.Add(db.idleGap())) {
+			if t.After(last.End) {
+				last.End = t
+			}
+			last.Lines++
+			db.dirty = true
+			return
+		}
+		last.Open = false
+	}
+
+	db.Sessions = append(db.Sessions, Session{ID: sessionID(t), Start: t, End: t, Lines: 1, Open: true})
+	db.dirty = true
+}
+
+// CloseIdleSession ends the open session if there was no activity for longer than the idle gap
+// before now, and returns the closed session
+func (db *EntropyDB) CloseIdleSession(now time.Time) (Session, bool) {
+	n := len(db.Sessions)
+	if n == 0 || !db.Sessions[n-1].Open || !now.After(db.Sessions[n-1].End.Add(db.idleGap())) {
+		return Session{}, false
+	}
+	db.Sessions[n-1].Open = false
+	db.dirty = true
+	return db.Sessions[n-1], true
+}
+
+// OpenSession returns the session that is still receiving activity, if any
+func (db *EntropyDB) OpenSession() (Session, bool) {
+	if n := len(db.Sessions); n > 0 && db.Sessions[n-1].Open {
+		return db.Sessions[n-1], true
+	}
+	return Session{}, false
+}
+
+// RebuildSessions replaces all sessions with those found in the whole chat log, for example
+// after the idle gap changed or for databases created before sessions were tracked
+func (db *EntropyDB) RebuildSessions(logPath string) (int, error) {
+	file, err := os.Open(logPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open chat log: %w", err)
+	}
+	defer file.Close()
+
+	previous := db.Sessions
+	db.Sessions = nil
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		db.recordActivity(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		db.Sessions = previous
+		return 0, fmt.Errorf("error reading chat log: %w", err)
+	}
+
+	db.dirty = true
+	return len(db.Sessions), nil
+}
+
+// summarizeSession summarizes the player's globals during a session
+func summarizeSession(session Session, globals []model.GlobalEntry) model.SessionSummary {
+	return model.SummarizeSession(session.ID, session.Start, session.End, session.Open, session.Lines,
+		model.SessionGlobals(globals, session.Start, session.End))
+}
+
+// GetSessions returns summaries of all sessions, newest first
+func (db *EntropyDB) GetSessions() []model.SessionSummary {
+	globals := db.modelEntries()
+	summaries := make([]model.SessionSummary, 0, len(db.Sessions))
+	for i := len(db.Sessions) - 1; i >= 0; i-- {
+		summaries = append(summaries, summarizeSession(db.Sessions[i], globals))
+	}
+	return summaries
+}
+
+// GetSession returns the summary, globals and per-target statistics of the session with the given ID
+func (db *EntropyDB) GetSession(id string) (model.SessionDetail, bool) {
+	for _, session := range db.Sessions {
+		if session.ID != id {
+			continue
+		}
+		globals := model.SessionGlobals(db.modelEntries(), session.Start, session.End)
+		return model.SessionDetail{
+			SessionSummary: model.SummarizeSession(session.ID, session.Start, session.End, session.Open, session.Lines, globals),
+			Entries:        globals,
+			Targets:        model.GenerateTargetStats(globals),
+		}, true
+	}
+	return model.SessionDetail{}, false
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessions(t *testing.T) {
+	t.Parallel()
+
+	lines := []string{
+		"2025-05-16 10:00:00 [System] [] You are now in Zone A",
+		"2025-05-16 10:20:00 [Globals] [] Test Player killed a creature (Atrox) with a value of 50 PED at Zone A!",
+		"2025-05-16 10:40:00 [Local] [Someone] hello",
+		"not a chat line",
+		"2025-05-16 12:00:00 [Globals] [] Test Player killed a creature (Daikiba) with a value of 80 PED",
+		"2025-05-16 12:05:00 [Globals] [] Other Player killed a creature (Daikiba) with a value of 90 PED",
+	}
+	path := filepath.Join(t.TempDir(), "chat.log")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("failed to write chat log: %v", err)
+	}
+
+	db := NewEntropyDB("Test Player", "")
+	if _, err := db.ProcessChatLog(path, nil, nil); err != nil {
+		t.Fatalf("ProcessChatLog() error = %v", err)
+	}
+
+	// The 80 minute pause ends the first session
+	if len(db.Sessions) != 2 || db.Sessions[0].Open || !db.Sessions[1].Open {
+		t.Fatalf("Sessions = %+v, want a closed and an open session", db.Sessions)
+	}
+	if db.Sessions[0].Lines != 3 || !db.Sessions[0].End.Equal(time.Date(2025, 5, 16, 10, 40, 0, 0, time.UTC)) {
+		t.Errorf("Sessions[0] = %+v, want 3 lines ending at 10:40", db.Sessions[0])
+	}
+
+	if _, closed := db.CloseIdleSession(time.Date(2025, 5, 16, 12, 20, 0, 0, time.UTC)); closed {
+		t.Errorf("CloseIdleSession() closed a session with recent activity")
+	}
+	if _, closed := db.CloseIdleSession(time.Date(2025, 5, 16, 13, 0, 0, 0, time.UTC)); !closed {
+		t.Errorf("CloseIdleSession() did not close an idle session")
+	}
+
+	sessions := db.GetSessions()
+	if len(sessions) != 2 || sessions[0].ID != "20250516-120000" || sessions[0].Globals != 1 || sessions[0].DurationSeconds != 300 {
+		t.Errorf("GetSessions() = %+v, want the newest session first with the player's global", sessions)
+	}
+
+	detail, ok := db.GetSession("20250516-100000")
+	if !ok || detail.Globals != 1 || detail.TotalValue != 50 || len(detail.Locations) != 1 || detail.Locations[0] != "Zone A" {
+		t.Errorf("GetSession() = %+v, %v", detail, ok)
+	}
+
+	// A longer idle gap merges both sessions
+	db.SetSessionIdleGap(2 * time.Hour)
+	if count, err := db.RebuildSessions(path); err != nil || count != 1 {
+		t.Errorf("RebuildSessions() = %d, %v, want 1 session", count, err)
+	}
+}
@@ -1,30 +1,164 @@
 package storage
 
 import (
+	"eu-clams/internal/analysis"
 	"eu-clams/internal/model"
+	"time"
 )
 
 // GetStatsData generates stats data for the current database
 func (db *EntropyDB) GetStatsData() model.Stats {
+	// Generate stats using the model function
+	return db.statsOf(db.modelEntries())
+}
+
+// GetStatsForGlobals generates stats data for a selection of globals, such as the result of
+// QueryGlobals, with the configured markups and value distribution
+func (db *EntropyDB) GetStatsForGlobals(entries []GlobalEntry) model.Stats {
+	globals := make([]model.GlobalEntry, len(entries))
+	for i, entry := range entries {
+		globals[i] = toModelEntry(entry)
+	}
+	return db.statsOf(globals)
+}
+
+// statsOf generates stats data for globals converted for the model package
+func (db *EntropyDB) statsOf(globals []model.GlobalEntry) model.Stats {
+	stats := model.GenerateStatsFromGlobals(globals)
+	if db.histogramEdges != nil || db.valueBrackets != nil {
+		// Configured edges and brackets were validated when they were set
+		stats.Values, _ = model.GenerateValueDistribution(globals, db.histogramEdges, db.valueBrackets, "")
+	}
+	model.ApplyMarkups(&stats, db.markups)
+	return stats
+}
+
+// SetMarkups sets the markups used to compute market values
+func (db *EntropyDB) SetMarkups(markups []model.Markup) error {
+	table, err := model.NewMarkupTable(markups)
+	if err != nil {
+		return err
+	}
+	db.markups = table
+	return nil
+}
+
+// MarketValue returns the value of a global with the markup of its target applied, or its TT
+// value if the target has no markup
+func (db *EntropyDB) MarketValue(entry GlobalEntry) float64 {
+	if m, ok := db.markups.Lookup(entry.Target); ok {
+		return m.Apply(entry.Value)
+	}
+	return entry.Value
+}
+
+// MarkupReport lists the configured markups and the player's targets that have none
+type MarkupReport struct {
+	Markups []model.Markup        `json:"markups"`
+	Missing []model.MissingMarkup `json:"missing"`
+}
+
+// GetMarkupReport returns the markups in use and the targets still valued at TT
+func (db *EntropyDB) GetMarkupReport() model.MarkupReport {
+	return model.MarkupReport{Markups: db.markups.Markups(), Missing: db.GetStatsData().MissingMarkups}
+}
+
+// SetValueDistribution sets the histogram edges and value brackets used for the player's
+// value distribution. Empty edges or brackets select the defaults.
+func (db *EntropyDB) SetValueDistribution(edges []float64, brackets []model.ValueBracket) error {
+	if len(edges) > 0 {
+		if err := model.ValidateHistogramEdges(edges); err != nil {
+			return err
+		}
+	}
+	if len(brackets) > 0 {
+		if err := model.ValidateValueBrackets(brackets); err != nil {
+			return err
+		}
+	}
+	db.histogramEdges = edges
+	db.valueBrackets = brackets
+	return nil
+}
+
+// GetValueDistribution computes the value distribution of the player's globals, optionally
+// for a single type and per day, week or month. Nil edges select the configured edges.
+func (db *EntropyDB) GetValueDistribution(interval, typ string, edges []float64) (model.ValueDistribution, error) {
+	globals := db.modelEntries()
+	if typ != "" {
+		filtered := globals[:0]
+		for _, entry := range globals {
+			if entry.Type == typ {
+				filtered = append(filtered, entry)
+			}
+		}
+		globals = filtered
+	}
+	if edges == nil {
+		edges = db.histogramEdges
+	}
+	return model.GenerateValueDistribution(globals, edges, db.valueBrackets, interval)
+}
+
+// GetTimeSeries aggregates the player's globals per day, week or month within the given window
+func (db *EntropyDB) GetTimeSeries(interval string, from, to time.Time, byType bool) (model.TimeSeries, error) {
+	return model.GenerateTimeSeries(db.modelEntries(), interval, from, to, byType)
+}
+
+// GetDroughtReport analyses the gaps between the player's globals up to now
+func (db *EntropyDB) GetDroughtReport(now time.Time, streakWindow time.Duration) analysis.DroughtReport {
+	return analysis.AnalyzeDroughts(db.modelEntries(), now, streakWindow)
+}
+
+// CompareDatasets compares the statistics of two datasets, with the configured markups. A
+// dataset without a player or team covers the player's own globals; one with an identity
+// searches everyone's captured globals, or every stored global when the Universe partition
+// is empty.
+func (db *EntropyDB) CompareDatasets(a, b model.DatasetFilter) model.Comparison {
+	statsA := db.statsOf(model.FilterGlobals(db.datasetEntries(a), a))
+	statsB := db.statsOf(model.FilterGlobals(db.datasetEntries(b), b))
+	return model.CompareStats(a, statsA, b, statsB)
+}
+
+// datasetEntries returns the globals a comparison dataset is selected from
+func (db *EntropyDB) datasetEntries(filter model.DatasetFilter) []model.GlobalEntry {
+	if filter.Player == "" && filter.Team == "" {
+		return db.modelEntries()
+	}
+	if len(db.Universe) > 0 {
+		return db.universeEntries()
+	}
+	entries := make([]model.GlobalEntry, len(db.Globals))
+	for i, entry := range db.Globals {
+		entries[i] = toModelEntry(entry)
+	}
+	return entries
+}
+
+// modelEntries returns the player's globals converted for the model package
+func (db *EntropyDB) modelEntries() []model.GlobalEntry {
 	// Convert storage.GlobalEntry to model.GlobalEntry
 	modelEntries := make([]model.GlobalEntry, 0, len(db.Globals))
 	for _, entry := range db.Globals {
 		if db.PlayerName != "" && entry.PlayerName != db.PlayerName {
 			continue // Skip entries not related to the player
 		}
-		modelEntry := model.GlobalEntry{
-			Timestamp:  entry.Timestamp.Format("2006-01-02 15:04:05"),
-			Type:       entry.Type,
-			PlayerName: entry.PlayerName,
-			TeamName:   entry.TeamName,
-			Target:     entry.Target,
-			Value:      entry.Value,
-			Location:   entry.Location,
-			IsHof:      entry.IsHof,
-		}
-		modelEntries = append(modelEntries, modelEntry)
+		modelEntries = append(modelEntries, toModelEntry(entry))
 	}
+	return modelEntries
+}
 
-	// Generate stats using the model function
-	return model.GenerateStatsFromGlobals(modelEntries)
+// toModelEntry converts a stored global for the model package
+func toModelEntry(entry GlobalEntry) model.GlobalEntry {
+	return model.GlobalEntry{
+		Timestamp:  entry.Timestamp.Format("2006-01-02 15:04:05"),
+		Type:       entry.Type,
+		PlayerName: entry.PlayerName,
+		TeamName:   entry.TeamName,
+		Target:     entry.Target,
+		Value:      entry.Value,
+		Location:   entry.Location,
+		IsHof:      entry.IsHof,
+		Records:    entry.Records,
+	}
 }
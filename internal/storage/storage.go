@@ -3,17 +3,20 @@ package storage
 import (
 	"bufio"
 	"eu-clams/internal/logger"
+	"eu-clams/internal/model"
 	"fmt"
 	"html"
 	"os"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
 // GlobalEntry represents a single global message
 type GlobalEntry struct {
+	ID         string    `yaml:"id" json:"id"` // Stable identifier derived from timestamp and raw message
 	Timestamp  time.Time `yaml:"timestamp" json:"timestamp"`
 	Type       string    `yaml:"type" json:"type"` // e.g., "kill", "craft", "find"
 	PlayerName string    `yaml:"player" json:"player"`
@@ -23,16 +26,39 @@ type GlobalEntry struct {
 	Location   string    `yaml:"location,omitempty" json:"location,omitempty"`
 	IsHof      bool      `yaml:"is_hof" json:"is_hof"`
 	RawMessage string    `yaml:"raw_message" json:"raw_message"`
+	Records    []string  `yaml:"records,omitempty" json:"records,omitempty"` // Personal record scopes set by this global
+	Member     string    `yaml:"member,omitempty" json:"member,omitempty"`   // Team member who pushed the global to a hub
 }
 
 // EntropyDB is the main structure for storing EU data
 type EntropyDB struct {
-	Globals           []GlobalEntry `yaml:"globals"`
-	PlayerName        string        `yaml:"player_name,omitempty"`
-	TeamName          string        `yaml:"team_name,omitempty"`
-	LastProcessed     time.Time     `yaml:"last_processed,omitempty"`
-	LastProcessedSize int64         `yaml:"last_processed_size,omitempty"`
-	dirty             bool          // Indicates if the database has unsaved changes
+	Globals           []GlobalEntry             `yaml:"globals"`
+	PlayerName        string                    `yaml:"player_name,omitempty"`
+	TeamName          string                    `yaml:"team_name,omitempty"`
+	LastProcessed     time.Time                 `yaml:"last_processed,omitempty"`
+	LastProcessedSize int64                     `yaml:"last_processed_size,omitempty"`
+	Changes           []ChangeRecord            `yaml:"changes,omitempty"`      // History of manual edits and deletions
+	Sessions          []Session                 `yaml:"sessions,omitempty"`     // Periods of chat log activity, oldest first
+	Universe          []GlobalEntry             `yaml:"-"`                      // Everyone's globals, kept in their own file when universe capture is on
+	Goals             []model.Goal              `yaml:"goals,omitempty"`        // User-defined goals with their last completion
+	NextGoalID        int                       `yaml:"next_goal_id,omitempty"` // Number of the next goal's ID, so IDs of removed goals are not reused
+	Achievements      []model.AchievementUnlock `yaml:"achievements,omitempty"` // Unlocked achievements, oldest first
+	Overlays          []model.OverlayProfile    `yaml:"overlays,omitempty"`     // Saved streaming overlay profiles
+	Team              []GlobalEntry             `yaml:"team,omitempty"`         // Globals pushed by team members, kept in hub mode
+	dirty             bool                      // Indicates if the database has unsaved changes
+	path              string                    // File the database was loaded from or last saved to
+	ids               map[string]bool           // IDs in use, built on first insert
+	histogramEdges    []float64                 // Value histogram edges, nil for the defaults
+	valueBrackets     []model.ValueBracket      // Value brackets, nil for the defaults
+	markups           model.MarkupTable         // Market value of targets, nil values everything at TT
+	sessionIdleGap    time.Duration             // Inactivity that ends a session, zero for the default
+	captureUniverse   bool                      // Whether to store everyone's globals in Universe
+	universeIDs       map[string]bool           // IDs in use in Universe, built on first insert
+	universeDirty     bool                      // Indicates if Universe has unsaved changes
+	teamIDs           map[string]bool           // IDs in Team, built on first merge
+	mu                *sync.RWMutex             // Held by the goroutines sharing the database, see Lock; a pointer as yaml copies the struct
+	linesParsed       int64                     // Chat log lines read since the database was loaded
+	parseErrors       int64                     // Chat log lines that failed to parse
 }
 
 // NewEntropyDB creates a new empty database
@@ -41,9 +67,32 @@ func NewEntropyDB(playerName string, teamName string) *EntropyDB {
 		Globals:    []GlobalEntry{},
 		PlayerName: playerName,
 		TeamName:   teamName,
+		mu:         &sync.RWMutex{},
 	}
 }
 
+// Lock locks the database for a change or a save. The database does not lock itself:
+// goroutines sharing it, such as the chat log watcher, the web server and the GUI, hold the
+// lock around every change and save, and the read lock around every read.
+func (db *EntropyDB) Lock() {
+	db.mu.Lock()
+}
+
+// Unlock unlocks the database after a change or a save
+func (db *EntropyDB) Unlock() {
+	db.mu.Unlock()
+}
+
+// RLock locks the database for reading
+func (db *EntropyDB) RLock() {
+	db.mu.RLock()
+}
+
+// RUnlock unlocks the database after reading
+func (db *EntropyDB) RUnlock() {
+	db.mu.RUnlock()
+}
+
 // regular expressions for parsing different types of global messages
 var ( // For team kills - handle both literal quotes and HTML entities
 	teamKillRegex = regexp.MustCompile(`\[\s*Globals\s*\]\s*\[\s*\]\s*Team\s*(?:"([^"]+)"|&quot;([^&]*?)&quot;)\s*killed\s*a\s*creature\s*\(([^)]+)\)\s*with\s*a\s*value\s*of\s*(\d+)\s*PED(?:\s*at\s*([^!]+))?(!)?(?:\s*A\s*record\s*has\s*been\s*added\s*to\s*the\s*Hall\s*of\s*Fame!)?`)
@@ -78,6 +127,7 @@ func ParseChatLine(line string) (*GlobalEntry, error) {
 	}
 
 	entry := GlobalEntry{
+		ID:         globalID(timestamp, line),
 		Timestamp:  timestamp,
 		RawMessage: line,
 	} // Try to match against different global message patterns
@@ -93,7 +143,7 @@ func ParseChatLine(line string) (*GlobalEntry, error) {
 		entry.Target = matches[3]
 		entry.Value = parseValue(matches[4])
 		if len(matches) > 5 && matches[5] != "" {
-			entry.Location = strings.TrimSpace(matches[5])
+			entry.Location = model.NormalizeLocation(matches[5])
 		}
 		// Check for Hall of Fame indicator - only use the explicit "Hall of Fame" text
 		entry.IsHof = strings.Contains(line, "Hall of Fame")
@@ -106,7 +156,7 @@ func ParseChatLine(line string) (*GlobalEntry, error) {
 		entry.Target = matches[2]
 		entry.Value = parseValue(matches[3])
 		if len(matches) > 4 && matches[4] != "" {
-			entry.Location = strings.TrimSpace(matches[4])
+			entry.Location = model.NormalizeLocation(matches[4])
 		}
 		// Check for Hall of Fame indicator - only use the explicit "Hall of Fame" text
 		entry.IsHof = strings.Contains(line, "Hall of Fame")
@@ -186,28 +236,29 @@ func parseValue(val string) float64 {
 	return value
 }
 
-// ProcessChatLogFromOffset reads a chat log file from a specific offset and extracts global messages
-func (db *EntropyDB) ProcessChatLogFromOffset(logPath string, offset int64, progressChan chan<- float64, logger *logger.Logger) (int, error) {
+// ProcessChatLogFromOffset reads a chat log file from a specific offset and extracts global
+// messages, returning the globals it added
+func (db *EntropyDB) ProcessChatLogFromOffset(logPath string, offset int64, progressChan chan<- float64, logger *logger.Logger) ([]GlobalEntry, error) {
 	if db == nil {
-		return 0, fmt.Errorf("database is nil")
+		return nil, fmt.Errorf("database is nil")
 	}
 
 	file, err := os.Open(logPath)
 	if err != nil {
-		return 0, fmt.Errorf("failed to open chat log: %w", err)
+		return nil, fmt.Errorf("failed to open chat log: %w", err)
 	}
 	defer file.Close()
 
 	// Get file size for progress tracking
 	fileInfo, err := file.Stat()
 	if err != nil {
-		return 0, fmt.Errorf("failed to get file info: %w", err)
+		return nil, fmt.Errorf("failed to get file info: %w", err)
 	}
 	totalSize := float64(fileInfo.Size())
 
 	// Seek to the offset
 	if _, err := file.Seek(offset, 0); err != nil {
-		return 0, fmt.Errorf("failed to seek to offset: %w", err)
+		return nil, fmt.Errorf("failed to seek to offset: %w", err)
 	}
 
 	if logger != nil {
@@ -216,14 +267,16 @@ func (db *EntropyDB) ProcessChatLogFromOffset(logPath string, offset int64, prog
 	}
 
 	scanner := bufio.NewScanner(file)
-	count := 0
+	var added []GlobalEntry
 	bytesRead := float64(offset)
 	lineNum := 0
 
 	for scanner.Scan() {
 		lineNum++
+		db.linesParsed++
 		line := scanner.Text()
 		bytesRead += float64(len(line) + 1) // +1 for newline
+		db.recordActivity(line)
 
 		if progressChan != nil {
 			select {
@@ -236,11 +289,15 @@ func (db *EntropyDB) ProcessChatLogFromOffset(logPath string, offset int64, prog
 
 		entry, err := ParseChatLine(line)
 		if err != nil {
+			db.parseErrors++
 			if logger != nil {
 				logger.Error("Error parsing line %d: %v\nLine content: %s", lineNum, err, line)
 			}
 			continue
 		}
+		if entry != nil && db.captureUniverse {
+			db.addUniverseGlobal(*entry)
+		}
 		if entry != nil {
 			if logger != nil {
 				logger.Info("Line %d - Found global: Type=%s, Player=%s, Team=%s, Target=%s, Value=%.2f",
@@ -268,21 +325,20 @@ func (db *EntropyDB) ProcessChatLogFromOffset(logPath string, offset int64, prog
 			}
 
 			if shouldInclude {
-				db.Globals = append(db.Globals, *entry)
-				count++
+				added = append(added, db.addGlobal(*entry))
 				if logger != nil {
-					logger.Info("Added global from line %d (total: %d)", lineNum, count)
+					logger.Info("Added global from line %d (total: %d)", lineNum, len(added))
 				}
 			}
 		}
 	}
 
 	if err := scanner.Err(); err != nil {
-		return count, fmt.Errorf("error reading chat log: %w", err)
+		return added, fmt.Errorf("error reading chat log: %w", err)
 	}
 
-	if logger != nil && count > 0 {
-		logger.Debug("Finished processing chat log from offset. Added %d new globals.", count)
+	if logger != nil && len(added) > 0 {
+		logger.Debug("Finished processing chat log from offset. Added %d new globals.", len(added))
 
 		// Log all stored globals for debugging
 		logger.Info("Stored globals (%d):", len(db.Globals))
@@ -294,30 +350,37 @@ func (db *EntropyDB) ProcessChatLogFromOffset(logPath string, offset int64, prog
 
 	db.LastProcessedSize = fileInfo.Size()
 	db.LastProcessed = time.Now()
-	return count, nil
+	db.dirty = true
+	return added, nil
+}
+
+// ParseCounts returns the number of chat log lines read and of lines that failed to parse
+// since the database was loaded
+func (db *EntropyDB) ParseCounts() (lines, errors int64) {
+	return db.linesParsed, db.parseErrors
 }
 
-// ProcessChatLog reads a chat log file and extracts all global messages
-// Only processes globals relevant to the player if playerName is specified
-func (db *EntropyDB) ProcessChatLog(logPath string, progressChan chan<- float64, logger *logger.Logger) (int, error) {
+// ProcessChatLog reads a chat log file and extracts all global messages, returning the globals
+// it added. Only processes globals relevant to the player if playerName is specified
+func (db *EntropyDB) ProcessChatLog(logPath string, progressChan chan<- float64, logger *logger.Logger) ([]GlobalEntry, error) {
 	if db == nil {
-		return 0, fmt.Errorf("database is nil")
+		return nil, fmt.Errorf("database is nil")
 	}
 
 	if _, err := os.Stat(logPath); os.IsNotExist(err) {
-		return 0, fmt.Errorf("chat log file does not exist: %s", logPath)
+		return nil, fmt.Errorf("chat log file does not exist: %s", logPath)
 	}
 
 	file, err := os.Open(logPath)
 	if err != nil {
-		return 0, fmt.Errorf("failed to open chat log: %w", err)
+		return nil, fmt.Errorf("failed to open chat log: %w", err)
 	}
 	defer file.Close()
 
 	// Get file size for progress tracking
 	fileInfo, err := file.Stat()
 	if err != nil {
-		return 0, fmt.Errorf("failed to get file info: %w", err)
+		return nil, fmt.Errorf("failed to get file info: %w", err)
 	}
 	totalSize := float64(fileInfo.Size())
 
@@ -327,14 +390,16 @@ func (db *EntropyDB) ProcessChatLog(logPath string, progressChan chan<- float64,
 	}
 
 	scanner := bufio.NewScanner(file)
-	count := 0
+	var added []GlobalEntry
 	bytesRead := float64(0)
 	lineNum := 0
 
 	for scanner.Scan() {
 		lineNum++
+		db.linesParsed++
 		line := scanner.Text()
 		bytesRead += float64(len(line) + 1) // +1 for newline
+		db.recordActivity(line)
 
 		if progressChan != nil {
 			select {
@@ -346,11 +411,15 @@ func (db *EntropyDB) ProcessChatLog(logPath string, progressChan chan<- float64,
 		}
 		entry, err := ParseChatLine(line)
 		if err != nil {
+			db.parseErrors++
 			if logger != nil {
 				logger.Error("Error parsing line %d: %v\nLine content: %s", lineNum, err, line)
 			}
 			continue
 		}
+		if entry != nil && db.captureUniverse {
+			db.addUniverseGlobal(*entry)
+		}
 
 		if entry != nil {
 			if logger != nil {
@@ -381,20 +450,19 @@ func (db *EntropyDB) ProcessChatLog(logPath string, progressChan chan<- float64,
 			}
 
 			if shouldInclude {
-				db.Globals = append(db.Globals, *entry)
-				count++
+				added = append(added, db.addGlobal(*entry))
 				if logger != nil {
-					logger.Info("Added global from line %d (total: %d)", lineNum, count)
+					logger.Info("Added global from line %d (total: %d)", lineNum, len(added))
 				}
 			}
 		}
 	}
 	if err := scanner.Err(); err != nil {
-		return count, fmt.Errorf("error reading chat log: %w", err)
+		return added, fmt.Errorf("error reading chat log: %w", err)
 	}
 
 	if logger != nil {
-		logger.Debug("Finished processing chat log. Found %d globals.", count)
+		logger.Debug("Finished processing chat log. Found %d globals.", len(added))
 
 		// Log all stored globals for debugging
 		logger.Debug("Stored globals (%d):", len(db.Globals))
@@ -406,7 +474,8 @@ func (db *EntropyDB) ProcessChatLog(logPath string, progressChan chan<- float64,
 
 	db.LastProcessed = time.Now()
 	db.LastProcessedSize = fileInfo.Size()
-	return count, nil
+	db.dirty = true
+	return added, nil
 }
 
 // GetHofEntries returns all HoF entries, ordered by timestamp (newest first)
@@ -461,21 +530,16 @@ func (db *EntropyDB) GetEntriesByValue(minValue float64) []GlobalEntry {
 
 // UpdateGlobalLocation updates the location for a specific global entry
 func (db *EntropyDB) UpdateGlobalLocation(entry *GlobalEntry) error {
-	// Find the entry in the database based on its timestamp and raw message
-	for i := range db.Globals {
-		// Match based on timestamp and raw message to ensure we find the exact entry
-		if db.Globals[i].Timestamp.Equal(entry.Timestamp) && db.Globals[i].RawMessage == entry.RawMessage {
-			// Update the location
-			db.Globals[i].Location = entry.Location
-
-			// Mark the database as dirty so it gets saved
-			db.dirty = true
-
-			// No need to continue searching
-			break
-		}
+	i, ok := db.FindGlobal(entry.ID)
+	if !ok {
+		return ErrGlobalNotFound
 	}
 
+	db.Globals[i].Location = entry.Location
+
+	// Mark the database as dirty so it gets saved
+	db.dirty = true
+
 	// We don't need to save the database here as that will be handled by the
 	// regular save mechanism in the data processor service
 	return nil
@@ -299,14 +299,14 @@ func TestTeamNameFiltering(t *testing.T) {
 			}
 
 			db := NewEntropyDB(tt.playerName, tt.teamName)
-			count, err := db.ProcessChatLog(tmpFile, nil, nil)
+			added, err := db.ProcessChatLog(tmpFile, nil, nil)
 			if err != nil {
 				t.Errorf("ProcessChatLog failed: %v", err)
 				return
 			}
 
-			if count != tt.wantCount {
-				t.Errorf("Expected %d entries, got %d", tt.wantCount, count)
+			if len(added) != tt.wantCount {
+				t.Errorf("Expected %d entries, got %d", tt.wantCount, len(added))
 			}
 
 			// Verify players and teams in results
@@ -481,14 +481,20 @@ func TestProcessChatLogFromOffsetMethod(t *testing.T) {
 			}
 
 			db := NewEntropyDB(tt.playerName, tt.teamName)
-			count, err := db.ProcessChatLogFromOffset(tmpFile, 0, nil, nil)
+			added, err := db.ProcessChatLogFromOffset(tmpFile, 0, nil, nil)
 			if err != nil {
 				t.Errorf("ProcessChatLogFromOffset failed: %v", err)
 				return
 			}
 
-			if count != tt.wantCount {
-				t.Errorf("Expected %d entries, got %d", tt.wantCount, count)
+			if len(added) != tt.wantCount {
+				t.Errorf("Expected %d entries, got %d", tt.wantCount, len(added))
+			}
+			// The returned globals are the stored ones, with their IDs
+			for i, entry := range added {
+				if entry.ID == "" || entry.ID != db.Globals[i].ID {
+					t.Errorf("added[%d].ID = %q, want the stored ID %q", i, entry.ID, db.Globals[i].ID)
+				}
 			}
 
 			// Verify players and teams in results
@@ -566,22 +572,22 @@ func TestConsistentFiltering(t *testing.T) {
 
 			// Process with both methods
 			db1 := NewEntropyDB(tt.playerName, tt.teamName)
-			count1, err1 := db1.ProcessChatLog(tmpFile, nil, nil)
+			added1, err1 := db1.ProcessChatLog(tmpFile, nil, nil)
 			if err1 != nil {
 				t.Errorf("ProcessChatLog failed: %v", err1)
 				return
 			}
 
 			db2 := NewEntropyDB(tt.playerName, tt.teamName)
-			count2, err2 := db2.ProcessChatLogFromOffset(tmpFile, 0, nil, nil)
+			added2, err2 := db2.ProcessChatLogFromOffset(tmpFile, 0, nil, nil)
 			if err2 != nil {
 				t.Errorf("ProcessChatLogFromOffset failed: %v", err2)
 				return
 			}
 
 			// Compare results
-			if count1 != count2 {
-				t.Errorf("Different counts: ProcessChatLog=%d, ProcessChatLogFromOffset=%d", count1, count2)
+			if len(added1) != len(added2) {
+				t.Errorf("Different counts: ProcessChatLog=%d, ProcessChatLogFromOffset=%d", len(added1), len(added2))
 			}
 
 			if len(db1.Globals) != len(db2.Globals) {
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"eu-clams/internal/model"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TeamMember summarizes the globals a member pushed to the hub
+type TeamMember struct {
+	Name       string    `json:"name"`
+	Globals    int       `json:"globals"`
+	Hofs       int       `json:"hofs"`
+	TotalValue float64   `json:"total_value"`
+	LastGlobal time.Time `json:"last_global"`
+}
+
+// MergeTeamGlobals adds globals pushed by a team member to the Team partition and returns
+// the ones that were new. Globals are recognized by their ID, derived here from the timestamp
+// and raw message whatever ID the member sent, so a global pushed twice or by two members who
+// saw it is stored once.
+func (db *EntropyDB) MergeTeamGlobals(member string, entries []GlobalEntry) []GlobalEntry {
+	if db.teamIDs == nil {
+		db.teamIDs = make(map[string]bool, len(db.Team))
+		for _, g := range db.Team {
+			db.teamIDs[g.ID] = true
+		}
+	}
+
+	var added []GlobalEntry
+	for _, entry := range entries {
+		entry.ID = globalID(entry.Timestamp, entry.RawMessage)
+		if db.teamIDs[entry.ID] {
+			continue
+		}
+		entry.Member = member
+		db.teamIDs[entry.ID] = true
+		db.Team = append(db.Team, entry)
+		added = append(added, entry)
+	}
+	if len(added) > 0 {
+		db.dirty = true
+	}
+	return added
+}
+
+// GetTeamGlobals returns the globals pushed by the team, newest first. An empty member
+// returns everyone's; a positive limit keeps only the newest.
+func (db *EntropyDB) GetTeamGlobals(member string, limit int) []GlobalEntry {
+	results := []GlobalEntry{}
+	for _, entry := range db.Team {
+		if member == "" || strings.EqualFold(entry.Member, member) {
+			results = append(results, entry)
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Timestamp.After(results[j].Timestamp)
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// GetTeamMembers summarizes the globals of every member, highest total value first
+func (db *EntropyDB) GetTeamMembers() []TeamMember {
+	byName := make(map[string]*TeamMember)
+	for _, entry := range db.Team {
+		m, ok := byName[entry.Member]
+		if !ok {
+			m = &TeamMember{Name: entry.Member}
+			byName[entry.Member] = m
+		}
+		m.Globals++
+		if entry.IsHof {
+			m.Hofs++
+		}
+		m.TotalValue += entry.Value
+		if entry.Timestamp.After(m.LastGlobal) {
+			m.LastGlobal = entry.Timestamp
+		}
+	}
+
+	members := make([]TeamMember, 0, len(byName))
+	for _, m := range byName {
+		members = append(members, *m)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if members[i].TotalValue != members[j].TotalValue {
+			return members[i].TotalValue > members[j].TotalValue
+		}
+		return members[i].Name < members[j].Name
+	})
+	return members
+}
+
+// GetTeamLeaderboards ranks the team's globals per day, week or month, newest period first.
+// Players are ranked by the member who pushed their globals.
+func (db *EntropyDB) GetTeamLeaderboards(interval string, limit int) ([]model.Leaderboard, error) {
+	entries := make([]model.GlobalEntry, len(db.Team))
+	for i, entry := range db.Team {
+		entries[i] = toModelEntry(entry)
+		entries[i].PlayerName = entry.Member
+	}
+	return model.GenerateLeaderboards(entries, interval, limit)
+}
+
+// TeamSize returns the number of globals pushed by the team
+func (db *EntropyDB) TeamSize() int {
+	return len(db.Team)
+}
@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMergeTeamGlobals(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2025, 5, 16, 10, 0, 0, 0, time.UTC)
+	atrox := GlobalEntry{Timestamp: start, Type: "kill", PlayerName: "Jane Doe", Target: "Atrox", Value: 100,
+		RawMessage: "2025-05-16 10:00:00 [Globals] [] Jane Doe killed a creature (Atrox) with a value of 100 PED"}
+	hof := GlobalEntry{Timestamp: start.Add(time.Hour), Type: "craft", PlayerName: "John Roe", Target: "Explosive Projectiles", Value: 1500, IsHof: true,
+		RawMessage: "2025-05-16 11:00:00 [Globals] [] John Roe constructed an item (Explosive Projectiles) worth 1500 PED! A record has been added to the Hall of Fame!"}
+
+	db := NewEntropyDB("Hub Owner", "")
+	if added := db.MergeTeamGlobals("jane", []GlobalEntry{atrox}); len(added) != 1 || added[0].Member != "jane" || added[0].ID == "" {
+		t.Fatalf("MergeTeamGlobals() = %+v, want Atrox attributed to jane with an ID", added)
+	}
+	// John also saw Jane's global in his chat log; only his own is new
+	if added := db.MergeTeamGlobals("john", []GlobalEntry{atrox, hof}); len(added) != 1 || added[0].Target != "Explosive Projectiles" {
+		t.Fatalf("MergeTeamGlobals() = %+v, want only the HoF", added)
+	}
+	// The ID is the hub's own: a member cannot store a global twice by sending another
+	forged := atrox
+	forged.ID = "forged"
+	if added := db.MergeTeamGlobals("john", []GlobalEntry{forged}); len(added) != 0 {
+		t.Fatalf("MergeTeamGlobals() = %+v, want Atrox recognized despite its forged ID", added)
+	}
+	if len(db.Team) != 2 || db.Team[0].Member != "jane" || len(db.Globals) != 0 {
+		t.Fatalf("Team = %+v, Globals = %d; want two team globals and no personal ones", db.Team, len(db.Globals))
+	}
+
+	if globals := db.GetTeamGlobals("", 0); len(globals) != 2 || globals[0].Member != "john" {
+		t.Errorf("GetTeamGlobals() = %+v, want newest first", globals)
+	}
+	if globals := db.GetTeamGlobals("JANE", 0); len(globals) != 1 || globals[0].Target != "Atrox" {
+		t.Errorf("GetTeamGlobals(JANE) = %+v, want Jane's global", globals)
+	}
+
+	members := db.GetTeamMembers()
+	if len(members) != 2 || members[0].Name != "john" || members[0].Hofs != 1 || members[1].TotalValue != 100 || !members[1].LastGlobal.Equal(start) {
+		t.Errorf("GetTeamMembers() = %+v", members)
+	}
+
+	boards, err := db.GetTeamLeaderboards("day", 5)
+	if err != nil || len(boards) != 1 || len(boards[0].Players) != 2 || boards[0].Players[0].Name != "john" {
+		t.Errorf("GetTeamLeaderboards() = %+v, %v; want members ranked", boards, err)
+	}
+}
+
+func TestMergeTeamGlobalsConcurrent(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "db.yaml")
+	chatLog := filepath.Join(dir, "chat.log")
+	start := time.Date(2025, 5, 16, 10, 0, 0, 0, time.UTC)
+	if err := os.WriteFile(chatLog, nil, 0644); err != nil {
+		t.Fatalf("Failed to create chat log: %v", err)
+	}
+
+	// Members push while the hub player's watcher adds their own globals, reads and saves,
+	// every goroutine holding the database lock as the web server and the watcher do
+	db := NewEntropyDB("Hub Owner", "")
+	var wg sync.WaitGroup
+	for m := 0; m < 4; m++ {
+		wg.Add(1)
+		go func(m int) {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				at := start.Add(time.Duration(i) * time.Minute)
+				raw := fmt.Sprintf("%s [Globals] [] Member %d killed a creature (Atrox) with a value of %d PED", at.Format("2006-01-02 15:04:05"), m, 50+i)
+				db.Lock()
+				db.MergeTeamGlobals(fmt.Sprintf("member%d", m), []GlobalEntry{{Timestamp: at, Type: "kill", Target: "Atrox", Value: float64(50 + i), RawMessage: raw}})
+				if err := db.SaveChanges(path, nil); err != nil {
+					t.Errorf("SaveChanges: %v", err)
+				}
+				db.Unlock()
+				db.RLock()
+				db.GetTeamMembers()
+				db.RUnlock()
+			}
+		}(m)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		f, err := os.OpenFile(chatLog, os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			t.Errorf("Failed to open chat log: %v", err)
+			return
+		}
+		defer f.Close()
+		for i := 0; i < 25; i++ {
+			at := start.Add(time.Duration(i) * time.Minute)
+			fmt.Fprintf(f, "%s [Globals] [] Hub Owner killed a creature (Atrox) with a value of %d PED\n", at.Format("2006-01-02 15:04:05"), 60+i)
+			db.Lock()
+			if _, err := db.ProcessChatLogFromOffset(chatLog, db.LastProcessedSize, nil, nil); err != nil {
+				t.Errorf("ProcessChatLogFromOffset: %v", err)
+			}
+			if err := db.SaveChanges(path, nil); err != nil {
+				t.Errorf("SaveChanges: %v", err)
+			}
+			db.Unlock()
+		}
+	}()
+	wg.Wait()
+
+	if err := db.SaveChanges(path, nil); err != nil {
+		t.Fatalf("SaveChanges: %v", err)
+	}
+	loaded, err := LoadDatabase(path, nil)
+	if err != nil {
+		t.Fatalf("LoadDatabase: %v", err)
+	}
+	if db.TeamSize() != 100 || loaded.TeamSize() != 100 {
+		t.Errorf("TeamSize = %d, saved %d; want all 100 pushes", db.TeamSize(), loaded.TeamSize())
+	}
+	if len(db.Globals) != 25 || len(loaded.Globals) != 25 {
+		t.Errorf("Globals = %d, saved %d; want all 25 of the watcher's", len(db.Globals), len(loaded.Globals))
+	}
+}
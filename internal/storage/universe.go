@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"bufio"
+	"eu-clams/internal/model"
+	"fmt"
+	"os"
+)
+
+// SetCaptureUniverse sets whether everyone's globals are stored in the Universe partition.
+// Personal statistics only ever use Globals.
+func (db *EntropyDB) SetCaptureUniverse(enabled bool) {
+	db.captureUniverse = enabled
+}
+
+// CapturesUniverse returns whether everyone's globals are stored
+func (db *EntropyDB) CapturesUniverse() bool {
+	return db.captureUniverse
+}
+
+// addUniverseGlobal appends a global to the Universe partition with an ID unique within it.
+// A global that is also stored in Globals has the same ID in both.
+func (db *EntropyDB) addUniverseGlobal(entry GlobalEntry) {
+	if db.universeIDs == nil {
+		db.universeIDs = make(map[string]bool, len(db.Universe))
+		for _, g := range db.Universe {
+			db.universeIDs[g.ID] = true
+		}
+	}
+
+	assignID(&entry, db.universeIDs)
+	db.Universe = append(db.Universe, entry)
+	db.universeDirty = true
+}
+
+// RebuildUniverse replaces the Universe partition with every global in the whole chat log,
+// for example to fill it after universe capture was turned on
+func (db *EntropyDB) RebuildUniverse(logPath string) (int, error) {
+	file, err := os.Open(logPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open chat log: %w", err)
+	}
+	defer file.Close()
+
+	var universe []GlobalEntry
+	ids := make(map[string]bool)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		entry, err := ParseChatLine(scanner.Text())
+		if err != nil || entry == nil {
+			continue
+		}
+		assignID(entry, ids)
+		universe = append(universe, *entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("error reading chat log: %w", err)
+	}
+
+	db.Universe = universe
+	db.universeIDs = ids
+	db.universeDirty = true
+	return len(universe), nil
+}
+
+// universeEntries returns everyone's stored globals converted for the model package
+func (db *EntropyDB) universeEntries() []model.GlobalEntry {
+	entries := make([]model.GlobalEntry, len(db.Universe))
+	for i, entry := range db.Universe {
+		entries[i] = toModelEntry(entry)
+	}
+	return entries
+}
+
+// GetLeaderboards ranks everyone's stored globals per day, week or month, newest period first
+func (db *EntropyDB) GetLeaderboards(interval string, limit int) ([]model.Leaderboard, error) {
+	return model.GenerateLeaderboards(db.universeEntries(), interval, limit)
+}
+
+// GetHeatmap counts globals per weekday and hour of day. scope selects everyone's captured
+// globals ("universe", the default) or only the player's own ("personal").
+func (db *EntropyDB) GetHeatmap(scope string, filter model.HeatmapFilter) (model.Heatmap, error) {
+	switch scope {
+	case "", "universe":
+		return model.GenerateHeatmap(db.universeEntries(), filter), nil
+	case "personal":
+		return model.GenerateHeatmap(db.modelEntries(), filter), nil
+	default:
+		return model.Heatmap{}, fmt.Errorf("invalid scope %q: must be universe or personal", scope)
+	}
+}
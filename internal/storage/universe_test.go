@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUniverseCapture(t *testing.T) {
+	t.Parallel()
+
+	lines := []string{
+		"2025-05-16 10:00:00 [Globals] [] Test Player killed a creature (Atrox) with a value of 50 PED",
+		"2025-05-16 10:05:00 [Globals] [] Other Player killed a creature (Daikiba) with a value of 90 PED",
+		"2025-05-16 10:10:00 [Globals] [] Team \"Hunters\" killed a creature (Atrox) with a value of 300 PED",
+	}
+	path := filepath.Join(t.TempDir(), "chat.log")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatalf("failed to write chat log: %v", err)
+	}
+
+	db := NewEntropyDB("Test Player", "")
+	db.SetCaptureUniverse(true)
+	if _, err := db.ProcessChatLog(path, nil, nil); err != nil {
+		t.Fatalf("ProcessChatLog() error = %v", err)
+	}
+
+	// Personal globals keep only our own entries
+	if len(db.Globals) != 1 || len(db.Universe) != 3 {
+		t.Fatalf("Globals = %d, Universe = %d, want 1 and 3", len(db.Globals), len(db.Universe))
+	}
+	if db.Universe[0].ID != db.Globals[0].ID {
+		t.Errorf("Universe ID %q differs from personal ID %q", db.Universe[0].ID, db.Globals[0].ID)
+	}
+	if stats := db.GetStatsData(); stats.TotalGlobals != 1 {
+		t.Errorf("GetStatsData() counted %d globals, want only our own", stats.TotalGlobals)
+	}
+
+	boards, err := db.GetLeaderboards("day", 5)
+	if err != nil || len(boards) != 1 || boards[0].Players[0].Name != "Other Player" || boards[0].Teams[0].Name != "Hunters" {
+		t.Errorf("GetLeaderboards() = %+v, %v", boards, err)
+	}
+
+	// Without capture, a rebuild fills the partition from the whole log
+	other := NewEntropyDB("Test Player", "")
+	if count, err := other.RebuildUniverse(path); err != nil || count != 3 {
+		t.Errorf("RebuildUniverse() = %d, %v, want 3", count, err)
+	}
+}
+
+func TestUniverseFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "db.yaml")
+	db := NewEntropyDB("Test Player", "")
+	db.addGlobal(GlobalEntry{ID: "a", Type: "kill", PlayerName: "Test Player", Target: "Atrox", Value: 50})
+	db.addUniverseGlobal(GlobalEntry{ID: "b", Type: "kill", PlayerName: "Other Player", Target: "Daikiba", Value: 90})
+	if err := db.SaveDatabase(path, nil); err != nil {
+		t.Fatalf("SaveDatabase() error = %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "Other Player") {
+		t.Error("database file contains the universe")
+	}
+	loaded, err := LoadDatabase(path, nil)
+	if err != nil || len(loaded.Universe) != 1 || len(loaded.Globals) != 1 {
+		t.Fatalf("LoadDatabase() = %d universe globals, %v; want 1", len(loaded.Universe), err)
+	}
+
+	// Nothing changed, so nothing is written
+	os.Remove(path)
+	os.Remove(UniversePath(path))
+	if err := loaded.SaveChanges(path, nil); err != nil {
+		t.Fatalf("SaveChanges() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("SaveChanges() wrote an unchanged database: %v", err)
+	}
+	loaded.addGlobal(GlobalEntry{ID: "c", Type: "craft", PlayerName: "Test Player", Target: "Explosive Projectiles", Value: 60})
+	if err := loaded.SaveChanges(path, nil); err != nil {
+		t.Fatalf("SaveChanges() error = %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("SaveChanges() did not write the changed database: %v", err)
+	}
+	if _, err := os.Stat(UniversePath(path)); !os.IsNotExist(err) {
+		t.Errorf("SaveChanges() rewrote the unchanged universe: %v", err)
+	}
+
+	// Databases from before the universe file move it out on the next save
+	legacy := filepath.Join(dir, "legacy.yaml")
+	os.WriteFile(legacy, []byte("globals: []\nuniverse:\n  - id: b\n    player: Other Player\n"), 0644)
+	old, err := LoadDatabase(legacy, nil)
+	if err != nil || len(old.Universe) != 1 {
+		t.Fatalf("LoadDatabase(legacy) = %d universe globals, %v; want 1", len(old.Universe), err)
+	}
+	if err := old.SaveChanges(legacy, nil); err != nil {
+		t.Fatalf("SaveChanges(legacy) error = %v", err)
+	}
+	if data, _ := os.ReadFile(legacy); strings.Contains(string(data), "universe") {
+		t.Error("legacy database still holds the universe")
+	}
+	if _, err := os.Stat(UniversePath(legacy)); err != nil {
+		t.Errorf("legacy universe was not moved to its own file: %v", err)
+	}
+}
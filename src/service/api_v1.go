@@ -0,0 +1,300 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"eu-clams/internal/model"
+	"eu-clams/internal/storage"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Page sizes of the versioned API
+const (
+	apiDefaultLimit = 50
+	apiMaxLimit     = 1000
+)
+
+// apiResponse is the envelope of every successful versioned API response
+type apiResponse struct {
+	Data       interface{} `json:"data"`
+	Total      *int        `json:"total,omitempty"`       // Number of items on all pages of a paged list
+	NextCursor string      `json:"next_cursor,omitempty"` // Pass as cursor to get the next page
+}
+
+// apiError is the envelope of every versioned API error
+type apiError struct {
+	Error apiErrorBody `json:"error"`
+}
+
+// apiErrorBody describes an API error
+type apiErrorBody struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"` // Machine-readable, e.g. "invalid_parameter"
+	Message string `json:"message"`
+}
+
+// registerAPIv1 adds the versioned API routes. The unversioned /api routes stay for main.js.
+func (s *WebService) registerAPIv1(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/v1/globals", s.handleAPIGlobals)
+	mux.HandleFunc("GET /api/v1/globals/{id}", s.handleAPIGlobal)
+	mux.HandleFunc("GET /api/v1/hofs", s.handleAPIHofs)
+	mux.HandleFunc("GET /api/v1/stats", s.handleAPIStats)
+	mux.HandleFunc("GET /api/v1/stats/targets", s.handleAPITargetStats)
+	mux.HandleFunc("GET /api/v1/stats/locations", s.handleAPILocationStats)
+	mux.HandleFunc("GET /api/v1/sessions", s.handleAPISessions)
+	mux.HandleFunc("GET /api/v1/sessions/{id}", s.handleAPISession)
+	mux.HandleFunc("/api/v1/", s.handleAPINotFound)
+}
+
+// handleAPIGlobals lists the player's globals with filters, sorting and cursor pagination
+func (s *WebService) handleAPIGlobals(w http.ResponseWriter, r *http.Request) {
+	s.writeGlobalPage(w, r, "")
+}
+
+// handleAPIHofs lists the player's HoFs like handleAPIGlobals
+func (s *WebService) handleAPIHofs(w http.ResponseWriter, r *http.Request) {
+	s.writeGlobalPage(w, r, storage.TierHof)
+}
+
+// writeGlobalPage answers a global query, optionally restricted to a tier
+func (s *WebService) writeGlobalPage(w http.ResponseWriter, r *http.Request, tier string) {
+	q, err := globalQueryFromRequest(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	}
+	if tier != "" {
+		q.Tier = tier
+	}
+
+	s.db.RLock()
+	page, err := s.db.QueryGlobals(q)
+	s.db.RUnlock()
+	if errors.Is(err, storage.ErrInvalidCursor) {
+		writeAPIError(w, http.StatusBadRequest, "invalid_cursor", "cursor is invalid or was issued for another sort order")
+		return
+	}
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	}
+
+	globals := make([]model.GlobalEntryJSON, len(page.Globals))
+	for i, g := range page.Globals {
+		globals[i] = toGlobalEntryJSON(g)
+	}
+	writeAPIResponse(w, r, apiResponse{Data: globals, Total: &page.Total, NextCursor: page.NextCursor})
+}
+
+// globalQueryFromRequest reads the filters, sort order and page of a global listing. Globals
+// are listed newest first unless sort or order say otherwise.
+func globalQueryFromRequest(r *http.Request) (storage.GlobalQuery, error) {
+	query := r.URL.Query()
+	q := storage.GlobalQuery{
+		Type:     query.Get("type"),
+		Target:   query.Get("target"),
+		Location: query.Get("location"),
+		Tier:     query.Get("tier"),
+		Sort:     query.Get("sort"),
+		Cursor:   query.Get("cursor"),
+	}
+
+	var err error
+	if q.From, q.To, err = model.ParseTimeRange(query.Get("from"), query.Get("to")); err != nil {
+		return q, err
+	}
+	if q.MinValue, err = floatParam(query.Get("min_value")); err != nil {
+		return q, fmt.Errorf("invalid min_value: %w", err)
+	}
+	if q.MaxValue, err = floatParam(query.Get("max_value")); err != nil {
+		return q, fmt.Errorf("invalid max_value: %w", err)
+	}
+	if q.MaxValue > 0 && q.MaxValue < q.MinValue {
+		return q, errors.New("max_value is below min_value")
+	}
+
+	switch order := query.Get("order"); order {
+	case "", "desc":
+		q.Descending = true
+	case "asc":
+	default:
+		return q, fmt.Errorf("invalid order %q: must be asc or desc", order)
+	}
+
+	q.Limit = apiDefaultLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 || l > apiMaxLimit {
+			return q, fmt.Errorf("invalid limit %q: must be between 1 and %d", limitStr, apiMaxLimit)
+		}
+		q.Limit = l
+	}
+	return q, nil
+}
+
+// floatParam parses an optional non-negative number
+func floatParam(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("%q is not a non-negative number", value)
+	}
+	return f, nil
+}
+
+// handleAPIGlobal returns a single global of the player
+func (s *WebService) handleAPIGlobal(w http.ResponseWriter, r *http.Request) {
+	s.db.RLock()
+	entry, ok := s.db.GetGlobal(r.PathValue("id"))
+	s.db.RUnlock()
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "not_found", storage.ErrGlobalNotFound.Error())
+		return
+	}
+	writeAPIResponse(w, r, apiResponse{Data: toGlobalEntryJSON(entry)})
+}
+
+// handleAPIStats returns the player's summary statistics
+func (s *WebService) handleAPIStats(w http.ResponseWriter, r *http.Request) {
+	s.db.RLock()
+	statsData := s.db.GetStatsData()
+	s.db.RUnlock()
+	writeAPIResponse(w, r, apiResponse{Data: model.ToStatsJSON(statsData)})
+}
+
+// handleAPITargetStats returns per-target statistics with the filters of /api/stats/targets
+func (s *WebService) handleAPITargetStats(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := model.TargetFilter{Type: query.Get("type"), Search: query.Get("q")}
+	if minStr := query.Get("min_count"); minStr != "" {
+		m, err := strconv.Atoi(minStr)
+		if err != nil || m < 0 {
+			writeAPIError(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("invalid min_count %q", minStr))
+			return
+		}
+		filter.MinCount = m
+	}
+
+	s.db.RLock()
+	targets := model.FilterTargetStats(s.db.GetStatsData().ByTarget, filter)
+	s.db.RUnlock()
+	if order := query.Get("order"); order != "" && order != "asc" && order != "desc" {
+		writeAPIError(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("invalid order %q: must be asc or desc", order))
+		return
+	}
+	if err := model.SortTargetStats(targets, query.Get("sort"), query.Get("order") != "asc"); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid_parameter", err.Error())
+		return
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		l, err := strconv.Atoi(limitStr)
+		if err != nil || l < 1 {
+			writeAPIError(w, http.StatusBadRequest, "invalid_parameter", fmt.Sprintf("invalid limit %q", limitStr))
+			return
+		}
+		if len(targets) > l {
+			targets = targets[:l]
+		}
+	}
+	writeAPIResponse(w, r, apiResponse{Data: targets})
+}
+
+// handleAPILocationStats returns per-location statistics
+func (s *WebService) handleAPILocationStats(w http.ResponseWriter, r *http.Request) {
+	s.db.RLock()
+	locations := s.db.GetStatsData().Locations
+	s.db.RUnlock()
+	if locations == nil {
+		locations = []model.LocationStats{}
+	}
+	writeAPIResponse(w, r, apiResponse{Data: locations})
+}
+
+// handleAPISessions returns the detected sessions, newest first
+func (s *WebService) handleAPISessions(w http.ResponseWriter, r *http.Request) {
+	s.db.RLock()
+	sessions := s.db.GetSessions()
+	s.db.RUnlock()
+	if sessions == nil {
+		sessions = []model.SessionSummary{}
+	}
+	writeAPIResponse(w, r, apiResponse{Data: sessions})
+}
+
+// handleAPISession returns a session with its globals
+func (s *WebService) handleAPISession(w http.ResponseWriter, r *http.Request) {
+	s.db.RLock()
+	detail, ok := s.db.GetSession(r.PathValue("id"))
+	s.db.RUnlock()
+	if !ok {
+		writeAPIError(w, http.StatusNotFound, "not_found", "session not found")
+		return
+	}
+	writeAPIResponse(w, r, apiResponse{Data: detail})
+}
+
+// handleAPINotFound answers requests to unknown versioned API routes. Every versioned route
+// is read-only, so other methods are reported as not allowed.
+func (s *WebService) handleAPINotFound(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		writeAPIError(w, http.StatusMethodNotAllowed, "method_not_allowed", r.Method+" is not allowed")
+		return
+	}
+	writeAPIError(w, http.StatusNotFound, "not_found", "no such endpoint: "+r.URL.Path)
+}
+
+// writeAPIResponse writes a versioned API response with an ETag of its body. A request whose
+// If-None-Match lists the ETag gets 304 Not Modified without a body.
+func writeAPIResponse(w http.ResponseWriter, r *http.Request, body apiResponse) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+	writeETagged(w, r, append(data, '\n'))
+}
+
+// writeETagged writes a JSON body with an ETag of its content, answering a request whose
+// If-None-Match lists the ETag with 304 Not Modified
+func writeETagged(w http.ResponseWriter, r *http.Request, data []byte) {
+	sum := sha256.Sum256(data)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+
+	// Clients may keep the response but must revalidate it
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("ETag", etag)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+}
+
+// etagMatches returns whether an If-None-Match header lists etag, comparing weakly
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// writeAPIError writes a versioned API error envelope
+func writeAPIError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(apiError{Error: apiErrorBody{Status: status, Code: code, Message: message}})
+}
@@ -0,0 +1,125 @@
+package service
+
+import (
+	"encoding/json"
+	"eu-clams/internal/logger"
+	"eu-clams/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestWebService returns a web service on a database with a few globals of the player
+func newTestWebService(t *testing.T) *WebService {
+	t.Helper()
+
+	start := time.Date(2025, 5, 16, 10, 0, 0, 0, time.UTC)
+	db := storage.NewEntropyDB("Test Player", "")
+	db.Globals = []storage.GlobalEntry{
+		{ID: "a", Timestamp: start, Type: "kill", PlayerName: "Test Player", Target: "Atrox", Value: 100},
+		{ID: "b", Timestamp: start.Add(time.Hour), Type: "kill", PlayerName: "Test Player", Target: "Daikiba", Value: 60},
+		{ID: "c", Timestamp: start.Add(2 * time.Hour), Type: "craft", PlayerName: "Test Player", Target: "Explosive Projectiles", Value: 1500, IsHof: true},
+	}
+	s := NewWebService(logger.New(), db, "Test Player", "", 0)
+	if err := s.loadTemplates(); err != nil {
+		t.Fatalf("loading templates: %v", err)
+	}
+	return s
+}
+
+// serve performs a request to localhost against the web service's routes
+func serve(s *WebService, method, target string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, nil)
+	req.Host = "localhost"
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	s.routes().ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAPIv1Globals(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+
+	rec := serve(s, http.MethodGet, "/api/v1/globals?type=kill&limit=1", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	var page struct {
+		Data       []map[string]interface{} `json:"data"`
+		Total      int                      `json:"total"`
+		NextCursor string                   `json:"next_cursor"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if len(page.Data) != 1 || page.Data[0]["id"] != "b" || page.Total != 2 || page.NextCursor == "" {
+		t.Fatalf("first page = %+v, want b of 2 with a cursor", page)
+	}
+
+	rec = serve(s, http.MethodGet, "/api/v1/globals?type=kill&limit=1&cursor="+page.NextCursor, nil)
+	page.NextCursor = ""
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil || len(page.Data) != 1 || page.Data[0]["id"] != "a" || page.NextCursor != "" {
+		t.Fatalf("second page = %+v (%v), want a as the last page", page, err)
+	}
+
+	// An unchanged response is not sent again
+	rec = serve(s, http.MethodGet, "/api/v1/hofs", nil)
+	etag := rec.Header().Get("ETag")
+	if rec.Code != http.StatusOK || etag == "" {
+		t.Fatalf("hofs status = %d, ETag %q, want 200 with an ETag", rec.Code, etag)
+	}
+	rec = serve(s, http.MethodGet, "/api/v1/hofs", http.Header{"If-None-Match": {`"other", ` + etag}})
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("revalidation status = %d with %d bytes, want 304 without a body", rec.Code, rec.Body.Len())
+	}
+}
+
+func TestAPIv1Errors(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+
+	tests := []struct {
+		method, target string
+		status         int
+		code           string
+	}{
+		{http.MethodGet, "/api/v1/globals?limit=0", http.StatusBadRequest, "invalid_parameter"},
+		{http.MethodGet, "/api/v1/globals?sort=player", http.StatusBadRequest, "invalid_parameter"},
+		{http.MethodGet, "/api/v1/globals?from=yesterday", http.StatusBadRequest, "invalid_parameter"},
+		{http.MethodGet, "/api/v1/globals?cursor=xyz", http.StatusBadRequest, "invalid_cursor"},
+		{http.MethodGet, "/api/v1/globals/missing", http.StatusNotFound, "not_found"},
+		{http.MethodGet, "/api/v1/nothing", http.StatusNotFound, "not_found"},
+		{http.MethodPost, "/api/v1/globals", http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, tt := range tests {
+		rec := serve(s, tt.method, tt.target, nil)
+		var body apiError
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Errorf("%s %s: decoding error envelope: %v", tt.method, tt.target, err)
+			continue
+		}
+		if rec.Code != tt.status || body.Error.Status != tt.status || body.Error.Code != tt.code || body.Error.Message == "" {
+			t.Errorf("%s %s = %d %+v, want %d %s", tt.method, tt.target, rec.Code, body.Error, tt.status, tt.code)
+		}
+	}
+}
+
+func TestAPIv1Stats(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+
+	rec := serve(s, http.MethodGet, "/api/v1/stats", nil)
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	if body.Data["total_globals"] != 3.0 || body.Data["total_hofs"] != 1.0 || body.Data["by_target"] == nil {
+		t.Errorf("stats = %v, want snake_case fields with 3 globals and 1 HoF", body.Data)
+	}
+}
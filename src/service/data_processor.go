@@ -1,8 +1,10 @@
 package service
 
 import (
+	"eu-clams/internal/analysis"
 	"eu-clams/internal/config"
 	"eu-clams/internal/logger"
+	"eu-clams/internal/model"
 	"eu-clams/internal/storage"
 	"fmt"
 	"os"
@@ -21,8 +23,9 @@ type DataProcessorService struct {
 	stopChan       chan struct{}
 	watchDelay     time.Duration
 	lastGlobal     time.Time
-	isImportMode   bool // Flag to indicate import-only mode, no screenshots
-	initialProcess bool // Flag to indicate if this is the initial processing of the chat log
+	isImportMode   bool                 // Flag to indicate import-only mode, no screenshots
+	initialProcess bool                 // Flag to indicate if this is the initial processing of the chat log
+	onAlert        func(message string) // Called for personal records, unlocked achievements and completed goals
 }
 
 // NewDataProcessorService creates a new DataProcessorService instance
@@ -41,39 +44,68 @@ func NewDataProcessorService(log *logger.Logger, cfg config.Config, chatLogPath
 	}
 }
 
+// DatabasePath returns the configured database file, with a relative path resolved against
+// the directory of the executable so that it does not depend on where the program starts
+func DatabasePath(cfg config.Config) string {
+	if filepath.IsAbs(cfg.DatabasePath) {
+		return cfg.DatabasePath
+	}
+	return filepath.Join(filepath.Dir(os.Args[0]), cfg.DatabasePath)
+}
+
 // Initialize initializes the service
 func (s *DataProcessorService) Initialize() error {
 	s.log.Info("DataProcessor service initializing...")
 
 	// Ensure database directory exists
-	dbPath := s.config.DatabasePath
-	if !filepath.IsAbs(dbPath) {
-		dbPath = filepath.Join(filepath.Dir(os.Args[0]), dbPath)
-		s.config.DatabasePath = dbPath
-	}
+	dbPath := DatabasePath(s.config)
+	s.config.DatabasePath = dbPath
 	dbDir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dbDir, 0755); err != nil {
 		return fmt.Errorf("failed to create database directory: %w", err)
 	}
 
-	// Load existing database or create new one
-	var err error
-	s.db, err = storage.LoadDatabase(dbPath, s.log)
-	if err != nil {
-		s.log.Error("Failed to load database: %v", err)
-		// Create a new database
-		s.log.Info("Creating new database")
-		s.db = storage.NewEntropyDB(s.config.PlayerName, s.config.TeamName)
+	// Load existing database or create new one, unless one is shared with the service
+	if s.db == nil {
+		var err error
+		s.db, err = storage.LoadDatabase(dbPath, s.log)
+		if err != nil {
+			s.log.Error("Failed to load database: %v", err)
+			// Create a new database
+			s.log.Info("Creating new database")
+			s.db = storage.NewEntropyDB(s.config.PlayerName, s.config.TeamName)
+		}
 	}
+	s.db.Lock()
+	defer s.db.Unlock()
 
 	// Update player/team names in database if needed
 	if s.config.PlayerName != "" && s.db.PlayerName != s.config.PlayerName {
 		s.log.Info("Updating player name in database from '%s' to '%s'", s.db.PlayerName, s.config.PlayerName)
 		s.db.PlayerName = s.config.PlayerName
+		s.db.MarkChanged()
 	}
 	if s.config.TeamName != "" && s.db.TeamName != s.config.TeamName {
 		s.log.Info("Updating team name in database from '%s' to '%s'", s.db.TeamName, s.config.TeamName)
 		s.db.TeamName = s.config.TeamName
+		s.db.MarkChanged()
+	}
+
+	// Flag personal records in databases from before they were tracked; a no-op otherwise
+	s.db.ComputeRecords()
+
+	s.db.SetSessionIdleGap(time.Duration(s.config.SessionIdleMinutes) * time.Minute)
+	s.db.SetCaptureUniverse(s.config.CaptureUniverse)
+
+	brackets := make([]model.ValueBracket, len(s.config.ValueBrackets))
+	for i, b := range s.config.ValueBrackets {
+		brackets[i] = model.ValueBracket{Name: b.Name, Min: b.Min}
+	}
+	if err := s.db.SetValueDistribution(s.config.HistogramEdges, brackets); err != nil {
+		s.log.Warn("Ignoring configured value distribution: %v", err)
+	}
+	if err := s.db.SetMarkups(ConfigMarkups(s.config)); err != nil {
+		s.log.Warn("Ignoring configured markups: %v", err)
 	}
 
 	return nil
@@ -113,6 +145,12 @@ func (s *DataProcessorService) Run() error {
 	return nil
 }
 
+// UseDatabase makes the service process the chat log into db, shared with a web server or
+// the GUI, instead of loading its own in Initialize
+func (s *DataProcessorService) UseDatabase(db *storage.EntropyDB) {
+	s.db = db
+}
+
 // SetProgressChannel sets the progress channel for reporting import progress
 func (s *DataProcessorService) SetProgressChannel(ch chan float64) {
 	s.progressChan = ch
@@ -147,18 +185,30 @@ func (s *DataProcessorService) processLogFile() error {
 		return fmt.Errorf("failed to get file info: %w", err)
 	}
 
-	// Store the current globals count before processing
-	oldGlobalsCount := len(s.db.Globals) // If we haven't processed this file before, process it from the beginning
+	// The web server and the GUI share the database: hold it for the whole tick, so that
+	// they never see half of it or change it underneath
+	s.db.Lock()
+	defer s.db.Unlock()
+
+	// Remember the open session to report sessions opened or closed by this run
+	openBefore, wasOpen := s.db.OpenSession()
+	linesBefore, errorsBefore := s.db.ParseCounts()
+	serviceMetrics.watcherLag.Set(float64(max(fileInfo.Size()-s.db.LastProcessedSize, 0)))
+
+	var newGlobals []storage.GlobalEntry
+	// If we haven't processed this file before, process it from the beginning
 	if s.db.LastProcessedSize == 0 {
 		// Set this flag to prevent taking screenshots for historical globals
 		s.isImportMode = true
 
 		s.log.Info("Processing entire chat log file: %s", s.chatLogPath)
-		count, err := s.db.ProcessChatLog(s.chatLogPath, s.progressChan, s.log)
+		added, err := s.db.ProcessChatLog(s.chatLogPath, s.progressChan, s.log)
 		if err != nil {
 			return fmt.Errorf("failed to process chat log: %w", err)
 		}
-		s.log.Info("Processed %d global entries", count)
+		newGlobals = added
+		s.log.Info("Processed %d global entries", len(added))
+		s.log.Info("Found %d personal records", s.db.ComputeRecords())
 
 		// After initial processing, reset the flag for future runs
 		if s.initialProcess {
@@ -168,29 +218,43 @@ func (s *DataProcessorService) processLogFile() error {
 	} else if fileInfo.Size() > s.db.LastProcessedSize {
 		// Process only new content
 		s.log.Debug("Processing new entries in chat log")
-		count, err := s.db.ProcessChatLogFromOffset(s.chatLogPath, s.db.LastProcessedSize, nil, s.log)
+		added, err := s.db.ProcessChatLogFromOffset(s.chatLogPath, s.db.LastProcessedSize, nil, s.log)
 		if err != nil {
 			return fmt.Errorf("failed to process new entries: %w", err)
 		}
-		if count > 0 {
-			s.log.Debug("Processed %d new global entries", count)
+		newGlobals = added
+		if len(newGlobals) > 0 {
+			s.log.Debug("Processed %d new global entries", len(newGlobals))
 
-			// Get the new globals that were added
-			newGlobals := s.db.Globals[oldGlobalsCount:]
+			// Flag the new globals that beat a previous best
+			records := s.db.DetectRecords(newGlobals)
 
 			// Only handle new globals with screenshots if not in initial processing mode
 			if !s.initialProcess {
 				s.HandleNewGlobals(newGlobals)
+				s.reportRecords(records)
 			}
 		}
 	}
 
-	// Save the database after processing
-	dbPath := s.config.DatabasePath
-	if !filepath.IsAbs(dbPath) {
-		dbPath = filepath.Join(filepath.Dir(os.Args[0]), dbPath)
+	s.observeProcessing(newGlobals, linesBefore, errorsBefore)
+
+	s.db.CloseIdleSession(analysis.WallClockNow())
+	if !s.isImportMode {
+		s.reportSessionChanges(openBefore, wasOpen)
 	}
-	return s.db.SaveDatabase(dbPath, s.log)
+
+	// Progress only changes with new globals; databases from before achievements existed
+	// have none stored yet and catch up on the first run
+	if len(newGlobals) > 0 || len(s.db.Achievements) == 0 {
+		unlocked, completed := s.db.UpdateProgress(analysis.WallClockNow())
+		if !s.isImportMode && !s.initialProcess {
+			s.reportProgress(unlocked, completed)
+		}
+	}
+
+	// Save the database after processing, if anything changed since the last tick
+	return s.db.SaveChanges(DatabasePath(s.config), s.log)
 }
 
 // watchLogFile continuously watches the chat log file for changes
@@ -242,3 +306,23 @@ func (s *DataProcessorService) ProcessLogOnly() error {
 	}
 	return nil
 }
+
+// observeProcessing updates the metrics after the chat log was processed into newGlobals
+func (s *DataProcessorService) observeProcessing(newGlobals []storage.GlobalEntry, linesBefore, errorsBefore int64) {
+	lines, errors := s.db.ParseCounts()
+	serviceMetrics.linesParsed.Add(float64(lines - linesBefore))
+	serviceMetrics.parseErrors.Add(float64(errors - errorsBefore))
+
+	// Seed the newest timestamp from the stored globals on the first tick
+	if serviceMetrics.lastGlobal.Value() == 0 {
+		for _, g := range s.db.Globals {
+			serviceMetrics.observeTimestamp(g.Timestamp)
+		}
+	}
+	serviceMetrics.observeGlobals(newGlobals)
+
+	// The file may have grown while it was processed
+	if info, err := os.Stat(s.chatLogPath); err == nil {
+		serviceMetrics.watcherLag.Set(float64(max(info.Size()-s.db.LastProcessedSize, 0)))
+	}
+}
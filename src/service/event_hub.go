@@ -0,0 +1,233 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"eu-clams/internal/model"
+	"eu-clams/internal/storage"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// eventBufferSize is the number of latest events kept for clients that resume
+const eventBufferSize = 500
+
+// eventFilter narrows the events a subscriber receives. Zero values match everything; the
+// value filters only apply to events about a global.
+type eventFilter struct {
+	Types      []string `json:"types,omitempty"`       // Event types, e.g. new_global
+	MinValue   float64  `json:"min_value,omitempty"`   // Globals of at least this PED
+	GlobalType string   `json:"global_type,omitempty"` // Globals of this type, e.g. kill
+	Target     string   `json:"target,omitempty"`      // Case-insensitive substring of the target
+	Player     string   `json:"player,omitempty"`      // Globals of this player or team member, case-insensitive
+}
+
+// matches returns whether an event passes the filter
+func (f eventFilter) matches(e *hubEvent) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, e.eventType) {
+		return false
+	}
+	g := e.global
+	if g == nil {
+		return true
+	}
+	return g.Value >= f.MinValue &&
+		(f.GlobalType == "" || strings.EqualFold(g.Type, f.GlobalType)) &&
+		(f.Target == "" || strings.Contains(strings.ToLower(g.Target), strings.ToLower(f.Target))) &&
+		(f.Player == "" || strings.EqualFold(g.PlayerName, f.Player))
+}
+
+// hubEvent is a numbered event kept for replay, or a protocol message for one subscriber
+// with a zero sequence number
+type hubEvent struct {
+	seq       uint64
+	eventType string
+	payload   []byte                 // The wsEvent as JSON
+	global    *model.GlobalEntryJSON // The global the event is about, if any
+}
+
+// eventSubscriber receives the events of a hub that pass its filter. The hub closes send
+// when the subscriber falls too far behind or the hub shuts down.
+type eventSubscriber struct {
+	send   chan *hubEvent
+	filter eventFilter
+	active bool // Whether events are sent; clients that must subscribe first start inactive
+}
+
+// eventHub numbers the events of a web service, keeps the latest for clients that resume
+// after a reconnect and hands them to the WebSocket and event stream subscribers
+type eventHub struct {
+	mu     sync.Mutex
+	stream string // Identifies the numbering, which starts over with a new hub; never changes
+	seq    uint64 // Sequence number of the latest event
+	buffer []*hubEvent
+	subs   map[*eventSubscriber]bool
+	closed bool
+}
+
+// newEventHub returns a hub with a new stream ID
+func newEventHub() *eventHub {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return &eventHub{stream: hex.EncodeToString(b), subs: make(map[*eventSubscriber]bool)}
+}
+
+// publish numbers an event, keeps it for replay and sends it to the matching subscribers.
+// It never blocks: subscribers whose queue is full are dropped, and reconnect and resume.
+func (h *eventHub) publish(eventType string, data interface{}) (uint64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	event := newWSEvent(eventType, data)
+	event.Seq = h.seq + 1
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+	h.seq++
+	e := &hubEvent{seq: h.seq, eventType: eventType, payload: payload, global: eventGlobal(event.Data)}
+	if len(h.buffer) == eventBufferSize {
+		h.buffer = append(h.buffer[:0], h.buffer[1:]...)
+	}
+	h.buffer = append(h.buffer, e)
+
+	for sub := range h.subs {
+		if sub.active && sub.filter.matches(e) {
+			h.queue(sub, e)
+		}
+	}
+	return e.seq, nil
+}
+
+// queue sends a message to a subscriber without blocking, dropping the subscriber if its
+// queue is full; the caller holds the lock
+func (h *eventHub) queue(sub *eventSubscriber, e *hubEvent) {
+	if !h.subs[sub] {
+		return
+	}
+	select {
+	case sub.send <- e:
+	default:
+		h.drop(sub)
+	}
+}
+
+// eventGlobal returns the global an event's data is about, if any
+func eventGlobal(data interface{}) *model.GlobalEntryJSON {
+	switch d := data.(type) {
+	case model.GlobalEntryJSON:
+		return &d
+	case storage.PersonalRecord:
+		g := toGlobalEntryJSON(d.Global)
+		return &g
+	}
+	return nil
+}
+
+// join adds a subscriber with room for a full replay in its queue
+func (h *eventHub) join(active bool, filter eventFilter) *eventSubscriber {
+	sub := &eventSubscriber{send: make(chan *hubEvent, eventBufferSize+64), filter: filter, active: active}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		close(sub.send)
+	} else {
+		h.subs[sub] = true
+	}
+	return sub
+}
+
+// leave removes a subscriber
+func (h *eventHub) leave(sub *eventSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.drop(sub)
+}
+
+// drop removes a subscriber and closes its queue; the caller holds the lock
+func (h *eventHub) drop(sub *eventSubscriber) {
+	if h.subs[sub] {
+		delete(h.subs, sub)
+		close(sub.send)
+	}
+}
+
+// subscribe changes the filter of a subscriber and starts sending it events. With a sequence
+// number of the same stream, the buffered events after it that pass the filter are sent
+// first; missed reports whether events after it are no longer buffered. The reply, if any, is
+// queued before the replayed events.
+func (h *eventHub) subscribe(sub *eventSubscriber, filter eventFilter, stream string, after uint64, reply func(seq uint64, replayed int, missed bool) []byte) (replayed int, missed bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.subs[sub] {
+		return 0, false
+	}
+	sub.filter = filter
+	sub.active = true
+
+	var replay []*hubEvent
+	if stream != "" || after > 0 {
+		replay, missed = h.since(filter, stream, after)
+	}
+	if reply != nil {
+		h.queue(sub, &hubEvent{payload: reply(h.seq, len(replay), missed)})
+	}
+	for _, e := range replay {
+		h.queue(sub, e)
+	}
+	return len(replay), missed
+}
+
+// pause stops sending events to a subscriber until it subscribes again
+func (h *eventHub) pause(sub *eventSubscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	sub.active = false
+}
+
+// notify queues a message for one subscriber
+func (h *eventHub) notify(sub *eventSubscriber, payload []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.queue(sub, &hubEvent{payload: payload})
+}
+
+// since returns the buffered events after a sequence number that pass a filter, and whether
+// some events after it were lost; the caller holds the lock
+func (h *eventHub) since(filter eventFilter, stream string, after uint64) ([]*hubEvent, bool) {
+	if stream != h.stream || after > h.seq {
+		// Numbers of another stream, e.g. before a restart, say nothing about this one
+		return nil, true
+	}
+	missed := len(h.buffer) > 0 && h.buffer[0].seq > after+1
+	var replay []*hubEvent
+	for _, e := range h.buffer {
+		if e.seq > after && filter.matches(e) {
+			replay = append(replay, e)
+		}
+	}
+	return replay, missed
+}
+
+// position returns the stream, the latest sequence number and the oldest buffered one
+func (h *eventHub) position() (string, uint64, uint64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	oldest := h.seq + 1
+	if len(h.buffer) > 0 {
+		oldest = h.buffer[0].seq
+	}
+	return h.stream, h.seq, oldest
+}
+
+// close drops every subscriber, which ends their connections
+func (h *eventHub) close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for sub := range h.subs {
+		h.drop(sub)
+	}
+	h.closed = true
+}
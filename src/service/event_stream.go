@@ -0,0 +1,120 @@
+package service
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// eventStreamTypes are the events of /api/events without a types parameter
+var eventStreamTypes = []string{"new_global", "new_hof", "stats_update"}
+
+// eventStreamKeepAlive is how often a comment is sent on an idle event stream, so that
+// proxies and clients do not close it
+const eventStreamKeepAlive = 30 * time.Second
+
+// eventStreamID returns the SSE id of an event. It holds the stream as well as the sequence
+// number, so that resuming after a restart is recognised.
+func eventStreamID(stream string, seq uint64) string {
+	return stream + "-" + strconv.FormatUint(seq, 10)
+}
+
+// parseEventStreamID splits an id made by eventStreamID
+func parseEventStreamID(id string) (string, uint64, bool) {
+	i := strings.LastIndex(id, "-")
+	if i <= 0 {
+		return "", 0, false
+	}
+	seq, err := strconv.ParseUint(id[i+1:], 10, 64)
+	return id[:i], seq, err == nil
+}
+
+// eventFilterFromQuery reads the types and filters of an event stream request
+func eventFilterFromQuery(query url.Values) (eventFilter, error) {
+	filter := eventFilter{
+		Types:      eventStreamTypes,
+		GlobalType: query.Get("global_type"),
+		Target:     query.Get("target"),
+		Player:     query.Get("player"),
+	}
+	if query.Has("types") {
+		filter.Types = splitList(query.Get("types"))
+		if err := validateEventTypes(filter.Types); err != nil {
+			return eventFilter{}, err
+		}
+	}
+	if v := query.Get("min_value"); v != "" {
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return eventFilter{}, fmt.Errorf("invalid min_value %q", v)
+		}
+		filter.MinValue = n
+	}
+	return filter, nil
+}
+
+// handleEventStream sends events as Server-Sent Events, for clients that cannot use the
+// WebSocket. Events come from the same hub and carry the same JSON; a client that reconnects
+// with Last-Event-ID gets the buffered events it missed, or a missed event if they are lost.
+func (s *WebService) handleEventStream(w http.ResponseWriter, r *http.Request) {
+	filter, err := eventFilterFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Browsers resend the id of the last event; other clients may pass it as a parameter
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.URL.Query().Get("last_event_id")
+	}
+	var resumeStream string
+	var resumeSeq uint64
+	if lastID != "" {
+		if resumeStream, resumeSeq, ok = parseEventStreamID(lastID); !ok {
+			resumeStream = lastID // Not one of ours, so its events are missed
+		}
+	}
+
+	sub := s.events.join(false, filter)
+	defer s.events.leave(sub)
+	serviceMetrics.eventStreamClients.Inc()
+	defer serviceMetrics.eventStreamClients.Dec()
+	_, missed := s.events.subscribe(sub, filter, resumeStream, resumeSeq, nil)
+	stream, seq, _ := s.events.position()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Keep reverse proxies from buffering the stream
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 5000\n\n")
+	if missed {
+		fmt.Fprintf(w, "id: %s\nevent: missed\ndata: {\"stream\":%q,\"seq\":%d}\n\n", eventStreamID(stream, seq), stream, seq)
+	}
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventStreamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case e, ok := <-sub.send:
+			if !ok {
+				return
+			}
+			fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", eventStreamID(stream, e.seq), e.eventType, e.payload)
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"eu-clams/internal/model"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sseEvent is an event read from an event stream
+type sseEvent struct {
+	ID, Event, Data string
+}
+
+// openEventStream requests /api/events and returns a function reading its next event
+func openEventStream(t *testing.T, server *httptest.Server, query, lastID string) func() sseEvent {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/api/events"+query, nil)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET /api/events: %v", err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status = %d, Content-Type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	lines := bufio.NewScanner(resp.Body)
+	return func() sseEvent {
+		t.Helper()
+		var e sseEvent
+		for lines.Scan() {
+			field, value, _ := strings.Cut(lines.Text(), ": ")
+			switch field {
+			case "id":
+				e.ID = value
+			case "event":
+				e.Event = value
+			case "data":
+				e.Data = value
+			case "":
+				if e.Event != "" {
+					return e
+				}
+			}
+		}
+		t.Fatalf("event stream ended: %v", lines.Err())
+		return e
+	}
+}
+
+func TestEventStream(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+	server := httptest.NewServer(s.routes())
+	defer server.Close()
+
+	next := openEventStream(t, server, "?min_value=80", "")
+	waitForSubscribers(t, s, 1)
+	s.BroadcastEvent("session_started", model.SessionSummary{ID: "s1"}) // Not a default type
+	s.BroadcastEvent("new_global", s.db.Globals[1])                     // 60 PED
+	s.BroadcastEvent("new_hof", s.db.Globals[2])
+	s.BroadcastEvent("stats_update", s.db.GetStatsData())
+
+	stream, _, _ := s.events.position()
+	e := next()
+	if e.Event != "new_hof" || e.ID != eventStreamID(stream, 3) || !strings.Contains(e.Data, `"seq":3`) {
+		t.Errorf("first event = %+v, want new_hof with id 3", e)
+	}
+	if e := next(); e.Event != "stats_update" || e.ID != eventStreamID(stream, 4) {
+		t.Errorf("second event = %+v, want stats_update with id 4", e)
+	}
+
+	// A reconnect with the last id gets what came after it
+	next = openEventStream(t, server, "?types=new_global,new_hof", eventStreamID(stream, 1))
+	if e := next(); e.Event != "new_global" || e.ID != eventStreamID(stream, 2) {
+		t.Errorf("replayed event = %+v, want new_global with id 2", e)
+	}
+	if e := next(); e.Event != "new_hof" {
+		t.Errorf("replayed event = %+v, want new_hof", e)
+	}
+
+	// Ids of an earlier run cannot be resumed
+	next = openEventStream(t, server, "", "earlier-7")
+	if e := next(); e.Event != "missed" || e.ID != eventStreamID(stream, 4) {
+		t.Errorf("event after a restart = %+v, want missed with the latest id", e)
+	}
+
+	if rec := serve(s, http.MethodGet, "/api/events?types=loot", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown type status = %d, want 400", rec.Code)
+	}
+}
+
+func TestParseEventStreamID(t *testing.T) {
+	t.Parallel()
+	if stream, seq, ok := parseEventStreamID(eventStreamID("ab12", 42)); !ok || stream != "ab12" || seq != 42 {
+		t.Errorf("parseEventStreamID = %q, %d, %v", stream, seq, ok)
+	}
+	for _, id := range []string{"", "42", "-1", "ab12-x"} {
+		if _, _, ok := parseEventStreamID(id); ok {
+			t.Errorf("parseEventStreamID(%q) succeeded", id)
+		}
+	}
+}
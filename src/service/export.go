@@ -0,0 +1,123 @@
+package service
+
+import (
+	"bytes"
+	"eu-clams/internal/export"
+	"eu-clams/internal/storage"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ExportGlobals writes the player's globals selected by a query in an export format, with the
+// statistics of those globals in the HTML report. The query starts at the first page, and
+// a zero limit exports every matching global. It reads the database under its read lock.
+func ExportGlobals(w io.Writer, db *storage.EntropyDB, q storage.GlobalQuery, opts export.Options, now time.Time) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	q.Cursor = ""
+	db.RLock()
+	page, err := db.QueryGlobals(q)
+	if err != nil {
+		db.RUnlock()
+		return err
+	}
+
+	globals := make([]export.Global, len(page.Globals))
+	for i, g := range page.Globals {
+		globals[i] = export.Global{GlobalEntryJSON: toGlobalEntryJSON(g), MarketValue: db.MarketValue(g)}
+	}
+	report := export.Report{
+		Title:     "EU-CLAMS Globals",
+		Generated: now,
+		Filters:   exportFilters(q),
+		Stats:     db.GetStatsForGlobals(page.Globals),
+	}
+	if db.PlayerName != "" {
+		report.Title += " of " + db.PlayerName
+	}
+	db.RUnlock()
+	return export.Write(w, globals, report, opts)
+}
+
+// exportFilters lists the filters of a query that are set, by their parameter names
+func exportFilters(q storage.GlobalQuery) map[string]string {
+	filters := map[string]string{}
+	set := func(name, value string) {
+		if value != "" {
+			filters[name] = value
+		}
+	}
+	if !q.From.IsZero() {
+		set("from", q.From.Format("2006-01-02 15:04:05"))
+	}
+	if !q.To.IsZero() {
+		set("to", q.To.Format("2006-01-02 15:04:05"))
+	}
+	set("type", q.Type)
+	set("target", q.Target)
+	set("location", q.Location)
+	set("tier", q.Tier)
+	if q.MinValue > 0 {
+		set("min_value", strconv.FormatFloat(q.MinValue, 'f', -1, 64))
+	}
+	if q.MaxValue > 0 {
+		set("max_value", strconv.FormatFloat(q.MaxValue, 'f', -1, 64))
+	}
+	return filters
+}
+
+// exportOptionsFromRequest reads the format, CSV columns and delimiter of an export request
+func exportOptionsFromRequest(r *http.Request) (export.Options, error) {
+	query := r.URL.Query()
+	opts := export.Options{Format: query.Get("format")}
+	if opts.Format == "" {
+		opts.Format = export.FormatCSV
+	}
+	var err error
+	if v := query.Get("columns"); v != "" {
+		if opts.Columns, err = export.ParseColumns(v); err != nil {
+			return opts, err
+		}
+	}
+	if opts.Delimiter, err = export.ParseDelimiter(query.Get("delimiter")); err != nil {
+		return opts, err
+	}
+	return opts, opts.Validate()
+}
+
+// handleExport downloads the player's globals as CSV, JSON, NDJSON or an HTML report. The
+// filters and sort order are those of /api/v1/globals; every matching global is exported
+// unless limit is given.
+func (s *WebService) handleExport(w http.ResponseWriter, r *http.Request) {
+	opts, err := exportOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	q, err := globalQueryFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !r.URL.Query().Has("limit") {
+		q.Limit = 0
+	}
+
+	// Render first, so that a bad query is answered with an error rather than half a file
+	var buf bytes.Buffer
+	now := time.Now()
+	if err := ExportGlobals(&buf, s.db, q, opts, now); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", export.ContentType(opts.Format))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.FileName(opts.Format, now)))
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Write(buf.Bytes())
+}
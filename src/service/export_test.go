@@ -0,0 +1,57 @@
+package service
+
+import (
+	"encoding/json"
+	"eu-clams/internal/model"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestExportEndpoint(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+	if err := s.db.SetMarkups([]model.Markup{{Item: "Atrox", Percent: 150}}); err != nil {
+		t.Fatalf("SetMarkups: %v", err)
+	}
+
+	rec := serve(s, http.MethodGet, "/api/export?type=kill&min_value=80&columns=target,value,market_value&delimiter=semicolon", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("Content-Type = %q, want text/csv", ct)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment; filename=\"eu-clams-globals-") || !strings.HasSuffix(cd, ".csv\"") {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	if body := rec.Body.String(); body != "\uFEFFtarget;value;market_value\r\nAtrox;100.00;150.00\r\n" {
+		t.Errorf("CSV = %q, want only the Atrox kill", body)
+	}
+
+	rec = serve(s, http.MethodGet, "/api/export?format=json&order=asc", nil)
+	var doc struct {
+		Count       int
+		TotalMarket float64 `json:"total_market_value"`
+		Globals     []struct {
+			MarketValue float64 `json:"market_value"`
+		}
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil || doc.Count != 3 {
+		t.Fatalf("JSON export = %s, %v; want all 3 globals", rec.Body, err)
+	}
+	if doc.TotalMarket != 1710 || doc.Globals[0].MarketValue != 150 || doc.Globals[1].MarketValue != 60 {
+		t.Errorf("JSON export market values = %v in total, %+v; want Atrox at 150%%, the rest at TT", doc.TotalMarket, doc.Globals)
+	}
+
+	rec = serve(s, http.MethodGet, "/api/export?format=html&tier=hof", nil)
+	if body := rec.Body.String(); !strings.Contains(body, "<!DOCTYPE html>") || strings.Contains(body, "Atrox") {
+		t.Error("HTML report does not show only the HoF")
+	}
+
+	for _, target := range []string{"/api/export?format=xlsx", "/api/export?columns=colour", "/api/export?delimiter=ab", "/api/export?tier=legendary", "/api/export?from=yesterday"} {
+		if rec := serve(s, http.MethodGet, target, nil); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s status = %d, want 400", target, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"errors"
+	"eu-clams/internal/logger"
+	"eu-clams/internal/storage"
+	"fmt"
+)
+
+// errSaveFailed is returned when a change was applied but could not be written to disk
+var errSaveFailed = errors.New("failed to save database")
+
+// EditGlobal edits a stored global, pushes the change to the web clients and saves the
+// database, holding its lock
+func EditGlobal(db *storage.EntropyDB, id string, edit storage.GlobalEdit, author string, log *logger.Logger) (*storage.GlobalEntry, error) {
+	db.Lock()
+	defer db.Unlock()
+	entry, err := db.EditGlobal(id, edit, author)
+	if err != nil {
+		return nil, err
+	}
+	if log != nil {
+		log.Info("Global %s edited by %s", id, author)
+	}
+	BroadcastToWebServices("global_updated", entry)
+	BroadcastToWebServices("stats_update", db.GetStatsData())
+	return entry, saveEditedDatabase(db, log)
+}
+
+// DeleteGlobal deletes a stored global, pushes the deletion to the web clients and saves the
+// database, holding its lock
+func DeleteGlobal(db *storage.EntropyDB, id string, author string, log *logger.Logger) error {
+	db.Lock()
+	defer db.Unlock()
+	i, ok := db.FindGlobal(id)
+	if !ok {
+		return storage.ErrGlobalNotFound
+	}
+	deleted := db.Globals[i]
+	if err := db.DeleteGlobal(id, author); err != nil {
+		return err
+	}
+	if log != nil {
+		log.Info("Global %s deleted by %s", id, author)
+	}
+	BroadcastToWebServices("global_deleted", &deleted)
+	BroadcastToWebServices("stats_update", db.GetStatsData())
+	return saveEditedDatabase(db, log)
+}
+
+// saveEditedDatabase writes the database back to the file it was loaded from. The caller
+// holds the database lock.
+func saveEditedDatabase(db *storage.EntropyDB, log *logger.Logger) error {
+	if db.Path() == "" {
+		return fmt.Errorf("%w: database has no file path", errSaveFailed)
+	}
+	if err := db.SaveDatabase(db.Path(), log); err != nil {
+		return fmt.Errorf("%w: %v", errSaveFailed, err)
+	}
+	return nil
+}
@@ -2,15 +2,18 @@ package service
 
 import (
 	"eu-clams/internal/storage"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 )
 
 // GlobalHandler defines a function that processes a new global entry
 type GlobalHandler func(entry *storage.GlobalEntry) error
 
-// HandleNewGlobals processes new globals detected in the chat log
+// HandleNewGlobals processes new globals detected in the chat log. The caller holds the
+// database lock.
 func (s *DataProcessorService) HandleNewGlobals(newEntries []storage.GlobalEntry) {
 	if len(newEntries) == 0 {
 		return
@@ -69,14 +72,13 @@ func (s *DataProcessorService) HandleNewGlobals(newEntries []storage.GlobalEntry
 							e.Location, e.RawMessage)
 
 						// Find and update this entry in the database
-						s.db.UpdateGlobalLocation(&e)
+						s.db.Lock()
+						if err := s.db.UpdateGlobalLocation(&e); err != nil {
+							s.log.Error("Failed to update location for global %s: %v", e.ID, err)
+						}
 
 						// Save the database to ensure the location is persisted
-						dbPath := s.config.DatabasePath
-						if !filepath.IsAbs(dbPath) {
-							dbPath = filepath.Join(filepath.Dir(os.Args[0]), dbPath)
-						}
-						if err := s.db.SaveDatabase(dbPath, s.log); err != nil {
+						if err := s.db.SaveDatabase(DatabasePath(s.config), s.log); err != nil {
 							s.log.Error("Failed to save database after location update: %v", err)
 						} else {
 							s.log.Info("Database updated with location: %s", e.Location)
@@ -84,6 +86,7 @@ func (s *DataProcessorService) HandleNewGlobals(newEntries []storage.GlobalEntry
 
 						// Broadcast updated stats since locations have changed
 						statsData := s.db.GetStatsData()
+						s.db.Unlock()
 						BroadcastToWebServices("stats_update", statsData)
 					}
 				}
@@ -103,4 +106,34 @@ func (s *DataProcessorService) HandleNewGlobals(newEntries []storage.GlobalEntry
 		statsData := s.db.GetStatsData()
 		BroadcastToWebServices("stats_update", statsData)
 	}
+
+	// Share new globals with the team; imports are history the hub does not need live
+	if !s.isImportMode {
+		PushToHub(newEntries)
+		if s.config.HubMode {
+			s.addOwnTeamGlobals(newEntries)
+		}
+	}
+}
+
+// addOwnTeamGlobals adds the hub player's own globals to the team's, as if pushed by a member
+// named after the player
+func (s *DataProcessorService) addOwnTeamGlobals(newEntries []storage.GlobalEntry) {
+	member := s.config.PlayerName
+	if member == "" {
+		member = "hub"
+	}
+	for _, entry := range s.db.MergeTeamGlobals(member, newEntries) {
+		BroadcastToWebServices("team_global", entry)
+	}
+}
+
+// reportRecords logs and broadcasts globals that beat the player's previous best
+func (s *DataProcessorService) reportRecords(records []storage.PersonalRecord) {
+	for _, record := range records {
+		e := record.Global
+		s.log.Info("Personal record (%s): %s %s worth %.2f PED", strings.Join(record.Scopes, ", "), e.Type, e.Target, e.Value)
+		BroadcastToWebServices("personal_record", record)
+		s.alert(fmt.Sprintf("New personal record: %s %.2f PED (%s)", e.Target, e.Value, strings.Join(record.Scopes, ", ")))
+	}
 }
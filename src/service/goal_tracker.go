@@ -0,0 +1,71 @@
+package service
+
+import (
+	"errors"
+	"eu-clams/internal/analysis"
+	"eu-clams/internal/logger"
+	"eu-clams/internal/model"
+	"eu-clams/internal/storage"
+	"fmt"
+)
+
+// errGoalNotFound is returned when removing a goal that does not exist
+var errGoalNotFound = errors.New("goal not found")
+
+// AddGoal stores a new goal, saves the database and notifies web clients, holding the
+// database lock
+func AddGoal(db *storage.EntropyDB, goal model.Goal, log *logger.Logger) (model.Goal, error) {
+	db.Lock()
+	defer db.Unlock()
+	added, err := db.AddGoal(goal, analysis.WallClockNow())
+	if err != nil {
+		return model.Goal{}, err
+	}
+	if log != nil {
+		log.Info("Goal %s added: %s", added.ID, added.Name)
+	}
+	BroadcastToWebServices("goals_updated", db.GetGoalProgress(analysis.WallClockNow()))
+	return added, saveEditedDatabase(db, log)
+}
+
+// RemoveGoal removes a goal, saves the database and notifies web clients, holding the
+// database lock
+func RemoveGoal(db *storage.EntropyDB, id string, log *logger.Logger) error {
+	db.Lock()
+	defer db.Unlock()
+	if !db.RemoveGoal(id) {
+		return fmt.Errorf("%w: %s", errGoalNotFound, id)
+	}
+	if log != nil {
+		log.Info("Goal %s removed", id)
+	}
+	BroadcastToWebServices("goals_updated", db.GetGoalProgress(analysis.WallClockNow()))
+	return saveEditedDatabase(db, log)
+}
+
+// SetAlertHandler sets a function that is called with a message for every personal record,
+// unlocked achievement and completed goal while monitoring, e.g. to show it in the GUI
+func (s *DataProcessorService) SetAlertHandler(handler func(message string)) {
+	s.onAlert = handler
+}
+
+// reportProgress logs and broadcasts unlocked achievements and completed goals
+func (s *DataProcessorService) reportProgress(unlocked []model.AchievementUnlock, completed []model.GoalProgress) {
+	for _, a := range unlocked {
+		s.log.Info("Achievement unlocked: %s - %s", a.Name, a.Description)
+		BroadcastToWebServices("achievement_unlocked", a)
+		s.alert(fmt.Sprintf("Achievement unlocked: %s", a.Name))
+	}
+	for _, p := range completed {
+		s.log.Info("Goal completed: %s (%.2f of %.2f)", p.Goal.Name, p.Current, p.Goal.Threshold)
+		BroadcastToWebServices("goal_completed", p)
+		s.alert(fmt.Sprintf("Goal completed: %s", p.Goal.Name))
+	}
+}
+
+// alert passes a message to the alert handler, if one is set
+func (s *DataProcessorService) alert(message string) {
+	if s.onAlert != nil {
+		s.onAlert(message)
+	}
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"eu-clams/internal/model"
+	"eu-clams/internal/storage"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Limits of a push to the hub
+const (
+	hubIngestPath      = "/api/hub/ingest"
+	maxHubBatch        = 1000
+	maxHubIngestBytes  = 4 << 20
+	defaultTeamGlobals = 50
+)
+
+// hubIngestRequest is the body a member's client pushes to the hub
+type hubIngestRequest struct {
+	Globals []model.GlobalEntryJSON `json:"globals"`
+}
+
+// hubIngestResponse tells a member how many pushed globals were new, and which were invalid
+type hubIngestResponse struct {
+	Member     string              `json:"member"`
+	Accepted   int                 `json:"accepted"`
+	Duplicates int                 `json:"duplicates"`
+	Rejected   []hubRejectedGlobal `json:"rejected,omitempty"`
+}
+
+// hubRejectedGlobal is a pushed global the hub did not store, by its position in the push
+type hubRejectedGlobal struct {
+	Index int    `json:"index"`
+	ID    string `json:"id,omitempty"`
+	Error string `json:"error"`
+}
+
+// hubMembersResponse is the body of /api/hub/members
+type hubMembersResponse struct {
+	HubMode bool                 `json:"hub_mode"`
+	Members []storage.TeamMember `json:"members"`
+}
+
+// hubLeaderboardsResponse is the body of /api/hub/leaderboards
+type hubLeaderboardsResponse struct {
+	Interval     string              `json:"interval"`
+	Leaderboards []model.Leaderboard `json:"leaderboards"`
+}
+
+// SetHubMode sets whether the web service accepts globals pushed by team members
+func (s *WebService) SetHubMode(enabled bool) {
+	s.hubMode = enabled
+}
+
+// canPush returns whether any token may push globals to a hub
+func (a *webAuth) canPush() bool {
+	for _, t := range a.tokens {
+		if t.Scope == ScopeMember || t.Scope == ScopeAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// fromGlobalEntryJSON converts a pushed global back to a stored one. The ID is left for the
+// hub to derive, so a member cannot choose it.
+func fromGlobalEntryJSON(g model.GlobalEntryJSON) (storage.GlobalEntry, error) {
+	timestamp, err := time.Parse(time.RFC3339, g.Timestamp)
+	if err != nil {
+		return storage.GlobalEntry{}, fmt.Errorf("global %q has an invalid timestamp %q", g.ID, g.Timestamp)
+	}
+	switch g.Type {
+	case "kill", "craft", "find":
+	default:
+		return storage.GlobalEntry{}, fmt.Errorf("global %q has invalid type %q: must be kill, craft or find", g.ID, g.Type)
+	}
+	if g.Value < 0 || g.Target == "" || g.RawMessage == "" {
+		return storage.GlobalEntry{}, fmt.Errorf("global %q needs a target, a raw message and a value that is not negative", g.ID)
+	}
+	return storage.GlobalEntry{
+		Timestamp:  timestamp.UTC(),
+		Type:       g.Type,
+		PlayerName: g.PlayerName,
+		TeamName:   g.TeamName,
+		Target:     g.Target,
+		Value:      g.Value,
+		Location:   g.Location,
+		IsHof:      g.IsHof,
+		RawMessage: g.RawMessage,
+		Records:    g.Records,
+	}, nil
+}
+
+// handleHubIngest stores the globals a member pushes, attributed to the member its token is
+// named after. Globals the hub already has are counted as duplicates, so a client may
+// safely push a batch again when it did not get the answer. Invalid globals are skipped and
+// reported, so one bad global does not cost the member the rest of the batch.
+func (s *WebService) handleHubIngest(w http.ResponseWriter, r *http.Request) {
+	if !s.hubMode {
+		http.Error(w, "hub mode is not enabled on this server", http.StatusNotFound)
+		return
+	}
+	token, ok := s.currentAuth().bearerToken(r)
+	if !ok || (token.Scope != ScopeMember && token.Scope != ScopeAdmin) {
+		http.Error(w, "pushing globals requires a member token", http.StatusForbidden)
+		return
+	}
+
+	var req hubIngestRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxHubIngestBytes)).Decode(&req); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "request body is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if len(req.Globals) > maxHubBatch {
+		http.Error(w, fmt.Sprintf("at most %d globals may be pushed at once", maxHubBatch), http.StatusRequestEntityTooLarge)
+		return
+	}
+	entries := make([]storage.GlobalEntry, 0, len(req.Globals))
+	var rejected []hubRejectedGlobal
+	for i, g := range req.Globals {
+		entry, err := fromGlobalEntryJSON(g)
+		if err != nil {
+			rejected = append(rejected, hubRejectedGlobal{Index: i, ID: g.ID, Error: err.Error()})
+			continue
+		}
+		entries = append(entries, entry)
+	}
+
+	// The hub's watcher adds its own globals to the team's, so hold the database lock
+	s.db.Lock()
+	added := s.db.MergeTeamGlobals(token.Name, entries)
+	var saveErr error
+	if len(added) > 0 {
+		saveErr = saveEditedDatabase(s.db, s.log)
+	}
+	s.db.Unlock()
+	if saveErr != nil {
+		s.log.Error("Failed to save globals pushed by %s: %v", token.Name, saveErr)
+		http.Error(w, saveErr.Error(), http.StatusInternalServerError)
+		return
+	}
+	if len(added) > 0 {
+		s.log.Info("Team member %s pushed %d new globals", token.Name, len(added))
+	}
+	if len(rejected) > 0 {
+		s.log.Warn("Team member %s pushed %d invalid globals, the first: %s", token.Name, len(rejected), rejected[0].Error)
+	}
+	for _, entry := range added {
+		BroadcastToWebServices("team_global", entry)
+	}
+
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(hubIngestResponse{
+		Member:     token.Name,
+		Accepted:   len(added),
+		Duplicates: len(entries) - len(added),
+		Rejected:   rejected,
+	})
+}
+
+// handleHubMembers handles the team members API endpoint
+func (s *WebService) handleHubMembers(w http.ResponseWriter, r *http.Request) {
+	// Set headers to prevent caching
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "application/json")
+
+	s.db.RLock()
+	members := s.db.GetTeamMembers()
+	s.db.RUnlock()
+	json.NewEncoder(w).Encode(hubMembersResponse{HubMode: s.hubMode, Members: members})
+}
+
+// handleHubGlobals handles the team globals API endpoint
+func (s *WebService) handleHubGlobals(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	limit := defaultTeamGlobals
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	s.db.RLock()
+	globals := s.db.GetTeamGlobals(query.Get("member"), limit)
+	s.db.RUnlock()
+	jsonGlobals := make([]model.GlobalEntryJSON, len(globals))
+	for i, g := range globals {
+		jsonGlobals[i] = toGlobalEntryJSON(g)
+	}
+
+	// Set headers to prevent caching
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(jsonGlobals)
+}
+
+// handleHubLeaderboards handles the team leaderboards API endpoint
+func (s *WebService) handleHubLeaderboards(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	interval := query.Get("interval")
+	if interval == "" {
+		interval = model.IntervalDay
+	}
+	limit := model.DefaultLeaderboardSize
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	s.db.RLock()
+	boards, err := s.db.GetTeamLeaderboards(interval, limit)
+	s.db.RUnlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Set headers to prevent caching
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(hubLeaderboardsResponse{Interval: interval, Leaderboards: boards})
+}
+
+// handleTeamPage renders the team dashboard: the members, this week's leaderboard and the
+// latest globals
+func (s *WebService) handleTeamPage(w http.ResponseWriter, r *http.Request) {
+	s.db.RLock()
+	boards, _ := s.db.GetTeamLeaderboards(model.IntervalWeek, model.DefaultLeaderboardSize)
+	if len(boards) > 1 {
+		boards = boards[:1]
+	}
+	data := map[string]interface{}{
+		"HubMode":      s.hubMode,
+		"Members":      s.db.GetTeamMembers(),
+		"Globals":      s.db.TeamSize(),
+		"Leaderboards": boards,
+		"Latest":       s.db.GetTeamGlobals("", defaultTeamGlobals),
+		"Generated":    time.Now().UTC().Format(time.RFC3339),
+	}
+	s.db.RUnlock()
+
+	// Set headers to prevent caching
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "text/html")
+
+	if err := s.templates.ExecuteTemplate(w, "team.html", data); err != nil {
+		s.log.Error("Failed to render template: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"eu-clams/internal/config"
+	"eu-clams/internal/logger"
+	"eu-clams/internal/model"
+	"eu-clams/internal/storage"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Retry timing of the hub client
+const (
+	hubMinBackoff   = time.Second
+	hubMaxBackoff   = 5 * time.Minute
+	hubPushTimeout  = 30 * time.Second
+	hubQueueFile    = "hub-queue.json"
+	maxHubQueueSize = 100000
+)
+
+// errHubRejected is returned when the hub refuses a batch for good, so retrying it as is
+// is pointless
+var errHubRejected = errors.New("hub rejected the globals")
+
+// HubClient pushes the player's new globals to a team hub. Globals wait in a queue that is
+// kept next to the database, so they survive restarts and are retried, with a growing delay,
+// while the hub cannot be reached.
+type HubClient struct {
+	*BaseService
+	log        *logger.Logger
+	ingestURL  string
+	token      string
+	queuePath  string
+	client     *http.Client
+	queue      []model.GlobalEntryJSON
+	dropped    int // Globals trimmed from the front of the queue so far
+	maxQueue   int
+	batchSize  int // Globals pushed at once, smaller after the hub refused a batch
+	mu         sync.Mutex
+	wake       chan struct{}
+	stopChan   chan struct{}
+	stopOnce   sync.Once
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+// NewHubClient creates a client pushing to the hub at hubURL with a member token, keeping
+// its queue in queuePath
+func NewHubClient(log *logger.Logger, hubURL, token, queuePath string) (*HubClient, error) {
+	u, err := url.Parse(strings.TrimSuffix(hubURL, "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid hub_url %q: must be like https://hub.example.com:8080", hubURL)
+	}
+	if token == "" {
+		return nil, fmt.Errorf("hub_token is required to push globals to %s", hubURL)
+	}
+	return &HubClient{
+		BaseService: NewBaseService("HubClient"),
+		log:         log,
+		ingestURL:   u.String() + hubIngestPath,
+		token:       token,
+		queuePath:   queuePath,
+		client:      &http.Client{Timeout: hubPushTimeout},
+		wake:        make(chan struct{}, 1),
+		stopChan:    make(chan struct{}),
+		maxQueue:    maxHubQueueSize,
+		batchSize:   maxHubBatch,
+		minBackoff:  hubMinBackoff,
+		maxBackoff:  hubMaxBackoff,
+	}, nil
+}
+
+// StartHubClient starts pushing new globals to the configured hub and registers the client
+// so that the data processor feeds it. It returns nil if no hub is configured.
+func StartHubClient(log *logger.Logger, cfg config.Config) (*HubClient, error) {
+	if cfg.HubURL == "" {
+		return nil, nil
+	}
+	queuePath := filepath.Join(filepath.Dir(DatabasePath(cfg)), hubQueueFile)
+	client, err := NewHubClient(log, cfg.HubURL, cfg.HubToken, queuePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.Initialize(); err != nil {
+		return nil, err
+	}
+	RegisterHubClient(client)
+	go client.Run()
+	return client, nil
+}
+
+// Initialize loads the globals left in the queue by the last run
+func (c *HubClient) Initialize() error {
+	data, err := os.ReadFile(c.queuePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read hub queue: %w", err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := json.Unmarshal(data, &c.queue); err != nil {
+		return fmt.Errorf("failed to parse hub queue %s: %w", c.queuePath, err)
+	}
+	if len(c.queue) > 0 {
+		c.log.Info("%d globals are waiting to be pushed to the hub", len(c.queue))
+	}
+	return nil
+}
+
+// Enqueue adds globals to the queue and wakes the client to push them
+func (c *HubClient) Enqueue(entries []storage.GlobalEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	c.mu.Lock()
+	for _, entry := range entries {
+		c.queue = append(c.queue, toGlobalEntryJSON(entry))
+	}
+	// A hub that stays away must not grow the queue forever: the oldest globals go first
+	if dropped := len(c.queue) - c.maxQueue; dropped > 0 {
+		c.log.Warn("Hub queue is full, dropping the %d oldest globals", dropped)
+		c.queue = append([]model.GlobalEntryJSON(nil), c.queue[dropped:]...)
+		c.dropped += dropped
+	}
+	err := c.saveQueue()
+	c.mu.Unlock()
+	if err != nil {
+		c.log.Error("Failed to save hub queue: %v", err)
+	}
+
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Pending returns the number of globals waiting to be pushed
+func (c *HubClient) Pending() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.queue)
+}
+
+// Run pushes queued globals until stopped, waiting longer after every failed attempt
+func (c *HubClient) Run() error {
+	c.log.Info("Pushing new globals to the hub at %s", c.ingestURL)
+	backoff := c.minBackoff
+	for {
+		var wait <-chan time.Time
+		if err := c.Flush(); err != nil {
+			c.log.Warn("Failed to push globals to the hub, retrying in %s: %v", backoff, err)
+			wait = time.After(backoff)
+			backoff = min(backoff*2, c.maxBackoff)
+		} else {
+			backoff = c.minBackoff
+		}
+
+		if wait == nil {
+			// Everything was pushed: sleep until there is more
+			select {
+			case <-c.wake:
+			case <-c.stopChan:
+				return nil
+			}
+			continue
+		}
+		select {
+		case <-wait:
+		case <-c.stopChan:
+			return nil
+		}
+	}
+}
+
+// Stop stops pushing; queued globals are kept for the next run
+func (c *HubClient) Stop() error {
+	c.stopOnce.Do(func() {
+		close(c.stopChan)
+		UnregisterHubClient(c)
+	})
+	return nil
+}
+
+// Flush pushes the queue in batches until it is empty or a push fails. The hub skips and
+// reports invalid globals; a batch it refuses as a whole is split until the global at
+// fault is alone, and only that one is dropped, since it would fail again.
+func (c *HubClient) Flush() error {
+	for {
+		c.mu.Lock()
+		batch := c.queue[:min(len(c.queue), c.batchSize)]
+		dropped := c.dropped
+		c.mu.Unlock()
+		if len(batch) == 0 {
+			return nil
+		}
+
+		result, err := c.push(batch)
+		switch {
+		case errors.Is(err, errHubRejected) && len(batch) > 1:
+			c.batchSize = (len(batch) + 1) / 2
+			continue
+		case errors.Is(err, errHubRejected):
+			c.log.Error("Dropping a global the hub refuses: %v", err)
+		case err != nil:
+			return err
+		default:
+			c.batchSize = min(c.batchSize*2, maxHubBatch)
+			if result.Accepted > 0 {
+				c.log.Info("Pushed %d globals to the hub as %s", result.Accepted, result.Member)
+			}
+			for _, r := range result.Rejected {
+				c.log.Error("The hub refused global %s: %s", r.ID, r.Error)
+			}
+		}
+
+		c.mu.Lock()
+		// A full queue may have trimmed the front, and part of the batch with it, meanwhile
+		done := max(0, len(batch)-(c.dropped-dropped))
+		c.queue = append([]model.GlobalEntryJSON(nil), c.queue[done:]...)
+		saveErr := c.saveQueue()
+		c.mu.Unlock()
+		if saveErr != nil {
+			c.log.Error("Failed to save hub queue: %v", saveErr)
+		}
+	}
+}
+
+// push sends one batch to the hub
+func (c *HubClient) push(batch []model.GlobalEntryJSON) (hubIngestResponse, error) {
+	var result hubIngestResponse
+	body, err := json.Marshal(hubIngestRequest{Globals: batch})
+	if err != nil {
+		return result, err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.ingestURL, bytes.NewReader(body))
+	if err != nil {
+		return result, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return result, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return result, json.NewDecoder(resp.Body).Decode(&result)
+	case resp.StatusCode == http.StatusBadRequest:
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return result, fmt.Errorf("%w: %s", errHubRejected, strings.TrimSpace(string(message)))
+	default:
+		// Wrong tokens and hubs that are down or not in hub mode yet may be fixed: keep trying
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return result, fmt.Errorf("hub answered %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+}
+
+// saveQueue writes the queue next to the database, or removes the file once it is empty.
+// The caller holds c.mu.
+func (c *HubClient) saveQueue() error {
+	if len(c.queue) == 0 {
+		if err := os.Remove(c.queuePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.Marshal(c.queue)
+	if err != nil {
+		return err
+	}
+	tmp := c.queuePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.queuePath)
+}
@@ -0,0 +1,323 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"eu-clams/internal/config"
+	"eu-clams/internal/logger"
+	"eu-clams/internal/model"
+	"eu-clams/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const testMemberToken = "member-token-0123456789"
+
+// newTestHub returns a web service in hub mode with a member token for jane, served over
+// HTTP like a second instance
+func newTestHub(t *testing.T) (*WebService, *httptest.Server) {
+	t.Helper()
+	hub := newTestWebService(t)
+	hub.SetHubMode(true)
+	tokens := []config.WebToken{
+		{Name: "viewer", Token: testReadToken, Scope: ScopeRead},
+		{Name: "jane", Token: testMemberToken, Scope: ScopeMember},
+	}
+	if err := hub.SetAuth(tokens, nil); err != nil {
+		t.Fatalf("SetAuth: %v", err)
+	}
+	if err := hub.db.SaveDatabase(filepath.Join(t.TempDir(), "hub.yaml"), nil); err != nil {
+		t.Fatalf("saving hub database: %v", err)
+	}
+	server := httptest.NewServer(hub.routes())
+	t.Cleanup(server.Close)
+	return hub, server
+}
+
+// memberGlobals returns globals as a member's instance parses them from its chat log
+func memberGlobals(t *testing.T, lines ...string) []storage.GlobalEntry {
+	t.Helper()
+	var entries []storage.GlobalEntry
+	for _, line := range lines {
+		entry, err := storage.ParseChatLine(line)
+		if err != nil || entry == nil {
+			t.Fatalf("ParseChatLine(%q) = %v, %v", line, entry, err)
+		}
+		entries = append(entries, *entry)
+	}
+	return entries
+}
+
+func TestHubIngest(t *testing.T) {
+	t.Parallel()
+	hub, server := newTestHub(t)
+
+	client, err := NewHubClient(logger.New(), server.URL+"/", testMemberToken, filepath.Join(t.TempDir(), hubQueueFile))
+	if err != nil {
+		t.Fatalf("NewHubClient: %v", err)
+	}
+	globals := memberGlobals(t,
+		"2025-05-16 10:00:00 [Globals] [] Jane Doe killed a creature (Atrox) with a value of 120 PED at Calypso",
+		"2025-05-16 10:30:00 [Globals] [] Jane Doe constructed an item (Explosive Projectiles) worth 900 PED",
+	)
+	client.Enqueue(globals)
+	if err := client.Flush(); err != nil || client.Pending() != 0 {
+		t.Fatalf("Flush() = %v with %d pending, want everything pushed", err, client.Pending())
+	}
+	if len(hub.db.Team) != 2 || hub.db.Team[0].Member != "jane" || hub.db.Team[0].ID != globals[0].ID {
+		t.Fatalf("hub Team = %+v, want both globals attributed to jane", hub.db.Team)
+	}
+
+	// Pushing again, as after a lost answer, stores nothing twice
+	client.Enqueue(globals[:1])
+	if err := client.Flush(); err != nil || len(hub.db.Team) != 2 {
+		t.Errorf("pushing again: %v, Team has %d globals, want 2", err, len(hub.db.Team))
+	}
+
+	// The hub's own database on disk has the team globals
+	saved, err := storage.LoadDatabase(hub.db.Path(), nil)
+	if err != nil || len(saved.Team) != 2 {
+		t.Errorf("saved hub database has %d team globals, %v", len(saved.Team), err)
+	}
+
+	rec := serve(hub, http.MethodGet, "/api/hub/members", bearer(testReadToken))
+	var members hubMembersResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &members); err != nil || !members.HubMode || len(members.Members) != 1 || members.Members[0].Globals != 2 {
+		t.Errorf("GET /api/hub/members = %s, %v", rec.Body, err)
+	}
+	rec = serve(hub, http.MethodGet, "/team", bearer(testMemberToken))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "Explosive Projectiles") {
+		t.Errorf("GET /team status = %d, want the team's globals", rec.Code)
+	}
+}
+
+// In the GUI the watcher and the hub share the database, so pushes survive the watcher's saves
+func TestHubIngestWhileWatching(t *testing.T) {
+	t.Parallel()
+	hub, server := newTestHub(t)
+	chatLog := filepath.Join(t.TempDir(), "chat.log")
+	history := "2025-05-16 09:00:00 [Globals] [] Test Player killed a creature (Atrox) with a value of 70 PED\n"
+	if err := os.WriteFile(chatLog, []byte(history), 0644); err != nil {
+		t.Fatalf("creating chat log: %v", err)
+	}
+	watcher := NewDataProcessorService(logger.New(), config.Config{PlayerName: "Test Player", DatabasePath: hub.db.Path(), HubMode: true}, chatLog)
+	watcher.UseDatabase(hub.db)
+	if err := watcher.Initialize(); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	if err := watcher.processLogFile(); err != nil {
+		t.Fatalf("importing the chat log: %v", err)
+	}
+
+	client, err := NewHubClient(logger.New(), server.URL+"/", testMemberToken, filepath.Join(t.TempDir(), hubQueueFile))
+	if err != nil {
+		t.Fatalf("NewHubClient: %v", err)
+	}
+	client.Enqueue(memberGlobals(t, "2025-05-16 10:00:00 [Globals] [] Jane Doe killed a creature (Atrox) with a value of 120 PED at Calypso"))
+	if err := client.Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	line := "2025-05-16 10:05:00 [Globals] [] Test Player killed a creature (Daikiba) with a value of 80 PED\n"
+	if err := os.WriteFile(chatLog, []byte(history+line), 0644); err != nil {
+		t.Fatalf("writing chat log: %v", err)
+	}
+	if err := watcher.processLogFile(); err != nil {
+		t.Fatalf("processing the new line: %v", err)
+	}
+
+	saved, err := storage.LoadDatabase(hub.db.Path(), nil)
+	if err != nil {
+		t.Fatalf("LoadDatabase: %v", err)
+	}
+	members := saved.GetTeamMembers()
+	if len(saved.Team) != 2 || len(members) != 2 {
+		t.Errorf("saved team = %+v, want jane's push and the hub player's own global", saved.Team)
+	}
+	if len(saved.Globals) != 5 {
+		t.Errorf("saved %d globals, want the 3 stored and the 2 from the chat log", len(saved.Globals))
+	}
+}
+
+func TestHubIngestAccess(t *testing.T) {
+	t.Parallel()
+	hub, _ := newTestHub(t)
+
+	post := func(token, body string) int {
+		req := httptest.NewRequest(http.MethodPost, hubIngestPath, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		hub.routes().ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if code := post(testReadToken, `{"globals":[]}`); code != http.StatusForbidden {
+		t.Errorf("read token push status = %d, want 403", code)
+	}
+	if code := post(testMemberToken, `{"globals":[`); code != http.StatusBadRequest {
+		t.Errorf("invalid body status = %d, want 400", code)
+	}
+	if code := post(testMemberToken, `{"globals":[]}`); code != http.StatusOK {
+		t.Errorf("empty push status = %d, want 200", code)
+	}
+	// Members may push but not edit
+	if rec := serve(hub, http.MethodDelete, "/api/globals/a", bearer(testMemberToken)); rec.Code != http.StatusForbidden {
+		t.Errorf("member DELETE status = %d, want 403", rec.Code)
+	}
+
+	hub.SetHubMode(false)
+	if code := post(testMemberToken, `{"globals":[]}`); code != http.StatusNotFound {
+		t.Errorf("push without hub mode status = %d, want 404", code)
+	}
+}
+
+func TestHubIngestSkipsInvalid(t *testing.T) {
+	t.Parallel()
+	hub, _ := newTestHub(t)
+
+	valid := toGlobalEntryJSON(memberGlobals(t, "2025-05-16 10:00:00 [Globals] [] Jane Doe killed a creature (Atrox) with a value of 120 PED")[0])
+	body, _ := json.Marshal(hubIngestRequest{Globals: []model.GlobalEntryJSON{
+		{ID: "bad", Timestamp: "yesterday", Type: "kill"},
+		valid,
+	}})
+	req := httptest.NewRequest(http.MethodPost, hubIngestPath, bytes.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+testMemberToken)
+	rec := httptest.NewRecorder()
+	hub.routes().ServeHTTP(rec, req)
+
+	var result hubIngestResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("push status = %d, body %s, %v", rec.Code, rec.Body, err)
+	}
+	if result.Accepted != 1 || len(result.Rejected) != 1 || result.Rejected[0].Index != 0 || result.Rejected[0].ID != "bad" {
+		t.Errorf("push = %+v, want the valid global stored and the invalid one reported", result)
+	}
+	if hub.db.TeamSize() != 1 {
+		t.Errorf("hub has %d team globals, want 1", hub.db.TeamSize())
+	}
+}
+
+func TestHubClientSplitsRefusedBatch(t *testing.T) {
+	t.Parallel()
+	// A hub that refuses any batch holding the Bad global as a whole
+	var pushed []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req hubIngestRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		for _, g := range req.Globals {
+			if g.Target == "Bad" {
+				http.Error(w, "global has an invalid target", http.StatusBadRequest)
+				return
+			}
+		}
+		for _, g := range req.Globals {
+			pushed = append(pushed, g.Target)
+		}
+		json.NewEncoder(w).Encode(hubIngestResponse{Member: "jane", Accepted: len(req.Globals)})
+	}))
+	defer server.Close()
+
+	client, _ := NewHubClient(logger.New(), server.URL, testMemberToken, filepath.Join(t.TempDir(), hubQueueFile))
+	client.Enqueue(memberGlobals(t,
+		"2025-05-16 10:00:00 [Globals] [] Jane Doe killed a creature (Atrox) with a value of 120 PED",
+		"2025-05-16 10:01:00 [Globals] [] Jane Doe killed a creature (Daikiba) with a value of 60 PED",
+		"2025-05-16 10:02:00 [Globals] [] Jane Doe killed a creature (Bad) with a value of 70 PED",
+		"2025-05-16 10:03:00 [Globals] [] Jane Doe killed a creature (Berycled) with a value of 80 PED",
+		"2025-05-16 10:04:00 [Globals] [] Jane Doe killed a creature (Cornundos) with a value of 90 PED",
+	))
+	if err := client.Flush(); err != nil || client.Pending() != 0 {
+		t.Fatalf("Flush() = %v with %d pending, want everything pushed or dropped", err, client.Pending())
+	}
+	if got := strings.Join(pushed, ","); got != "Atrox,Daikiba,Berycled,Cornundos" {
+		t.Errorf("pushed %s, want every global but the refused one", got)
+	}
+}
+
+func TestHubClientTrimsDuringPush(t *testing.T) {
+	t.Parallel()
+	var client *HubClient
+	later := memberGlobals(t,
+		"2025-05-16 11:00:00 [Globals] [] Jane Doe killed a creature (Berycled) with a value of 80 PED",
+		"2025-05-16 11:01:00 [Globals] [] Jane Doe killed a creature (Cornundos) with a value of 90 PED",
+	)
+	var pushed []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req hubIngestRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		for _, g := range req.Globals {
+			pushed = append(pushed, g.Target)
+		}
+		// New globals overflow the queue while the first batch is on its way
+		if len(pushed) == 2 {
+			client.Enqueue(later)
+		}
+		json.NewEncoder(w).Encode(hubIngestResponse{Member: "jane", Accepted: len(req.Globals)})
+	}))
+	defer server.Close()
+
+	client, _ = NewHubClient(logger.New(), server.URL, testMemberToken, filepath.Join(t.TempDir(), hubQueueFile))
+	client.maxQueue = 2
+	client.Enqueue(memberGlobals(t,
+		"2025-05-16 10:00:00 [Globals] [] Jane Doe killed a creature (Atrox) with a value of 120 PED",
+		"2025-05-16 10:01:00 [Globals] [] Jane Doe killed a creature (Daikiba) with a value of 60 PED",
+	))
+	if err := client.Flush(); err != nil || client.Pending() != 0 {
+		t.Fatalf("Flush() = %v with %d pending, want everything pushed", err, client.Pending())
+	}
+	if got := strings.Join(pushed, ","); got != "Atrox,Daikiba,Berycled,Cornundos" {
+		t.Errorf("pushed %s, want the globals queued during the push too", got)
+	}
+}
+
+func TestHubClientQueuesWhileUnreachable(t *testing.T) {
+	t.Parallel()
+	hub, server := newTestHub(t)
+	queuePath := filepath.Join(t.TempDir(), hubQueueFile)
+	globals := memberGlobals(t, "2025-05-16 12:00:00 [Globals] [] Jane Doe killed a creature (Daikiba) with a value of 75 PED")
+
+	// Nothing listens here any more
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	offline, _ := NewHubClient(logger.New(), down.URL, testMemberToken, queuePath)
+	offline.Enqueue(globals)
+	if err := offline.Flush(); err == nil || offline.Pending() != 1 {
+		t.Fatalf("Flush() = %v with %d pending, want an error and the global kept", err, offline.Pending())
+	}
+	if _, err := os.Stat(queuePath); err != nil {
+		t.Fatalf("queue was not saved: %v", err)
+	}
+
+	// After a restart the queue is pushed, retrying while the hub is briefly unavailable
+	var calls atomic.Int32
+	flaky := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, "starting up", http.StatusServiceUnavailable)
+			return
+		}
+		server.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer flaky.Close()
+	online, _ := NewHubClient(logger.New(), flaky.URL, testMemberToken, queuePath)
+	online.minBackoff = 10 * time.Millisecond
+	if err := online.Initialize(); err != nil || online.Pending() != 1 {
+		t.Fatalf("Initialize() = %v with %d pending, want the saved global", err, online.Pending())
+	}
+	go online.Run()
+	defer online.Stop()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for online.Pending() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if online.Pending() != 0 || calls.Load() < 2 || len(hub.db.Team) != 1 {
+		t.Fatalf("after retrying: %d pending, %d calls, %d team globals", online.Pending(), calls.Load(), len(hub.db.Team))
+	}
+	if _, err := os.Stat(queuePath); !os.IsNotExist(err) {
+		t.Errorf("empty queue file was kept: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"eu-clams/internal/config"
+	"eu-clams/internal/logger"
+	"eu-clams/internal/model"
+	"eu-clams/internal/storage"
+	"io"
+)
+
+// ConfigMarkups converts the configured markups for the model package
+func ConfigMarkups(cfg config.Config) []model.Markup {
+	markups := make([]model.Markup, len(cfg.Markups))
+	for i, m := range cfg.Markups {
+		markups[i] = model.Markup{Item: m.Item, Percent: m.Percent, Absolute: m.Absolute}
+	}
+	return markups
+}
+
+// SetConfigMarkups replaces the configured markups
+func SetConfigMarkups(cfg *config.Config, markups []model.Markup) {
+	cfg.Markups = make([]config.Markup, len(markups))
+	for i, m := range markups {
+		cfg.Markups[i] = config.Markup{Item: m.Item, Percent: m.Percent, Absolute: m.Absolute}
+	}
+}
+
+// ImportMarkupCSV merges the markups of a CSV file of item and markup rows into the
+// configuration, replacing those of the same items, and returns the number of rows read.
+// The caller saves the configuration.
+func ImportMarkupCSV(cfg *config.Config, r io.Reader) (int, error) {
+	imported, err := model.ParseMarkupCSV(r)
+	if err != nil {
+		return 0, err
+	}
+	merged := model.MergeMarkups(ConfigMarkups(*cfg), imported)
+	if _, err := model.NewMarkupTable(merged); err != nil {
+		return 0, err
+	}
+	SetConfigMarkups(cfg, merged)
+	return len(imported), nil
+}
+
+// ApplyMarkups sets the configured markups on the database and notifies web clients of the
+// revalued statistics, holding the database lock
+func ApplyMarkups(db *storage.EntropyDB, cfg config.Config, log *logger.Logger) error {
+	db.Lock()
+	defer db.Unlock()
+	if err := db.SetMarkups(ConfigMarkups(cfg)); err != nil {
+		return err
+	}
+	if log != nil {
+		log.Info("Using %d markups", len(cfg.Markups))
+	}
+	BroadcastToWebServices("stats_update", db.GetStatsData())
+	return nil
+}
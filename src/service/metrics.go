@@ -0,0 +1,156 @@
+package service
+
+import (
+	"bufio"
+	"errors"
+	"eu-clams/internal/metrics"
+	"eu-clams/internal/storage"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// serviceMetrics are the metrics of this process, served on /metrics
+var serviceMetrics = newServiceMetrics(metrics.NewRegistry())
+
+// metricSet holds the metrics updated by the services
+type metricSet struct {
+	registry           *metrics.Registry
+	globals            metrics.CounterVec
+	hofs               metrics.CounterVec
+	globalValue        *metrics.Counter
+	lastGlobal         *metrics.Gauge
+	linesParsed        *metrics.Counter
+	parseErrors        *metrics.Counter
+	watcherLag         *metrics.Gauge
+	screenshots        metrics.CounterVec
+	webSocketClients   *metrics.Gauge
+	eventStreamClients *metrics.Gauge
+	requestDuration    metrics.HistogramVec
+}
+
+// newServiceMetrics registers the metrics of the services in a registry
+func newServiceMetrics(r *metrics.Registry) *metricSet {
+	return &metricSet{
+		registry:           r,
+		globals:            r.CounterVec("eu_clams_globals_total", "Globals recorded by this process, HoFs included.", "type"),
+		hofs:               r.CounterVec("eu_clams_hofs_total", "Hall of Fame globals recorded by this process.", "type"),
+		globalValue:        r.Counter("eu_clams_global_value_ped_total", "Total value in PED of the globals recorded by this process."),
+		lastGlobal:         r.Gauge("eu_clams_last_global_timestamp_seconds", "Unix time of the newest global in the database."),
+		linesParsed:        r.Counter("eu_clams_lines_parsed_total", "Chat log lines read."),
+		parseErrors:        r.Counter("eu_clams_parse_errors_total", "Chat log lines that failed to parse."),
+		watcherLag:         r.Gauge("eu_clams_watcher_lag_bytes", "Bytes of the chat log not processed yet."),
+		screenshots:        r.CounterVec("eu_clams_screenshots_total", "Screenshots taken for globals, by result.", "result"),
+		webSocketClients:   r.Gauge("eu_clams_websocket_clients", "Connected WebSocket clients."),
+		eventStreamClients: r.Gauge("eu_clams_event_stream_clients", "Connected Server-Sent Events clients."),
+		requestDuration: r.HistogramVec("eu_clams_http_request_duration_seconds", "Duration of HTTP requests, by route pattern.",
+			nil, "method", "route", "code"),
+	}
+}
+
+// observeGlobals counts new globals and moves the newest global timestamp forward
+func (m *metricSet) observeGlobals(entries []storage.GlobalEntry) {
+	for _, g := range entries {
+		m.globals.With(g.Type).Inc()
+		if g.IsHof {
+			m.hofs.With(g.Type).Inc()
+		}
+		m.globalValue.Add(g.Value)
+		m.observeTimestamp(g.Timestamp)
+	}
+}
+
+// observeTimestamp sets the newest global timestamp if t is newer
+func (m *metricSet) observeTimestamp(t time.Time) {
+	if seconds := float64(t.UnixNano()) / 1e9; !t.IsZero() && seconds > m.lastGlobal.Value() {
+		m.lastGlobal.Set(seconds)
+	}
+}
+
+// observeScreenshot counts a screenshot attempt
+func (m *metricSet) observeScreenshot(err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	m.screenshots.With(result).Inc()
+}
+
+// instrument records the duration of every request by its route pattern. Streams and
+// WebSockets are left out, as their duration is that of the connection.
+func (m *metricSet) instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if rec.streaming {
+			return
+		}
+
+		// The mux sets the pattern on the request it was given; requests rejected before
+		// reaching it have none
+		route := r.Pattern
+		if _, path, ok := strings.Cut(route, " "); ok {
+			route = path
+		}
+		if route == "" {
+			route = "unmatched"
+		}
+		m.requestDuration.With(r.Method, route, strconv.Itoa(rec.status)).Observe(time.Since(start).Seconds())
+	})
+}
+
+// statusRecorder remembers the status of a response and whether it was streamed
+type statusRecorder struct {
+	http.ResponseWriter
+	status    int
+	wrote     bool
+	streaming bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wrote {
+		r.status = status
+		r.wrote = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	r.wrote = true
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush passes on flushes for event streams
+func (r *statusRecorder) Flush() {
+	r.streaming = true
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack passes on hijacking for WebSocket upgrades
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	r.streaming = true
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijacking is not supported")
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the wrapped writer for http.ResponseController
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// handleMetrics serves the metrics in the Prometheus text exposition format
+func (s *WebService) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := serviceMetrics.registry.WritePrometheus(w); err != nil {
+		s.log.Error("Failed to write metrics: %v", err)
+	}
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"eu-clams/internal/metrics"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetricsEndpoint(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+
+	durations := serviceMetrics.requestDuration.With(http.MethodGet, "/api/globals/{id}", "404")
+	before := durations.Count()
+	serve(s, http.MethodGet, "/api/globals/missing", nil)
+	if durations.Count() != before+1 {
+		t.Error("request duration was not recorded under its route pattern")
+	}
+
+	rec := serve(s, http.MethodGet, "/metrics", nil)
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("status = %d, Content-Type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	for _, want := range []string{
+		"# TYPE eu_clams_globals_total counter",
+		"# TYPE eu_clams_watcher_lag_bytes gauge",
+		`eu_clams_http_request_duration_seconds_count{method="GET",route="/api/globals/{id}",code="404"}`,
+	} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("metrics lack %s", want)
+		}
+	}
+}
+
+func TestMetricsNeedToken(t *testing.T) {
+	t.Parallel()
+	s := newProtectedWebService(t)
+	if rec := serve(s, http.MethodGet, "/metrics", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("status without token = %d, want 401", rec.Code)
+	}
+	if rec := serve(s, http.MethodGet, "/metrics", bearer(testReadToken)); rec.Code != http.StatusOK {
+		t.Errorf("status with read token = %d, want 200", rec.Code)
+	}
+}
+
+func TestObserveGlobals(t *testing.T) {
+	t.Parallel()
+	m := newServiceMetrics(metrics.NewRegistry())
+	s := newTestWebService(t)
+
+	m.observeGlobals(s.db.Globals)
+	if got := m.globals.With("kill").Value(); got != 2 {
+		t.Errorf("kill globals = %v, want 2", got)
+	}
+	if got := m.hofs.With("craft").Value(); got != 1 {
+		t.Errorf("craft HoFs = %v, want 1", got)
+	}
+	if got := m.globalValue.Value(); got != 1660 {
+		t.Errorf("total value = %v, want 1660", got)
+	}
+
+	newest := s.db.Globals[0].Timestamp
+	for _, g := range s.db.Globals {
+		if g.Timestamp.After(newest) {
+			newest = g.Timestamp
+		}
+	}
+	if got := m.lastGlobal.Value(); got != float64(newest.Unix()) {
+		t.Errorf("last global = %v, want %v", got, newest.Unix())
+	}
+	m.observeTimestamp(newest.Add(-time.Hour))
+	if got := m.lastGlobal.Value(); got != float64(newest.Unix()) {
+		t.Error("an older global moved the last global timestamp back")
+	}
+
+	m.observeScreenshot(nil)
+	if got := m.screenshots.With("success").Value(); got != 1 {
+		t.Errorf("successful screenshots = %v, want 1", got)
+	}
+}
@@ -0,0 +1,506 @@
+package service
+
+import (
+	"encoding/json"
+	"eu-clams/internal/analysis"
+	"eu-clams/internal/export"
+	"eu-clams/internal/model"
+	"eu-clams/internal/storage"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiOperation documents one route of the web API. Response and Body hold a value of the
+// JSON type sent or expected, from which the schema is derived.
+type apiOperation struct {
+	Method      string
+	Path        string
+	Summary     string
+	Params      []apiParam
+	Body        interface{} // JSON request body, nil for none
+	Form        []apiParam  // Fields of a form request body
+	Status      int         // Status of a successful response, 200 by default
+	Response    interface{} // JSON response body, nil for none or ContentType
+	ContentType string      // Media type of a response that is not JSON
+	Versioned   bool        // Wrapped in the /api/v1 envelope, with ETag and error envelope
+	Paged       bool        // A versioned list with total and next_cursor
+	Public      bool        // Served without a token
+}
+
+// apiParam documents a query parameter; path parameters are taken from the path
+type apiParam struct {
+	Name        string
+	Type        string // OpenAPI type, string by default
+	Description string
+}
+
+// Parameters shared by several routes
+var (
+	timeRangeParams = []apiParam{
+		{Name: "from", Description: "Start of the time range: a date, \"2006-01-02 15:04:05\" or RFC3339"},
+		{Name: "to", Description: "End of the time range; a date covers the whole day"},
+	}
+	heatmapParams = []apiParam{
+		{Name: "scope", Description: "universe (everyone's captured globals, the default) or personal"},
+		{Name: "type", Description: "Only globals of this type"},
+		{Name: "target", Description: "Only globals of this target"},
+	}
+	compareParams = func() []apiParam {
+		var params []apiParam
+		params = append(params, apiParam{Name: "preset", Description: "day, week or month: compare the previous with the current period"})
+		for _, side := range []string{"a", "b"} {
+			params = append(params,
+				apiParam{Name: side + "_label", Description: "Label of dataset " + side},
+				apiParam{Name: side + "_from", Description: "Start of dataset " + side},
+				apiParam{Name: side + "_to", Description: "End of dataset " + side},
+				apiParam{Name: side + "_player", Description: "Player of dataset " + side},
+				apiParam{Name: side + "_team", Description: "Team of dataset " + side},
+			)
+		}
+		return params
+	}()
+	targetStatsParams = []apiParam{
+		{Name: "type", Description: "Only targets of this global type"},
+		{Name: "q", Description: "Case-insensitive substring of the target name"},
+		{Name: "min_count", Type: "integer", Description: "Minimum number of globals"},
+		{Name: "sort", Description: "One of " + strings.Join(model.TargetSortFields, ", ")},
+		{Name: "order", Description: "desc (default) or asc"},
+		{Name: "limit", Type: "integer", Description: "Maximum number of targets"},
+	}
+	globalQueryParams = append(append([]apiParam{}, timeRangeParams...),
+		apiParam{Name: "type", Description: "Exact global type, e.g. kill"},
+		apiParam{Name: "target", Description: "Case-insensitive substring of the target name"},
+		apiParam{Name: "location", Description: "Case-insensitive substring of the location"},
+		apiParam{Name: "min_value", Type: "number", Description: "Minimum value in PED"},
+		apiParam{Name: "max_value", Type: "number", Description: "Maximum value in PED"},
+		apiParam{Name: "sort", Description: "One of " + strings.Join(storage.GlobalSortFields, ", ")},
+		apiParam{Name: "order", Description: "desc (default) or asc"},
+		apiParam{Name: "limit", Type: "integer", Description: "Page size, 1 to 1000, 50 by default"},
+		apiParam{Name: "cursor", Description: "next_cursor of the previous page"},
+	)
+	overlayParams = []apiParam{
+		{Name: "profile", Description: "Name of a saved overlay profile"},
+		{Name: "token", Description: "Read token, for browser sources that cannot log in"},
+		{Name: "widgets", Description: "Comma-separated widgets: " + strings.Join(model.OverlayWidgets, ", ")},
+		{Name: "events", Description: "Comma-separated events: " + strings.Join(model.OverlayEvents, ", ")},
+		{Name: "min_value", Type: "number", Description: "Globals below this PED are not shown"},
+		{Name: "highlight_value", Type: "number", Description: "Globals from this PED are highlighted like HoFs"},
+		{Name: "background", Description: "transparent or a colour such as 00ff00 for chroma keying"},
+		{Name: "text_color", Description: "Text colour"},
+		{Name: "accent_color", Description: "Accent colour"},
+		{Name: "hof_color", Description: "Colour of HoFs and highlighted globals"},
+		{Name: "duration", Type: "integer", Description: "Seconds the latest global stays in view, 0 to keep it"},
+		{Name: "ticker_size", Type: "integer", Description: "Globals in the ticker"},
+		{Name: "font_size", Type: "integer", Description: "Font size in pixels"},
+	}
+	eventStreamParams = []apiParam{
+		{Name: "types", Description: "Comma-separated event types, new_global,new_hof,stats_update by default"},
+		{Name: "min_value", Type: "number", Description: "Only globals of at least this PED"},
+		{Name: "global_type", Description: "Only globals of this type, e.g. kill"},
+		{Name: "target", Description: "Only globals whose target contains this, case-insensitive"},
+		{Name: "player", Description: "Only globals of this player"},
+		{Name: "last_event_id", Description: "Resume after this event id, for clients that cannot send Last-Event-ID"},
+		{Name: "token", Description: "Read token, for clients that cannot send headers"},
+	}
+	exportParams = append(append([]apiParam{
+		{Name: "format", Description: "One of " + strings.Join(export.Formats, ", ") + "; csv by default"},
+		{Name: "columns", Description: "Comma-separated CSV columns out of " + strings.Join(export.Columns, ", ")},
+		{Name: "delimiter", Description: "CSV delimiter: a character, tab, comma (default) or semicolon"},
+	}, globalQueryParams[:len(globalQueryParams)-2]...),
+		apiParam{Name: "tier", Description: "global or hof"},
+		apiParam{Name: "limit", Type: "integer", Description: "Maximum number of globals, 1 to 1000; all by default"},
+	)
+	limitParam = apiParam{Name: "limit", Type: "integer", Description: "Maximum number of items"}
+)
+
+// apiOperations lists every route of the web server
+var apiOperations = []apiOperation{
+	{Method: "GET", Path: "/", Summary: "Dashboard page", ContentType: "text/html"},
+	{Method: "GET", Path: "/compare", Summary: "Comparison page", Params: compareParams, ContentType: "text/html"},
+	{Method: "GET", Path: "/overlay", Summary: "Streaming overlay driven by the WebSocket; parameters override the profile", Params: overlayParams, ContentType: "text/html"},
+	{Method: "GET", Path: "/team", Summary: "Team dashboard of a hub", ContentType: "text/html"},
+	{Method: "GET", Path: "/ws", Summary: "WebSocket of live events; see x-websocket-protocol and x-websocket-events", Status: http.StatusSwitchingProtocols},
+	{Method: "GET", Path: "/api/events", Summary: "Server-Sent Events with the JSON of the WebSocket events; the id is resumed with Last-Event-ID, and an event named missed reports events that are lost", Params: eventStreamParams, ContentType: "text/event-stream"},
+	{Method: "GET", Path: "/api/openapi.json", Summary: "This document", Response: map[string]interface{}{}},
+	{Method: "GET", Path: "/api/export", Summary: "Download the globals matching the filters as CSV, JSON, NDJSON or a standalone HTML report", Params: exportParams, ContentType: "application/octet-stream"},
+	{Method: "GET", Path: "/metrics", Summary: "Prometheus metrics in the text exposition format", ContentType: "text/plain"},
+	{Method: "GET", Path: "/login", Summary: "Login form", ContentType: "text/html", Public: true,
+		Params: []apiParam{{Name: "next", Description: "Local path to go to after logging in"}}},
+	{Method: "POST", Path: "/login", Summary: "Check a token and keep it in a cookie, then go to next", Status: http.StatusSeeOther, Public: true,
+		Form: []apiParam{{Name: "token", Description: "One of the configured web tokens"}, {Name: "next", Description: "Local path to go to after logging in"}}},
+	{Method: "POST", Path: "/logout", Summary: "Remove the login cookie", Status: http.StatusSeeOther, Public: true},
+
+	{Method: "GET", Path: "/api/stats", Summary: "Summary statistics with Go field names", Response: model.Stats{}},
+	{Method: "GET", Path: "/api/stats/timeseries", Summary: "Globals per day, week or month", Response: model.TimeSeries{},
+		Params: append([]apiParam{{Name: "interval", Description: "day (default), week or month"}, {Name: "by_type", Type: "boolean", Description: "Add counts per type"}}, timeRangeParams...)},
+	{Method: "GET", Path: "/api/stats/targets", Summary: "Statistics per creature, item and deposit", Params: targetStatsParams, Response: []model.TargetStats{}},
+	{Method: "GET", Path: "/api/stats/locations", Summary: "Statistics per location", Response: []model.LocationStats{}},
+	{Method: "GET", Path: "/api/stats/droughts", Summary: "Gaps and streaks between globals", Response: analysis.DroughtReport{},
+		Params: []apiParam{{Name: "streak_window", Description: "Maximum gap within a streak, e.g. 5m; 10m by default"}}},
+	{Method: "GET", Path: "/api/stats/values", Summary: "Value histogram and brackets", Response: model.ValueDistribution{},
+		Params: []apiParam{{Name: "type", Description: "Only globals of this type"}, {Name: "interval", Description: "Add histograms per day, week or month"}, {Name: "edges", Description: "Histogram edges in PED, e.g. 50,100,500"}}},
+	{Method: "GET", Path: "/api/globals", Summary: "Latest globals, newest first", Params: []apiParam{limitParam}, Response: []model.GlobalEntryJSON{}},
+	{Method: "GET", Path: "/api/globals/{id}", Summary: "A single global", Response: model.GlobalEntryJSON{}},
+	{Method: "PATCH", Path: "/api/globals/{id}", Summary: "Correct a stored global; the body holds only the fields to change", Body: storage.GlobalEdit{}, Response: model.GlobalEntryJSON{}},
+	{Method: "DELETE", Path: "/api/globals/{id}", Summary: "Delete a stored global", Status: http.StatusNoContent},
+	{Method: "GET", Path: "/api/hofs", Summary: "Latest HoFs, newest first", Params: []apiParam{limitParam}, Response: []model.GlobalEntryJSON{}},
+	{Method: "GET", Path: "/api/sessions", Summary: "Detected sessions, newest first", Params: []apiParam{limitParam}, Response: []model.SessionSummary{}},
+	{Method: "GET", Path: "/api/sessions/{id}", Summary: "A session with its globals", Response: model.SessionDetail{}},
+	{Method: "GET", Path: "/api/leaderboards", Summary: "Universe leaderboards, newest period first", Response: leaderboardsResponse{},
+		Params: []apiParam{{Name: "interval", Description: "day (default), week or month"}, {Name: "period", Description: "A single period"}, {Name: "periods", Type: "integer", Description: "Number of latest periods"}, limitParam}},
+	{Method: "GET", Path: "/api/heatmap", Summary: "Globals per weekday and hour", Params: heatmapParams, Response: model.Heatmap{}},
+	{Method: "GET", Path: "/api/heatmap.svg", Summary: "Heatmap as an SVG image", ContentType: "image/svg+xml",
+		Params: append(append([]apiParam{}, heatmapParams...), apiParam{Name: "metric", Description: "count (default) or value"})},
+	{Method: "GET", Path: "/api/compare", Summary: "Comparison of two datasets", Params: compareParams, Response: model.Comparison{}},
+	{Method: "GET", Path: "/api/goals", Summary: "Progress towards every goal", Response: []model.GoalProgress{}},
+	{Method: "POST", Path: "/api/goals", Summary: "Add a goal", Body: model.Goal{}, Status: http.StatusCreated, Response: model.Goal{}},
+	{Method: "DELETE", Path: "/api/goals/{id}", Summary: "Remove a goal", Status: http.StatusNoContent},
+	{Method: "GET", Path: "/api/overlays", Summary: "Saved overlay profiles", Response: []model.OverlayProfile{}},
+	{Method: "PUT", Path: "/api/overlays/{name}", Summary: "Save an overlay profile; settings left out get their defaults", Body: model.OverlayProfile{}, Response: model.OverlayProfile{}},
+	{Method: "DELETE", Path: "/api/overlays/{name}", Summary: "Remove an overlay profile", Status: http.StatusNoContent},
+	{Method: "GET", Path: "/api/achievements", Summary: "Unlocked and locked achievements", Response: achievementsResponse{}},
+	{Method: "GET", Path: "/api/records", Summary: "Personal bests and the globals that set a record", Response: model.RecordBook{}},
+	{Method: "GET", Path: "/api/markups", Summary: "Markups in use and targets without one", Response: model.MarkupReport{}},
+	{Method: "POST", Path: "/api/hub/ingest", Summary: "Push a member's new globals to a hub; needs a member token and hub mode", Body: hubIngestRequest{}, Response: hubIngestResponse{}},
+	{Method: "GET", Path: "/api/hub/members", Summary: "Team members with the globals they pushed", Response: hubMembersResponse{}},
+	{Method: "GET", Path: "/api/hub/globals", Summary: "Latest team globals, newest first",
+		Params: []apiParam{{Name: "member", Description: "Only the globals of one member"}, limitParam}, Response: []model.GlobalEntryJSON{}},
+	{Method: "GET", Path: "/api/hub/leaderboards", Summary: "Team leaderboards ranking members, newest period first",
+		Params: []apiParam{{Name: "interval", Description: "day (default), week or month"}, limitParam}, Response: hubLeaderboardsResponse{}},
+
+	{Method: "GET", Path: "/api/v1/globals", Summary: "Page through the player's globals", Params: append(append([]apiParam{}, globalQueryParams...), apiParam{Name: "tier", Description: "global or hof"}),
+		Response: []model.GlobalEntryJSON{}, Versioned: true, Paged: true},
+	{Method: "GET", Path: "/api/v1/globals/{id}", Summary: "A single global", Response: model.GlobalEntryJSON{}, Versioned: true},
+	{Method: "GET", Path: "/api/v1/hofs", Summary: "Page through the player's HoFs", Params: globalQueryParams, Response: []model.GlobalEntryJSON{}, Versioned: true, Paged: true},
+	{Method: "GET", Path: "/api/v1/stats", Summary: "Summary statistics", Response: model.StatsJSON{}, Versioned: true},
+	{Method: "GET", Path: "/api/v1/stats/targets", Summary: "Statistics per creature, item and deposit", Params: targetStatsParams, Response: []model.TargetStats{}, Versioned: true},
+	{Method: "GET", Path: "/api/v1/stats/locations", Summary: "Statistics per location", Response: []model.LocationStats{}, Versioned: true},
+	{Method: "GET", Path: "/api/v1/sessions", Summary: "Detected sessions, newest first", Response: []model.SessionSummary{}, Versioned: true},
+	{Method: "GET", Path: "/api/v1/sessions/{id}", Summary: "A session with its globals", Response: model.SessionDetail{}, Versioned: true},
+}
+
+// webSocketEvent is an event type with an example of its data
+type webSocketEvent struct {
+	Type string
+	Data interface{}
+}
+
+// webSocketEvents lists the events sent over the WebSocket with the type of their data
+var webSocketEvents = []webSocketEvent{
+	{"new_global", model.GlobalEntryJSON{}},
+	{"new_hof", model.GlobalEntryJSON{}},
+	{"stats_update", model.Stats{}},
+	{"global_updated", model.GlobalEntryJSON{}},
+	{"global_deleted", model.GlobalEntryJSON{}},
+	{"session_started", model.SessionSummary{}},
+	{"session_ended", model.SessionSummary{}},
+	{"goals_updated", []model.GoalProgress{}},
+	{"goal_completed", model.GoalProgress{}},
+	{"achievement_unlocked", model.AchievementUnlock{}},
+	{"personal_record", storage.PersonalRecord{}},
+	{"overlay_updated", model.OverlayProfile{}},
+	{"team_global", model.GlobalEntryJSON{}},
+}
+
+// openAPIDocument is built once on first use
+var openAPIDocument = struct {
+	once sync.Once
+	data []byte
+}{}
+
+// OpenAPIDocument returns the OpenAPI 3 description of the web API as JSON
+func OpenAPIDocument() []byte {
+	openAPIDocument.once.Do(func() {
+		openAPIDocument.data, _ = json.MarshalIndent(buildOpenAPI(), "", "  ")
+	})
+	return openAPIDocument.data
+}
+
+// handleOpenAPI serves the OpenAPI document
+func (s *WebService) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeETagged(w, r, OpenAPIDocument())
+}
+
+// buildOpenAPI describes apiOperations and webSocketEvents
+func buildOpenAPI() map[string]interface{} {
+	b := newSchemaBuilder()
+	paths := make(map[string]interface{})
+
+	for _, op := range apiOperations {
+		operation := map[string]interface{}{"summary": op.Summary}
+
+		var params []interface{}
+		for _, name := range pathParams(op.Path) {
+			params = append(params, map[string]interface{}{
+				"name": name, "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"},
+			})
+		}
+		for _, p := range op.Params {
+			typ := p.Type
+			if typ == "" {
+				typ = "string"
+			}
+			params = append(params, map[string]interface{}{
+				"name": p.Name, "in": "query", "description": p.Description, "schema": map[string]interface{}{"type": typ},
+			})
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+
+		if op.Body != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(b.schema(reflect.TypeOf(op.Body))),
+			}
+		}
+		if len(op.Form) > 0 {
+			properties := make(map[string]interface{})
+			for _, p := range op.Form {
+				properties[p.Name] = map[string]interface{}{"type": "string", "description": p.Description}
+			}
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/x-www-form-urlencoded": map[string]interface{}{
+						"schema": map[string]interface{}{"type": "object", "properties": properties},
+					},
+				},
+			}
+		}
+		if op.Public {
+			operation["security"] = []interface{}{}
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		response := map[string]interface{}{"description": http.StatusText(status)}
+		switch {
+		case op.ContentType != "":
+			response["content"] = map[string]interface{}{op.ContentType: map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}}
+		case op.Response != nil && op.Versioned:
+			response["content"] = jsonContent(envelopeSchema(b.schema(reflect.TypeOf(op.Response)), op.Paged))
+		case op.Response != nil:
+			response["content"] = jsonContent(b.schema(reflect.TypeOf(op.Response)))
+		}
+		responses := map[string]interface{}{strconv.Itoa(status): response}
+		if op.Versioned {
+			responses["default"] = map[string]interface{}{"description": "Error", "content": jsonContent(b.schema(reflect.TypeOf(apiError{})))}
+		} else if op.ContentType == "" && op.Status != http.StatusSwitchingProtocols {
+			responses["4XX"] = map[string]interface{}{
+				"description": "Invalid request or unknown ID",
+				"content":     map[string]interface{}{"text/plain": map[string]interface{}{"schema": map[string]interface{}{"type": "string"}}},
+			}
+		}
+		if !op.Public {
+			responses["401"] = map[string]interface{}{"description": "Missing or invalid token, when web tokens are configured"}
+			if op.Method != http.MethodGet {
+				responses["403"] = map[string]interface{}{"description": "The token does not have the admin scope"}
+			}
+		}
+		operation["responses"] = responses
+
+		item, _ := paths[op.Path].(map[string]interface{})
+		if item == nil {
+			item = make(map[string]interface{})
+			paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = operation
+	}
+
+	// The WebSocket is not an HTTP API, so its messages are described in an extension
+	var eventTypes []interface{}
+	events := make(map[string]interface{})
+	for _, e := range webSocketEvents {
+		eventTypes = append(eventTypes, e.Type)
+		events[e.Type] = b.schema(reflect.TypeOf(e.Data))
+	}
+	b.components["WebSocketEvent"] = map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"op":   map[string]interface{}{"type": "string", "enum": []interface{}{"event"}},
+			"seq":  map[string]interface{}{"type": "integer", "description": "Increases by one with every event of the stream"},
+			"type": map[string]interface{}{"type": "string", "enum": eventTypes},
+			"data": map[string]interface{}{"description": "Event data, see x-websocket-events"},
+			"time": map[string]interface{}{"type": "string", "format": "date-time"},
+		},
+		"required":             []string{"data", "op", "seq", "time", "type"},
+		"additionalProperties": false,
+	}
+	b.components["WebSocketClientMessage"] = b.structSchema(reflect.TypeOf(wsClientMessage{}))
+	b.components["WebSocketControl"] = b.structSchema(reflect.TypeOf(wsControl{}))
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "EU-CLAMS web API",
+			"version":     "1",
+			"description": "Statistics of Entropia Universe globals and HoFs. Routes under /api/v1 wrap responses in an envelope and support ETags; the others are kept for the bundled dashboard.",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": b.components,
+			"securitySchemes": map[string]interface{}{
+				"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+				"cookieAuth": map[string]interface{}{"type": "apiKey", "in": "cookie", "name": authCookieName},
+			},
+		},
+		// Tokens are only required when web_tokens are configured
+		"security":           []interface{}{map[string]interface{}{"bearerAuth": []string{}}, map[string]interface{}{"cookieAuth": []string{}}},
+		"x-websocket-events": events,
+		"x-websocket-protocol": "Clients without a subprotocol receive every event as a WebSocketEvent. Clients that request the " +
+			WebSocketProtocolV2 + " subprotocol get a welcome WebSocketControl with the stream ID and latest seq, and no events until " +
+			"they send a WebSocketClientMessage with op subscribe: its types and filters select the events, and stream and seq of " +
+			"the last event received replay the buffered events since then after the subscribed reply, which reports missed events " +
+			"that are no longer buffered. op unsubscribe pauses the events. The server pings every " + wsPingPeriod.String() +
+			" and closes connections that do not answer within " + wsPongWait.String() + ".",
+	}
+}
+
+// jsonContent is the content of a JSON request or response body
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+// envelopeSchema describes an apiResponse holding data
+func envelopeSchema(data map[string]interface{}, paged bool) map[string]interface{} {
+	properties := map[string]interface{}{"data": data}
+	if paged {
+		properties["total"] = map[string]interface{}{"type": "integer"}
+		properties["next_cursor"] = map[string]interface{}{"type": "string"}
+	}
+	return map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             []string{"data"},
+		"additionalProperties": false,
+	}
+}
+
+// pathParams returns the names of the {parameters} in a path
+func pathParams(path string) []string {
+	var names []string
+	for _, part := range strings.Split(path, "/") {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			names = append(names, strings.Trim(part, "{}"))
+		}
+	}
+	return names
+}
+
+// schemaBuilder derives JSON schemas from Go types the way encoding/json encodes them. Named
+// structs become components; slices, maps and pointers are nullable as they may encode as null.
+type schemaBuilder struct {
+	components map[string]interface{}
+	names      map[reflect.Type]string
+}
+
+// newSchemaBuilder creates a builder without components
+func newSchemaBuilder() *schemaBuilder {
+	return &schemaBuilder{components: make(map[string]interface{}), names: make(map[reflect.Type]string)}
+}
+
+// timeType is encoded as an RFC3339 string
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema returns the schema of a type, registering named structs as components
+func (b *schemaBuilder) schema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return map[string]interface{}{"nullable": true, "allOf": []interface{}{b.schema(t.Elem())}}
+	case reflect.Interface:
+		return map[string]interface{}{}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": b.schema(t.Elem()), "nullable": true}
+	case reflect.Array:
+		return map[string]interface{}{"type": "array", "items": b.schema(t.Elem()), "minItems": t.Len(), "maxItems": t.Len()}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": b.schema(t.Elem()), "nullable": true}
+	case reflect.Struct:
+		if t == timeType {
+			return map[string]interface{}{"type": "string", "format": "date-time"}
+		}
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + b.component(t)}
+	}
+	return map[string]interface{}{}
+}
+
+// component registers a named struct and returns its component name. A name already taken by
+// a type of another package is prefixed with the package name, e.g. StorageGlobalEntry.
+func (b *schemaBuilder) component(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+	name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+	if _, taken := b.components[name]; taken {
+		pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	b.names[t] = name
+	b.components[name] = map[string]interface{}{} // Placeholder for recursive types
+	b.components[name] = b.structSchema(t)
+	return name
+}
+
+// structSchema describes the encoded fields of a struct, flattening embedded structs
+func (b *schemaBuilder) structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	b.addFields(t, properties, &required)
+	sort.Strings(required)
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addFields adds the encoded fields of a struct to properties; fields without omitempty are required
+func (b *schemaBuilder) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			b.addFields(field.Type, properties, required)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = b.schema(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
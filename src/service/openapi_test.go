@@ -0,0 +1,357 @@
+package service
+
+import (
+	"encoding/json"
+	"eu-clams/internal/analysis"
+	"eu-clams/internal/logger"
+	"eu-clams/internal/model"
+	"eu-clams/internal/storage"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// openAPIDoc is the decoded document with a minimal validator for the schemas it uses
+type openAPIDoc map[string]interface{}
+
+// loadOpenAPI decodes the document served at /api/openapi.json
+func loadOpenAPI(t *testing.T, s *WebService) openAPIDoc {
+	t.Helper()
+	rec := serve(s, http.MethodGet, "/api/openapi.json", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("openapi.json status = %d, want 200", rec.Code)
+	}
+	var doc openAPIDoc
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decoding openapi.json: %v", err)
+	}
+	return doc
+}
+
+// operation returns the operation of a path and method, or nil
+func (d openAPIDoc) operation(path, method string) map[string]interface{} {
+	item, _ := d["paths"].(map[string]interface{})[path].(map[string]interface{})
+	op, _ := item[strings.ToLower(method)].(map[string]interface{})
+	return op
+}
+
+// responseSchema returns the JSON schema of a response of an operation, or nil
+func responseSchema(op map[string]interface{}, status string) map[string]interface{} {
+	responses, _ := op["responses"].(map[string]interface{})
+	response, _ := responses[status].(map[string]interface{})
+	content, _ := response["content"].(map[string]interface{})
+	media, _ := content["application/json"].(map[string]interface{})
+	schema, _ := media["schema"].(map[string]interface{})
+	return schema
+}
+
+// validate checks a decoded JSON value against a schema, returning the first mismatch
+func (d openAPIDoc) validate(schema map[string]interface{}, value interface{}, at string) error {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/components/schemas/")
+		resolved, ok := d["components"].(map[string]interface{})["schemas"].(map[string]interface{})[name].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: unresolved $ref %s", at, ref)
+		}
+		return d.validate(resolved, value, at)
+	}
+	if value == nil && (schema["nullable"] == true || len(schema) == 0 || schema["description"] != nil && schema["type"] == nil) {
+		return nil
+	}
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			if err := d.validate(sub.(map[string]interface{}), value, at); err != nil {
+				return err
+			}
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			found = found || e == value
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", at, value, enum)
+		}
+	}
+
+	switch schema["type"] {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: %T is not an object", at, value)
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := obj[name.(string)]; !ok {
+				return fmt.Errorf("%s: missing required property %s", at, name)
+			}
+		}
+		for name, v := range obj {
+			if prop, ok := properties[name].(map[string]interface{}); ok {
+				if err := d.validate(prop, v, at+"."+name); err != nil {
+					return err
+				}
+				continue
+			}
+			switch extra := schema["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					return fmt.Errorf("%s: unexpected property %s", at, name)
+				}
+			case map[string]interface{}:
+				if err := d.validate(extra, v, at+"."+name); err != nil {
+					return err
+				}
+			}
+		}
+	case "array":
+		arr, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: %T is not an array", at, value)
+		}
+		if n, ok := schema["minItems"].(float64); ok && len(arr) < int(n) {
+			return fmt.Errorf("%s: %d items, want at least %v", at, len(arr), n)
+		}
+		if n, ok := schema["maxItems"].(float64); ok && len(arr) > int(n) {
+			return fmt.Errorf("%s: %d items, want at most %v", at, len(arr), n)
+		}
+		items, _ := schema["items"].(map[string]interface{})
+		for i, v := range arr {
+			if err := d.validate(items, v, at+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: %T is not a string", at, value)
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339, str); err != nil {
+				return fmt.Errorf("%s: %q is not a date-time", at, str)
+			}
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != float64(int64(n)) {
+			return fmt.Errorf("%s: %v is not an integer", at, value)
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: %T is not a number", at, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: %T is not a boolean", at, value)
+		}
+	}
+	return nil
+}
+
+// validateBody decodes a response body and validates it against a schema
+func (d openAPIDoc) validateBody(t *testing.T, schema map[string]interface{}, body []byte, name string) {
+	t.Helper()
+	if schema == nil {
+		t.Errorf("%s: no JSON schema in the document", name)
+		return
+	}
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		t.Errorf("%s: decoding response: %v", name, err)
+		return
+	}
+	if err := d.validate(schema, value, name); err != nil {
+		t.Error(err)
+	}
+}
+
+// registeredRoutes returns the patterns passed to HandleFunc in a source file of this package
+func registeredRoutes(t *testing.T, file string) []string {
+	t.Helper()
+	f, err := parser.ParseFile(token.NewFileSet(), file, nil, 0)
+	if err != nil {
+		t.Fatalf("parsing %s: %v", file, err)
+	}
+	var patterns []string
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) == 0 {
+			return true
+		}
+		if sel, ok := call.Fun.(*ast.SelectorExpr); ok && sel.Sel.Name == "HandleFunc" {
+			if lit, ok := call.Args[0].(*ast.BasicLit); ok {
+				pattern, _ := strconv.Unquote(lit.Value)
+				patterns = append(patterns, pattern)
+			}
+		}
+		return true
+	})
+	return patterns
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	t.Parallel()
+	doc := loadOpenAPI(t, newTestWebService(t))
+
+	registered := make(map[string]bool)
+	for _, file := range []string{"web_service.go", "api_v1.go"} {
+		for _, pattern := range registeredRoutes(t, file) {
+			method, path, found := strings.Cut(pattern, " ")
+			if !found {
+				method, path = http.MethodGet, pattern
+			}
+			if path == "/api/v1/" {
+				continue // Catch-all for unknown versioned routes
+			}
+			registered[method+" "+path] = true
+			if doc.operation(path, method) == nil {
+				t.Errorf("route %s is not documented", pattern)
+			}
+		}
+	}
+
+	for path, item := range doc["paths"].(map[string]interface{}) {
+		for method := range item.(map[string]interface{}) {
+			if !registered[strings.ToUpper(method)+" "+path] {
+				t.Errorf("documented %s %s is not a route", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestOpenAPIMatchesResponses(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+	doc := loadOpenAPI(t, s)
+
+	start := s.db.Globals[0].Timestamp
+	s.db.Sessions = []storage.Session{{ID: "s1", Start: start, End: start.Add(3 * time.Hour), Lines: 40}}
+	ids := map[string]string{
+		"/api/globals/{id}":     "a",
+		"/api/v1/globals/{id}":  "a",
+		"/api/sessions/{id}":    "s1",
+		"/api/v1/sessions/{id}": "s1",
+	}
+
+	var paths []string
+	for path := range doc["paths"].(map[string]interface{}) {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		op := doc.operation(path, http.MethodGet)
+		schema := responseSchema(op, "200")
+		if schema == nil {
+			continue // Pages, images and the WebSocket
+		}
+		target := path
+		if strings.Contains(path, "{id}") {
+			id, ok := ids[path]
+			if !ok {
+				t.Errorf("no fixture ID for %s", path)
+				continue
+			}
+			target = strings.Replace(path, "{id}", id, 1)
+		}
+		rec := serve(s, http.MethodGet, target, nil)
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s status = %d, want 200: %s", target, rec.Code, rec.Body)
+			continue
+		}
+		doc.validateBody(t, schema, rec.Body.Bytes(), "GET "+target)
+	}
+
+	// Versioned errors use the documented envelope
+	rec := serve(s, http.MethodGet, "/api/v1/globals?limit=0", nil)
+	doc.validateBody(t, responseSchema(doc.operation("/api/v1/globals", http.MethodGet), "default"), rec.Body.Bytes(), "invalid limit")
+}
+
+func TestOpenAPIMatchesEdits(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+	doc := loadOpenAPI(t, s)
+	if err := s.db.SaveDatabase(filepath.Join(t.TempDir(), "db.yaml"), logger.New()); err != nil {
+		t.Fatalf("saving database: %v", err)
+	}
+
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Host = "localhost"
+		rec := httptest.NewRecorder()
+		s.routes().ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send(http.MethodPost, "/api/goals", `{"name":"Ten kills","metric":"globals","threshold":10,"type":"kill"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("POST /api/goals status = %d, want 201: %s", rec.Code, rec.Body)
+	}
+	doc.validateBody(t, responseSchema(doc.operation("/api/goals", http.MethodPost), "201"), rec.Body.Bytes(), "POST /api/goals")
+
+	rec = send(http.MethodPut, "/api/overlays/stream", `{"widgets":["latest"],"background":"00ff00"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT /api/overlays/stream status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	doc.validateBody(t, responseSchema(doc.operation("/api/overlays/{name}", http.MethodPut), "200"), rec.Body.Bytes(), "PUT /api/overlays/stream")
+
+	rec = send(http.MethodPatch, "/api/globals/a", `{"value":120}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PATCH /api/globals/a status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	doc.validateBody(t, responseSchema(doc.operation("/api/globals/{id}", http.MethodPatch), "200"), rec.Body.Bytes(), "PATCH /api/globals/a")
+
+	if rec = send(http.MethodDelete, "/api/globals/b", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("DELETE /api/globals/b status = %d, want 204", rec.Code)
+	}
+}
+
+func TestOpenAPIMatchesEvents(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+	doc := loadOpenAPI(t, s)
+	events := doc["x-websocket-events"].(map[string]interface{})
+
+	// Events with data from the fixture where the database has some
+	start := s.db.Globals[0].Timestamp
+	s.db.Sessions = []storage.Session{{ID: "s1", Start: start, End: start.Add(3 * time.Hour), Lines: 40}}
+	samples := map[string]interface{}{
+		"new_global":      s.db.Globals[0],
+		"new_hof":         &s.db.Globals[2],
+		"stats_update":    s.db.GetStatsData(),
+		"global_updated":  s.db.Globals[1],
+		"global_deleted":  &s.db.Globals[1],
+		"goals_updated":   s.db.GetGoalProgress(analysis.WallClockNow()),
+		"session_ended":   s.db.GetSessions()[0],
+		"overlay_updated": model.DefaultOverlayProfile(),
+	}
+
+	envelope := map[string]interface{}{"$ref": "#/components/schemas/WebSocketEvent"}
+	for _, e := range webSocketEvents {
+		data, ok := samples[e.Type]
+		if !ok {
+			data = e.Data
+		}
+		payload, err := json.Marshal(newWSEvent(e.Type, data))
+		if err != nil {
+			t.Fatalf("encoding %s: %v", e.Type, err)
+		}
+		doc.validateBody(t, envelope, payload, e.Type)
+
+		var event struct {
+			Data json.RawMessage `json:"data"`
+		}
+		json.Unmarshal(payload, &event)
+		schema, _ := events[e.Type].(map[string]interface{})
+		doc.validateBody(t, schema, event.Data, e.Type+" data")
+	}
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"eu-clams/internal/logger"
+	"eu-clams/internal/model"
+	"eu-clams/internal/storage"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// errOverlayNotFound is returned for an overlay profile that does not exist
+var errOverlayNotFound = errors.New("overlay profile not found")
+
+// SaveOverlay stores an overlay profile, saves the database and tells overlays showing it to
+// reload, holding the database lock
+func SaveOverlay(db *storage.EntropyDB, profile model.OverlayProfile, log *logger.Logger) (model.OverlayProfile, error) {
+	db.Lock()
+	defer db.Unlock()
+	saved, created, err := db.SaveOverlay(profile)
+	if err != nil {
+		return model.OverlayProfile{}, err
+	}
+	if log != nil {
+		if created {
+			log.Info("Overlay profile %s added", saved.Name)
+		} else {
+			log.Info("Overlay profile %s updated", saved.Name)
+		}
+	}
+	BroadcastToWebServices("overlay_updated", saved)
+	return saved, saveEditedDatabase(db, log)
+}
+
+// RemoveOverlay removes an overlay profile and saves the database, holding its lock
+func RemoveOverlay(db *storage.EntropyDB, name string, log *logger.Logger) error {
+	db.Lock()
+	defer db.Unlock()
+	if !db.RemoveOverlay(name) {
+		return fmt.Errorf("%w: %s", errOverlayNotFound, name)
+	}
+	if log != nil {
+		log.Info("Overlay profile %s removed", name)
+	}
+	return saveEditedDatabase(db, log)
+}
+
+// overlaySettings returns the overlay settings of a request: those of the profile named by
+// the profile parameter, or the defaults, with the other parameters overriding them. The
+// caller holds the database read lock.
+func overlaySettings(db *storage.EntropyDB, query url.Values) (model.OverlayProfile, error) {
+	profile := model.DefaultOverlayProfile()
+	if name := query.Get("profile"); name != "" {
+		saved, ok := db.GetOverlay(name)
+		if !ok {
+			return model.OverlayProfile{}, fmt.Errorf("%w: %s", errOverlayNotFound, name)
+		}
+		profile = saved.WithDefaults()
+	}
+
+	lists := map[string]*[]string{"widgets": &profile.Widgets, "events": &profile.Events}
+	for key, list := range lists {
+		if query.Has(key) {
+			*list = splitList(query.Get(key))
+		}
+	}
+	colors := map[string]*string{
+		"background":   &profile.Background,
+		"text_color":   &profile.TextColor,
+		"accent_color": &profile.AccentColor,
+		"hof_color":    &profile.HofColor,
+	}
+	for key, color := range colors {
+		if v := query.Get(key); v != "" {
+			*color = model.NormalizeOverlayColor(v)
+		}
+	}
+	numbers := map[string]*float64{"min_value": &profile.MinValue, "highlight_value": &profile.HighlightValue}
+	for key, number := range numbers {
+		if v := query.Get(key); v != "" {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return model.OverlayProfile{}, fmt.Errorf("invalid %s %q", key, v)
+			}
+			*number = n
+		}
+	}
+	ints := map[string]*int{"duration": &profile.Duration, "ticker_size": &profile.TickerSize, "font_size": &profile.FontSize}
+	for key, number := range ints {
+		if v := query.Get(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return model.OverlayProfile{}, fmt.Errorf("invalid %s %q", key, v)
+			}
+			*number = n
+		}
+	}
+
+	if err := model.ValidateOverlaySettings(profile); err != nil {
+		return model.OverlayProfile{}, err
+	}
+	return profile, nil
+}
+
+// splitList splits a comma-separated parameter, skipping empty items
+func splitList(s string) []string {
+	items := []string{}
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// overlayPage is the data of the overlay template. The settings and initial state are also
+// handed to the page's script as JSON.
+type overlayPage struct {
+	Settings model.OverlayProfile    `json:"settings"`
+	Player   string                  `json:"player"`  // Only the player's globals count towards the session
+	Globals  []model.GlobalEntryJSON `json:"globals"` // Shown globals, newest first
+	Session  *model.SessionSummary   `json:"session"` // Open session, nil between sessions
+}
+
+// Shows returns whether the overlay shows a widget
+func (p overlayPage) Shows(widget string) bool {
+	for _, w := range p.Settings.Widgets {
+		if w == widget {
+			return true
+		}
+	}
+	return false
+}
+
+// handleOverlay shows the streaming overlay, e.g. as a browser source in OBS
+func (s *WebService) handleOverlay(w http.ResponseWriter, r *http.Request) {
+	s.db.RLock()
+	settings, err := overlaySettings(s.db, r.URL.Query())
+	if err != nil {
+		s.db.RUnlock()
+		writeEditError(w, err)
+		return
+	}
+
+	page := overlayPage{Settings: settings, Player: s.db.PlayerName, Globals: []model.GlobalEntryJSON{}}
+	for _, g := range s.db.GetOverlayGlobals(settings) {
+		page.Globals = append(page.Globals, toGlobalEntryJSON(g))
+	}
+	if session, ok := s.db.GetOpenSession(); ok {
+		page.Session = &session
+	}
+	s.db.RUnlock()
+
+	// Set headers to prevent caching
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "text/html")
+
+	if err := s.templates.ExecuteTemplate(w, "overlay.html", page); err != nil {
+		s.log.Error("Failed to render template: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
+
+// handleOverlays lists the saved overlay profiles
+func (s *WebService) handleOverlays(w http.ResponseWriter, r *http.Request) {
+	// Set headers to prevent caching
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "application/json")
+
+	s.db.RLock()
+	overlays := s.db.GetOverlays()
+	s.db.RUnlock()
+	json.NewEncoder(w).Encode(overlays)
+}
+
+// handleSaveOverlay saves the overlay profile in the JSON request body under the name in the
+// path; settings the body leaves out keep their defaults
+func (s *WebService) handleSaveOverlay(w http.ResponseWriter, r *http.Request) {
+	profile := model.DefaultOverlayProfile()
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	profile.Name = r.PathValue("name")
+
+	saved, err := SaveOverlay(s.db, profile, s.log)
+	if err != nil {
+		writeEditError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+// handleRemoveOverlay removes an overlay profile
+func (s *WebService) handleRemoveOverlay(w http.ResponseWriter, r *http.Request) {
+	if err := RemoveOverlay(s.db, r.PathValue("name"), s.log); err != nil {
+		writeEditError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"eu-clams/internal/model"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestOverlayPage(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+	if _, _, err := s.db.SaveOverlay(model.OverlayProfile{Name: "stream", Widgets: []string{model.OverlayWidgetTicker}, MinValue: 80}); err != nil {
+		t.Fatalf("SaveOverlay: %v", err)
+	}
+
+	rec := serve(s, http.MethodGet, "/overlay?profile=stream&background=00ff00", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+	body := rec.Body.String()
+	if !strings.Contains(body, `id="ticker"`) || strings.Contains(body, `id="latest"`) {
+		t.Error("overlay does not show only the profile's ticker")
+	}
+	for _, want := range []string{`"background":"#00ff00"`, `"min_value":80`, `"target":"Atrox"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("overlay lacks %s", want)
+		}
+	}
+	if strings.Contains(body, `"target":"Daikiba"`) {
+		t.Error("overlay shows a global below the profile's minimum value")
+	}
+
+	tests := []struct {
+		target string
+		status int
+	}{
+		{"/overlay", http.StatusOK},
+		{"/overlay?widgets=latest,session&events=hof&duration=0", http.StatusOK},
+		{"/overlay?profile=missing", http.StatusNotFound},
+		{"/overlay?widgets=clock", http.StatusBadRequest},
+		{"/overlay?background=red%3Bx", http.StatusBadRequest},
+		{"/overlay?ticker_size=many", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if rec := serve(s, http.MethodGet, tt.target, nil); rec.Code != tt.status {
+			t.Errorf("GET %s status = %d, want %d", tt.target, rec.Code, tt.status)
+		}
+	}
+}
+
+func TestOverlayTokenParameter(t *testing.T) {
+	t.Parallel()
+	s := newProtectedWebService(t)
+
+	tests := []struct {
+		method string
+		target string
+		status int
+	}{
+		{http.MethodGet, "/overlay?token=" + testReadToken, http.StatusOK},
+		{http.MethodGet, "/overlay?token=wrong-token-0123456789", http.StatusSeeOther},
+		{http.MethodGet, "/api/stats?token=" + testReadToken, http.StatusUnauthorized},
+		{http.MethodPut, "/api/overlays/stream?token=" + testAdminToken, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if rec := serve(s, tt.method, tt.target, nil); rec.Code != tt.status {
+			t.Errorf("%s %s status = %d, want %d", tt.method, tt.target, rec.Code, tt.status)
+		}
+	}
+}
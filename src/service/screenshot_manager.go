@@ -1,6 +1,7 @@
 package service
 
 import (
+	"eu-clams/internal/model"
 	"eu-clams/internal/storage"
 	"eu-clams/pkg/screenshot"
 	"fmt"
@@ -77,12 +78,13 @@ func (sm *ScreenshotManager) TakeScreenshotForGlobal(entry *storage.GlobalEntry)
 		}
 	} // Take the screenshot and get the full window title
 	filePath, fullWindowTitle, err := screenshot.TakeScreenshot(sm.gameWindowTitle, absScreenshotDir, prefix)
+	serviceMetrics.observeScreenshot(err)
 	if err == nil {
 		sm.lastScreenshot = time.Now()
 
 		// If we got a window title, try to extract location if the global entry doesn't have one
 		if fullWindowTitle != "" && entry.Location == "" {
-			if location := screenshot.ExtractLocationFromWindowTitle(fullWindowTitle); location != "" {
+			if location := model.NormalizeLocation(screenshot.ExtractLocationFromWindowTitle(fullWindowTitle)); location != "" {
 				entry.Location = location
 			}
 		}
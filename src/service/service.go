@@ -4,13 +4,13 @@ package service
 type Service interface {
 	// Initialize initializes the service
 	Initialize() error
-	
+
 	// Name returns the name of the service
 	Name() string
-	
+
 	// Run executes the service logic
 	Run() error
-	
+
 	// Stop gracefully stops the service
 	Stop() error
 }
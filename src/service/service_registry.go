@@ -1,6 +1,7 @@
 package service
 
 import (
+	"eu-clams/internal/storage"
 	"fmt"
 	"sync"
 )
@@ -9,6 +10,7 @@ import (
 // This is useful for broadcasting events across services
 type ServiceRegistry struct {
 	webServices map[string]*WebService
+	hubClient   *HubClient
 	mu          sync.RWMutex
 }
 
@@ -33,13 +35,41 @@ func UnregisterWebService(name string) {
 	delete(registry.webServices, name)
 }
 
-// BroadcastToWebServices sends an event to all registered web services
+// BroadcastToWebServices sends an event to all registered web services. Broadcasting does
+// not wait for clients, so events are numbered in the order they are broadcast.
 func BroadcastToWebServices(eventType string, data interface{}) {
 	registry.mu.RLock()
 	defer registry.mu.RUnlock()
 
 	for _, service := range registry.webServices {
-		go service.BroadcastEvent(eventType, data)
+		service.BroadcastEvent(eventType, data)
+	}
+}
+
+// RegisterHubClient sets the client that pushes new globals to a team hub
+func RegisterHubClient(client *HubClient) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	registry.hubClient = client
+}
+
+// UnregisterHubClient removes a hub client if it is the registered one
+func UnregisterHubClient(client *HubClient) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if registry.hubClient == client {
+		registry.hubClient = nil
+	}
+}
+
+// PushToHub queues globals for the registered hub client; without one it does nothing
+func PushToHub(entries []storage.GlobalEntry) {
+	registry.mu.RLock()
+	client := registry.hubClient
+	registry.mu.RUnlock()
+
+	if client != nil {
+		client.Enqueue(entries)
 	}
 }
 
@@ -0,0 +1,28 @@
+package service
+
+import (
+	"eu-clams/internal/storage"
+)
+
+// reportSessionChanges logs and broadcasts sessions that were closed or opened since
+// openBefore was the open session (if wasOpen)
+func (s *DataProcessorService) reportSessionChanges(openBefore storage.Session, wasOpen bool) {
+	openNow, isOpen := s.db.OpenSession()
+	if wasOpen && isOpen && openNow.ID == openBefore.ID {
+		return
+	}
+
+	if wasOpen {
+		if detail, ok := s.db.GetSession(openBefore.ID); ok {
+			s.log.Info("Session %s ended after %.0f minutes with %d globals worth %.2f PED",
+				detail.ID, detail.DurationSeconds/60, detail.Globals, detail.TotalValue)
+			BroadcastToWebServices("session_ended", detail.SessionSummary)
+		}
+	}
+	if isOpen {
+		if detail, ok := s.db.GetSession(openNow.ID); ok {
+			s.log.Info("Session %s started", detail.ID)
+			BroadcastToWebServices("session_started", detail.SessionSummary)
+		}
+	}
+}
@@ -1,13 +1,17 @@
 package service
 
 import (
+	"eu-clams/internal/analysis"
 	"eu-clams/internal/logger"
+	"eu-clams/internal/model"
 	"eu-clams/internal/stats"
 	"eu-clams/internal/storage"
 	"fmt"
+	"time"
 )
 
-// StatsService handles statistics generation and reporting
+// StatsService handles statistics generation and reporting. Its methods read the database
+// under its read lock.
 type StatsService struct {
 	*BaseService
 	log        *logger.Logger
@@ -51,11 +55,14 @@ func (s *StatsService) Run() error {
 
 	// Generate statistics
 	s.log.Info("Generating statistics for player: %s", s.playerName)
+	s.db.RLock()
 	statsData := s.db.GetStatsData()
+	s.db.RUnlock()
 	// Format and print report
 	statsReport := stats.FormatStatsReport(statsData, s.playerName, s.teamName)
 	fmt.Println("\n--- PLAYER STATISTICS ---")
 	fmt.Println(statsReport)
+	fmt.Println(stats.FormatDroughtReport(s.AnalyzeDroughts(analysis.DefaultStreakWindow)))
 
 	return nil
 }
@@ -63,9 +70,94 @@ func (s *StatsService) Run() error {
 // GenerateStats returns statistics data
 func (s *StatsService) GenerateStats() stats.Stats {
 	s.log.Info("Generating statistics for player: %s", s.playerName)
+	s.db.RLock()
+	defer s.db.RUnlock()
 	return s.db.GetStatsData()
 }
 
+// GenerateTimeSeries returns the player's globals aggregated per day, week or month
+func (s *StatsService) GenerateTimeSeries(interval string, from, to time.Time, byType bool) (model.TimeSeries, error) {
+	s.log.Info("Generating %s time series for player: %s", interval, s.playerName)
+	s.db.RLock()
+	defer s.db.RUnlock()
+	return s.db.GetTimeSeries(interval, from, to, byType)
+}
+
+// GenerateValueDistribution returns the value histogram and brackets of the player's globals,
+// optionally for a single type and per day, week or month
+func (s *StatsService) GenerateValueDistribution(interval, typ string) (model.ValueDistribution, error) {
+	s.log.Info("Generating value distribution for player: %s", s.playerName)
+	s.db.RLock()
+	defer s.db.RUnlock()
+	return s.db.GetValueDistribution(interval, typ, nil)
+}
+
+// AnalyzeDroughts returns the gaps and streaks between the player's globals up to now
+func (s *StatsService) AnalyzeDroughts(streakWindow time.Duration) analysis.DroughtReport {
+	s.log.Info("Analyzing droughts for player: %s", s.playerName)
+	s.db.RLock()
+	defer s.db.RUnlock()
+	return s.db.GetDroughtReport(analysis.WallClockNow(), streakWindow)
+}
+
+// GenerateLeaderboards ranks everyone's captured globals per day, week or month, newest period first
+func (s *StatsService) GenerateLeaderboards(interval string, limit int) ([]model.Leaderboard, error) {
+	s.log.Info("Generating %s leaderboards", interval)
+	s.db.RLock()
+	defer s.db.RUnlock()
+	return s.db.GetLeaderboards(interval, limit)
+}
+
+// GetSessions returns summaries of the detected sessions, newest first
+func (s *StatsService) GetSessions() []model.SessionSummary {
+	s.db.RLock()
+	defer s.db.RUnlock()
+	return s.db.GetSessions()
+}
+
+// GetSession returns a session with its globals
+func (s *StatsService) GetSession(id string) (model.SessionDetail, bool) {
+	s.db.RLock()
+	defer s.db.RUnlock()
+	return s.db.GetSession(id)
+}
+
+// Compare puts the statistics of two time ranges or identities side by side
+func (s *StatsService) Compare(a, b model.DatasetFilter) model.Comparison {
+	s.log.Info("Comparing %s with %s", stats.DatasetDescription(a), stats.DatasetDescription(b))
+	s.db.RLock()
+	defer s.db.RUnlock()
+	return s.db.CompareDatasets(a, b)
+}
+
+// GetGoalProgress returns the progress towards every goal
+func (s *StatsService) GetGoalProgress() []model.GoalProgress {
+	s.db.RLock()
+	defer s.db.RUnlock()
+	return s.db.GetGoalProgress(analysis.WallClockNow())
+}
+
+// GetAchievements returns the unlocked and the still locked achievements
+func (s *StatsService) GetAchievements() ([]model.AchievementUnlock, []model.Achievement) {
+	s.db.RLock()
+	defer s.db.RUnlock()
+	return s.db.GetAchievements()
+}
+
+// GetRecordBook returns the personal bests and the globals that set a record
+func (s *StatsService) GetRecordBook() model.RecordBook {
+	s.db.RLock()
+	defer s.db.RUnlock()
+	return s.db.GetRecordBook()
+}
+
+// GetMarkupReport returns the markups in use and the targets still valued at TT
+func (s *StatsService) GetMarkupReport() model.MarkupReport {
+	s.db.RLock()
+	defer s.db.RUnlock()
+	return s.db.GetMarkupReport()
+}
+
 // FormatStatsReport formats a statistics report as a string
 func (s *StatsService) FormatStatsReport(statsData stats.Stats) string {
 	return stats.FormatStatsReport(statsData, s.playerName, s.teamName)
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"eu-clams/templates"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// pageTemplates are the HTML templates of the dashboard
+var pageTemplates = []string{"index.html", "compare.html", "login.html", "overlay.html", "team.html"}
+
+// themeFS serves files from a theme directory and falls back to the embedded files for
+// those the theme does not replace
+type themeFS struct {
+	theme fs.FS // nil without a theme directory
+	base  fs.FS
+}
+
+// Open opens a file of the theme, or of the base if the theme lacks it
+func (t themeFS) Open(name string) (fs.File, error) {
+	if t.theme != nil {
+		f, err := t.theme.Open(name)
+		if err == nil {
+			return f, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return t.base.Open(name)
+}
+
+// SetThemeDir sets a directory whose files replace the embedded templates and static
+// files of the same path, e.g. static/css/styles.css. Templates are read by Initialize;
+// static files are served from the directory as they change.
+func (s *WebService) SetThemeDir(dir string) error {
+	if dir != "" {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("web theme directory: %w", err)
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("web theme directory %s is not a directory", dir)
+		}
+	}
+	s.themeDir = dir
+	return nil
+}
+
+// assets returns the templates and static files, with the theme's files first
+func (s *WebService) assets() fs.FS {
+	assets := themeFS{base: templates.FS}
+	if s.themeDir != "" {
+		assets.theme = os.DirFS(s.themeDir)
+	}
+	return assets
+}
+
+// loadTemplates parses the page templates
+func (s *WebService) loadTemplates() error {
+	tmpl, err := template.New("index.html").Funcs(templateFuncs).ParseFS(s.assets(), pageTemplates...)
+	if err != nil {
+		return fmt.Errorf("failed to parse templates: %w", err)
+	}
+	s.templates = tmpl
+	return nil
+}
+
+// ExportTheme copies the embedded templates and static files into dir as a starting point
+// for web_theme_dir, keeping files that already exist there, and returns the files written
+func ExportTheme(dir string) ([]string, error) {
+	var written []string
+	err := fs.WalkDir(templates.FS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(path))
+		if _, err := os.Stat(target); err == nil {
+			return nil
+		}
+		data, err := fs.ReadFile(templates.FS, path)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(target, data, 0644); err != nil {
+			return err
+		}
+		written = append(written, target)
+		return nil
+	})
+	return written, err
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEmbeddedAssets(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+
+	for _, target := range []string{"/", "/compare", "/login", "/static/css/styles.css", "/static/js/main.js"} {
+		rec := serve(s, http.MethodGet, target, nil)
+		if rec.Code != http.StatusOK || rec.Body.Len() == 0 {
+			t.Errorf("GET %s status = %d with %d bytes, want the embedded file", target, rec.Code, rec.Body.Len())
+		}
+	}
+	if rec := serve(s, http.MethodGet, "/static/missing.css", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("missing static file status = %d, want 404", rec.Code)
+	}
+}
+
+func TestThemeDirOverridesFiles(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	writeFile := func(path, content string) {
+		t.Helper()
+		path = filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile("static/css/styles.css", "body { color: hotpink; }")
+	writeFile("login.html", `<p>Themed login for {{.Next}}</p>`)
+
+	s := newTestWebService(t)
+	if err := s.SetThemeDir(dir); err != nil {
+		t.Fatalf("SetThemeDir: %v", err)
+	}
+	if err := s.loadTemplates(); err != nil {
+		t.Fatalf("loading templates: %v", err)
+	}
+
+	if body := serve(s, http.MethodGet, "/static/css/styles.css", nil).Body.String(); !strings.Contains(body, "hotpink") {
+		t.Errorf("styles.css = %q, want the theme's", body)
+	}
+	if body := serve(s, http.MethodGet, "/login?next=/compare", nil).Body.String(); !strings.Contains(body, "Themed login for /compare") {
+		t.Errorf("login page = %q, want the theme's template", body)
+	}
+	// Files the theme lacks come from the binary
+	if rec := serve(s, http.MethodGet, "/static/js/main.js", nil); rec.Code != http.StatusOK {
+		t.Errorf("main.js status = %d, want the embedded file", rec.Code)
+	}
+	if body := serve(s, http.MethodGet, "/", nil).Body.String(); !strings.Contains(body, "EU-CLAMS Statistics") {
+		t.Error("index page is not the embedded template")
+	}
+
+	if err := s.SetThemeDir(filepath.Join(dir, "missing")); err == nil {
+		t.Error("SetThemeDir with a missing directory succeeded")
+	}
+}
+
+func TestExportTheme(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	kept := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(kept, []byte("mine"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	written, err := ExportTheme(dir)
+	if err != nil {
+		t.Fatalf("ExportTheme: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "static", "css", "styles.css")); err != nil {
+		t.Errorf("styles.css not exported: %v", err)
+	}
+	for _, path := range written {
+		if path == kept || strings.HasSuffix(path, ".go") {
+			t.Errorf("exported %s, want existing files and sources skipped", path)
+		}
+	}
+	if data, _ := os.ReadFile(kept); string(data) != "mine" {
+		t.Errorf("existing index.html was overwritten with %q", data)
+	}
+
+	// The export is a valid theme
+	s := newTestWebService(t)
+	if err := s.SetThemeDir(dir); err != nil {
+		t.Fatalf("SetThemeDir: %v", err)
+	}
+	os.Remove(kept)
+	if err := s.loadTemplates(); err != nil {
+		t.Errorf("loading the exported theme: %v", err)
+	}
+}
@@ -0,0 +1,373 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"eu-clams/internal/config"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Scopes of a web token
+const (
+	ScopeRead   = "read"   // View pages, the API and live events
+	ScopeMember = "member" // Also push globals to a hub, as the member the token is named after
+	ScopeAdmin  = "admin"  // Also edit globals and goals
+)
+
+// Login cookie and token requirements
+const (
+	authCookieName  = "eu_clams_token"
+	authCookieDays  = 30
+	minWebTokenSize = 16
+)
+
+// webAuth is the access control of the web service. Without tokens every request is
+// allowed with the admin scope, as before tokens existed.
+type webAuth struct {
+	tokens  []config.WebToken
+	origins map[string]bool // Normalized origins allowed besides the server's own
+}
+
+// SetAuth sets the tokens that may use the web service and the other sites allowed to call
+// the API and open WebSockets. It may be called while the server is running.
+func (s *WebService) SetAuth(tokens []config.WebToken, allowedOrigins []string) error {
+	auth, err := newWebAuth(tokens, allowedOrigins)
+	if err != nil {
+		return err
+	}
+	s.authLock.Lock()
+	s.auth = auth
+	s.authLock.Unlock()
+	return nil
+}
+
+// ValidateWebAuth checks configured tokens and origins the way SetAuth does
+func ValidateWebAuth(tokens []config.WebToken, allowedOrigins []string) error {
+	_, err := newWebAuth(tokens, allowedOrigins)
+	return err
+}
+
+// newWebAuth checks tokens and origins; tokens without a scope get the read scope
+func newWebAuth(tokens []config.WebToken, allowedOrigins []string) (*webAuth, error) {
+	auth := &webAuth{origins: make(map[string]bool)}
+	seen := make(map[string]bool)
+	for _, t := range tokens {
+		if len(t.Token) < minWebTokenSize {
+			return nil, fmt.Errorf("web token %q is shorter than %d characters", t.Name, minWebTokenSize)
+		}
+		if seen[t.Token] {
+			return nil, fmt.Errorf("web token %q is used twice", t.Name)
+		}
+		seen[t.Token] = true
+		switch t.Scope {
+		case "":
+			t.Scope = ScopeRead
+		case ScopeRead, ScopeMember, ScopeAdmin:
+		default:
+			return nil, fmt.Errorf("web token %q has invalid scope %q: must be %s, %s or %s", t.Name, t.Scope, ScopeRead, ScopeMember, ScopeAdmin)
+		}
+		auth.tokens = append(auth.tokens, t)
+	}
+	for _, origin := range allowedOrigins {
+		normalized, err := normalizeOrigin(origin)
+		if err != nil {
+			return nil, err
+		}
+		auth.origins[normalized] = true
+	}
+	return auth, nil
+}
+
+// currentAuth returns the access control in effect
+func (s *WebService) currentAuth() *webAuth {
+	s.authLock.RLock()
+	defer s.authLock.RUnlock()
+	if s.auth == nil {
+		return &webAuth{}
+	}
+	return s.auth
+}
+
+// GenerateWebToken returns a random token for the web_tokens configuration
+func GenerateWebToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// normalizeOrigin checks an origin such as https://example.com:8443 and lowercases it
+func normalizeOrigin(origin string) (string, error) {
+	u, err := url.Parse(strings.TrimSuffix(origin, "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" {
+		return "", fmt.Errorf("invalid origin %q: must be like https://example.com", origin)
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host), nil
+}
+
+// enabled returns whether tokens are required
+func (a *webAuth) enabled() bool {
+	return len(a.tokens) > 0
+}
+
+// lookup returns the configured token matching a token, or false if there is none
+func (a *webAuth) lookup(token string) (config.WebToken, bool) {
+	if token == "" {
+		return config.WebToken{}, false
+	}
+	var match config.WebToken
+	found := false
+	// Compare every token in constant time so the timing does not reveal a match
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) == 1 {
+			match, found = t, true
+		}
+	}
+	return match, found
+}
+
+// scopeOf returns the scope of a token, or false if it is not configured
+func (a *webAuth) scopeOf(token string) (string, bool) {
+	t, ok := a.lookup(token)
+	return t.Scope, ok
+}
+
+// bearerToken returns the configured token of a request's Authorization header
+func (a *webAuth) bearerToken(r *http.Request) (config.WebToken, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return config.WebToken{}, false
+	}
+	return a.lookup(strings.TrimSpace(token))
+}
+
+// authenticate returns the scope of a request's bearer token or login cookie
+func (a *webAuth) authenticate(r *http.Request) (string, bool) {
+	if !a.enabled() {
+		return ScopeAdmin, true
+	}
+	if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+		return a.scopeOf(strings.TrimSpace(token))
+	}
+	if cookie, err := r.Cookie(authCookieName); err == nil {
+		return a.scopeOf(cookie.Value)
+	}
+	// Browser sources such as OBS cannot log in, so the overlay and the event streams also
+	// take the token as a parameter; it can only read
+	if token := r.URL.Query().Get("token"); token != "" && tokenParamPath(r.URL.Path) && readOnlyMethod(r.Method) {
+		if _, ok := a.scopeOf(token); ok {
+			return ScopeRead, true
+		}
+	}
+	return "", false
+}
+
+// tokenParamPath returns whether a path accepts the token as a query parameter
+func tokenParamPath(path string) bool {
+	return path == "/overlay" || path == "/ws" || path == "/api/events"
+}
+
+// identify returns the configured token of a request's bearer token or login cookie
+func (a *webAuth) identify(r *http.Request) (config.WebToken, bool) {
+	if t, ok := a.bearerToken(r); ok {
+		return t, true
+	}
+	if cookie, err := r.Cookie(authCookieName); err == nil {
+		return a.lookup(cookie.Value)
+	}
+	return config.WebToken{}, false
+}
+
+// author returns who makes a change through the web server for the change history: the
+// name of the token used, or the client's address when no tokens are configured
+func (s *WebService) author(r *http.Request) string {
+	if t, ok := s.currentAuth().identify(r); ok {
+		return "web " + t.Name
+	}
+	return "web " + r.RemoteAddr
+}
+
+// hostTrusted returns whether the Host of a request names this server rather than a domain
+// that may have been pointed at it: a loopback name, an IP address, the bind address or the
+// host of an allowed origin. Without tokens this stops DNS rebinding, where another site's
+// domain resolves to 127.0.0.1 and its pages pass as the server's own.
+func (s *WebService) hostTrusted(r *http.Request) bool {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || net.ParseIP(host) != nil {
+		return true
+	}
+	if strings.EqualFold(host, s.bindAddress) {
+		return true
+	}
+	for origin := range s.currentAuth().origins {
+		if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Hostname(), host) {
+			return true
+		}
+	}
+	return false
+}
+
+// originAllowed returns whether a request may come from its Origin: requests without one
+// (not from a browser), from the server's own pages if sameHost is trusted, and from the
+// allowed origins
+func (a *webAuth) originAllowed(r *http.Request, sameHost bool) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if sameHost && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	return a.origins[strings.ToLower(u.Scheme+"://"+u.Host)]
+}
+
+// crossOrigin returns whether a request comes from one of the allowed other origins
+func (a *webAuth) crossOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	u, err := url.Parse(origin)
+	return origin != "" && err == nil && !strings.EqualFold(u.Host, r.Host)
+}
+
+// publicPath returns whether a path is served without a token
+func publicPath(path string) bool {
+	return path == "/login" || path == "/logout" || strings.HasPrefix(path, "/static/")
+}
+
+// readOnlyMethod returns whether a method only reads
+func readOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// protect checks the origin, token and scope of every request before passing it on, and
+// answers CORS preflight requests of the allowed origins
+func (s *WebService) protect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := s.currentAuth()
+
+		// Without tokens anyone who reaches the server is an admin, so it only answers to
+		// its own names
+		trusted := s.hostTrusted(r)
+		if !auth.enabled() && !trusted {
+			writeAuthError(w, r, http.StatusForbidden, "host_not_allowed", "host "+r.Host+" is not allowed")
+			return
+		}
+		if !auth.originAllowed(r, auth.enabled() || trusted) {
+			writeAuthError(w, r, http.StatusForbidden, "origin_not_allowed", "origin "+r.Header.Get("Origin")+" is not allowed")
+			return
+		}
+		if auth.crossOrigin(r) {
+			w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+			w.Header().Set("Access-Control-Expose-Headers", "ETag")
+			w.Header().Add("Vary", "Origin")
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, PATCH, DELETE")
+				w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type, If-None-Match")
+				w.Header().Set("Access-Control-Max-Age", "600")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+		}
+
+		if publicPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		scope, ok := auth.authenticate(r)
+		if !ok {
+			if isAPIPath(r.URL.Path) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="EU-CLAMS"`)
+				writeAuthError(w, r, http.StatusUnauthorized, "unauthorized", "a valid token is required")
+				return
+			}
+			http.Redirect(w, r, "/login?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusSeeOther)
+			return
+		}
+		if !readOnlyMethod(r.Method) && scope != ScopeAdmin && !(scope == ScopeMember && r.URL.Path == hubIngestPath) {
+			writeAuthError(w, r, http.StatusForbidden, "forbidden", "an admin token is required")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// isAPIPath returns whether a path is answered with data rather than a page
+func isAPIPath(path string) bool {
+	return strings.HasPrefix(path, "/api/") || path == "/ws" || path == "/metrics"
+}
+
+// writeAuthError rejects a request, with the error envelope on the versioned API
+func writeAuthError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	if strings.HasPrefix(r.URL.Path, "/api/v1/") {
+		writeAPIError(w, status, code, message)
+		return
+	}
+	http.Error(w, message, status)
+}
+
+// handleLoginPage shows the login form
+func (s *WebService) handleLoginPage(w http.ResponseWriter, r *http.Request) {
+	s.renderLogin(w, http.StatusOK, safeRedirect(r.URL.Query().Get("next")), "")
+}
+
+// handleLogin checks the submitted token and keeps it in a cookie
+func (s *WebService) handleLogin(w http.ResponseWriter, r *http.Request) {
+	next := safeRedirect(r.FormValue("next"))
+	auth := s.currentAuth()
+	if auth.enabled() {
+		token := strings.TrimSpace(r.FormValue("token"))
+		if _, ok := auth.scopeOf(token); !ok {
+			s.log.Warn("Failed web login from %s", r.RemoteAddr)
+			s.renderLogin(w, http.StatusUnauthorized, next, "Invalid token")
+			return
+		}
+		http.SetCookie(w, &http.Cookie{
+			Name:     authCookieName,
+			Value:    token,
+			Path:     "/",
+			MaxAge:   authCookieDays * 24 * 60 * 60,
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteStrictMode,
+		})
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// handleLogout removes the login cookie
+func (s *WebService) handleLogout(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: authCookieName, Value: "", Path: "/", MaxAge: -1, HttpOnly: true, SameSite: http.SameSiteStrictMode})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// renderLogin writes the login form
+func (s *WebService) renderLogin(w http.ResponseWriter, status int, next, message string) {
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(status)
+	if err := s.templates.ExecuteTemplate(w, "login.html", map[string]string{"Next": next, "Error": message}); err != nil {
+		s.log.Error("Failed to render login page: %v", err)
+	}
+}
+
+// safeRedirect returns a local path to go to after logging in, so the login form cannot
+// send the browser to another site
+func safeRedirect(next string) string {
+	if !strings.HasPrefix(next, "/") || strings.HasPrefix(next, "//") || strings.HasPrefix(next, "/\\") {
+		return "/"
+	}
+	return next
+}
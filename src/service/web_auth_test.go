@@ -0,0 +1,270 @@
+package service
+
+import (
+	"encoding/json"
+	"eu-clams/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+// Tokens of the protected test service
+const (
+	testReadToken  = "read-token-0123456789"
+	testAdminToken = "admin-token-0123456789"
+)
+
+// newProtectedWebService returns a test web service with a read and an admin token
+func newProtectedWebService(t *testing.T) *WebService {
+	t.Helper()
+	s := newTestWebService(t)
+	tokens := []config.WebToken{
+		{Name: "viewer", Token: testReadToken, Scope: ScopeRead},
+		{Name: "owner", Token: testAdminToken, Scope: ScopeAdmin},
+	}
+	if err := s.SetAuth(tokens, []string{"https://Overlay.example/"}); err != nil {
+		t.Fatalf("SetAuth: %v", err)
+	}
+	return s
+}
+
+// bearer returns the header of a bearer token
+func bearer(token string) http.Header {
+	return http.Header{"Authorization": {"Bearer " + token}}
+}
+
+func TestWebAuthWithoutTokens(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+
+	if rec := serve(s, http.MethodGet, "/api/stats", nil); rec.Code != http.StatusOK {
+		t.Errorf("status without tokens = %d, want 200", rec.Code)
+	}
+	rec := serve(s, http.MethodPost, "/api/goals", nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("POST without tokens status = %d, want 400 from the handler", rec.Code)
+	}
+}
+
+func TestWebAuthTokens(t *testing.T) {
+	t.Parallel()
+	s := newProtectedWebService(t)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		header http.Header
+		status int
+	}{
+		{"no token", http.MethodGet, "/api/stats", nil, http.StatusUnauthorized},
+		{"unknown token", http.MethodGet, "/api/stats", bearer("not-a-configured-token"), http.StatusUnauthorized},
+		{"read token", http.MethodGet, "/api/stats", bearer(testReadToken), http.StatusOK},
+		{"lowercase scheme", http.MethodGet, "/api/stats", http.Header{"Authorization": {"bearer " + testReadToken}}, http.StatusOK},
+		{"cookie", http.MethodGet, "/api/v1/stats", http.Header{"Cookie": {authCookieName + "=" + testReadToken}}, http.StatusOK},
+		{"read token edit", http.MethodDelete, "/api/globals/a", bearer(testReadToken), http.StatusForbidden},
+		{"read token add goal", http.MethodPost, "/api/goals", bearer(testReadToken), http.StatusForbidden},
+		{"admin token add goal", http.MethodPost, "/api/goals", bearer(testAdminToken), http.StatusBadRequest}, // Reaches the handler without a body
+		{"login page", http.MethodGet, "/login", nil, http.StatusOK},
+		{"websocket", http.MethodGet, "/ws", nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if rec := serve(s, tt.method, tt.target, tt.header); rec.Code != tt.status {
+			t.Errorf("%s: %s %s status = %d, want %d", tt.name, tt.method, tt.target, rec.Code, tt.status)
+		}
+	}
+
+	rec := serve(s, http.MethodGet, "/api/stats", nil)
+	if !strings.HasPrefix(rec.Header().Get("WWW-Authenticate"), "Bearer") {
+		t.Errorf("WWW-Authenticate = %q, want a Bearer challenge", rec.Header().Get("WWW-Authenticate"))
+	}
+
+	// The versioned API keeps its error envelope
+	rec = serve(s, http.MethodGet, "/api/v1/globals", nil)
+	var body apiError
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error.Code != "unauthorized" {
+		t.Errorf("v1 error = %s (%v), want code unauthorized", rec.Body, err)
+	}
+
+	// Pages send the browser to the login form
+	rec = serve(s, http.MethodGet, "/compare?preset=week", nil)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/login?next="+url.QueryEscape("/compare?preset=week") {
+		t.Errorf("page status = %d, Location %q, want 303 to the login form", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+func TestWebAuthLogin(t *testing.T) {
+	t.Parallel()
+	s := newProtectedWebService(t)
+
+	login := func(token, next string) *httptest.ResponseRecorder {
+		form := url.Values{"token": {token}, "next": {next}}
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		s.routes().ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := login(testReadToken, "/compare")
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/compare" {
+		t.Fatalf("login status = %d, Location %q, want 303 to /compare", rec.Code, rec.Header().Get("Location"))
+	}
+	cookies := rec.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != authCookieName || !cookies[0].HttpOnly || cookies[0].SameSite != http.SameSiteStrictMode {
+		t.Fatalf("login cookies = %+v, want an HttpOnly SameSite=Strict %s", cookies, authCookieName)
+	}
+	if rec := serve(s, http.MethodGet, "/api/stats", http.Header{"Cookie": {cookies[0].String()}}); rec.Code != http.StatusOK {
+		t.Errorf("status with the login cookie = %d, want 200", rec.Code)
+	}
+
+	if rec := login("wrong-token-0123456789", "/"); rec.Code != http.StatusUnauthorized || len(rec.Result().Cookies()) != 0 {
+		t.Errorf("wrong token status = %d with %d cookies, want 401 without a cookie", rec.Code, len(rec.Result().Cookies()))
+	}
+
+	// The form only redirects within the server
+	for _, next := range []string{"//evil.example/", "https://evil.example/", "/\\evil.example"} {
+		if rec := login(testReadToken, next); rec.Header().Get("Location") != "/" {
+			t.Errorf("next %q redirected to %q, want /", next, rec.Header().Get("Location"))
+		}
+	}
+
+	rec = serve(s, http.MethodPost, "/logout", nil)
+	if cookies := rec.Result().Cookies(); rec.Code != http.StatusSeeOther || len(cookies) != 1 || cookies[0].MaxAge >= 0 {
+		t.Errorf("logout status = %d, cookies %+v, want 303 removing the cookie", rec.Code, cookies)
+	}
+}
+
+func TestWebAuthOrigins(t *testing.T) {
+	t.Parallel()
+	s := newProtectedWebService(t)
+
+	withOrigin := func(origin string, header http.Header) http.Header {
+		if header == nil {
+			header = http.Header{}
+		}
+		header.Set("Origin", origin)
+		return header
+	}
+
+	// The server's own pages
+	rec := serve(s, http.MethodGet, "/api/stats", withOrigin("http://localhost", bearer(testReadToken)))
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("same origin status = %d, CORS %q, want 200 without CORS", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	rec = serve(s, http.MethodGet, "/api/stats", withOrigin("https://overlay.example", bearer(testReadToken)))
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "https://overlay.example" {
+		t.Errorf("allowed origin status = %d, CORS %q, want 200 allowing the origin", rec.Code, rec.Header().Get("Access-Control-Allow-Origin"))
+	}
+
+	// Preflight requests carry no token
+	rec = serve(s, http.MethodOptions, "/api/goals", withOrigin("https://overlay.example", http.Header{"Access-Control-Request-Method": {"POST"}}))
+	if rec.Code != http.StatusNoContent || !strings.Contains(rec.Header().Get("Access-Control-Allow-Headers"), "Authorization") {
+		t.Errorf("preflight status = %d, headers %v, want 204 allowing Authorization", rec.Code, rec.Header())
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodOptions, http.MethodPost} {
+		rec = serve(s, method, "/api/stats", withOrigin("https://evil.example", bearer(testAdminToken)))
+		if rec.Code != http.StatusForbidden || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("%s from another origin status = %d, want 403 without CORS", method, rec.Code)
+		}
+	}
+}
+
+func TestWebAuthWebSocketOrigin(t *testing.T) {
+	t.Parallel()
+	s := newProtectedWebService(t)
+	server := httptest.NewServer(s.routes())
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	header := bearer(testReadToken)
+	header.Set("Origin", "https://evil.example")
+	_, resp, err := websocket.DefaultDialer.Dial(wsURL, header)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("dial from another origin = %v, want 403", err)
+	}
+
+	header.Set("Origin", server.URL)
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, header)
+	if err != nil {
+		t.Fatalf("dial from the server's own origin: %v", err)
+	}
+	conn.Close()
+}
+
+func TestWebAuthHosts(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+	if err := s.SetAuth(nil, []string{"https://stats.example"}); err != nil {
+		t.Fatalf("SetAuth: %v", err)
+	}
+
+	request := func(host, origin string) int {
+		req := httptest.NewRequest(http.MethodDelete, "/api/globals/a", nil)
+		req.Host = host
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		rec := httptest.NewRecorder()
+		s.routes().ServeHTTP(rec, req)
+		return rec.Code
+	}
+	// Without tokens a page of a rebound domain must not pass as the server's own
+	if code := request("rebind.example:8080", "http://rebind.example:8080"); code != http.StatusForbidden {
+		t.Errorf("rebound host status = %d, want 403", code)
+	}
+	if code := request("rebind.example:8080", ""); code != http.StatusForbidden {
+		t.Errorf("rebound host without origin status = %d, want 403", code)
+	}
+	for _, host := range []string{"127.0.0.1:8080", "localhost:8080", "[::1]:8080", "stats.example"} {
+		if code := request(host, "http://"+host); code == http.StatusForbidden {
+			t.Errorf("host %s status = 403, want it trusted", host)
+		}
+	}
+}
+
+func TestWebEditAuthor(t *testing.T) {
+	t.Parallel()
+	s := newProtectedWebService(t)
+
+	rec := serve(s, http.MethodDelete, "/api/globals/a", bearer(testAdminToken))
+	if len(s.db.Changes) != 1 || s.db.Changes[0].Author != "web owner" {
+		t.Errorf("DELETE status = %d, changes %+v; want the deletion credited to the token name", rec.Code, s.db.Changes)
+	}
+}
+
+func TestSetAuthValidates(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+
+	tests := []struct {
+		name    string
+		tokens  []config.WebToken
+		origins []string
+	}{
+		{"short token", []config.WebToken{{Name: "a", Token: "short"}}, nil},
+		{"duplicate token", []config.WebToken{{Name: "a", Token: testReadToken}, {Name: "b", Token: testReadToken}}, nil},
+		{"invalid scope", []config.WebToken{{Name: "a", Token: testReadToken, Scope: "write"}}, nil},
+		{"origin with a path", nil, []string{"https://example.com/page"}},
+		{"origin without a scheme", nil, []string{"example.com"}},
+	}
+	for _, tt := range tests {
+		if err := s.SetAuth(tt.tokens, tt.origins); err == nil {
+			t.Errorf("%s: SetAuth succeeded, want an error", tt.name)
+		}
+	}
+
+	// A token without a scope may only read
+	if err := s.SetAuth([]config.WebToken{{Name: "a", Token: testReadToken}}, nil); err != nil {
+		t.Fatalf("SetAuth: %v", err)
+	}
+	if rec := serve(s, http.MethodDelete, "/api/goals/g1", bearer(testReadToken)); rec.Code != http.StatusForbidden {
+		t.Errorf("default scope edit status = %d, want 403", rec.Code)
+	}
+}
@@ -0,0 +1,132 @@
+package service
+
+import (
+	"crypto/tls"
+	"errors"
+	"eu-clams/internal/config"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// DefaultWebBindAddress only accepts connections from this computer
+const DefaultWebBindAddress = "127.0.0.1"
+
+// ConfigureWebService applies the access, address, theme and HTTPS settings of the configuration.
+// With web_tls but no certificate files, a self-signed certificate kept next to the database
+// is used, and created or renewed as needed.
+func ConfigureWebService(s *WebService, cfg config.Config) error {
+	if err := s.SetAuth(cfg.WebTokens, cfg.WebAllowedOrigins); err != nil {
+		return fmt.Errorf("invalid web server access settings: %w", err)
+	}
+	// Without a member token every push would be refused, and members would retry forever
+	if cfg.HubMode && !s.currentAuth().canPush() {
+		return errors.New("hub_mode needs web_tokens with the member scope, one per teammate")
+	}
+	s.SetBindAddress(cfg.WebBindAddress)
+	s.SetHubMode(cfg.HubMode)
+	if err := s.SetThemeDir(cfg.WebThemeDir); err != nil {
+		return err
+	}
+	if !cfg.WebTLS {
+		s.SetTLS("", "")
+		return nil
+	}
+
+	certFile, keyFile := cfg.WebTLSCert, cfg.WebTLSKey
+	if certFile == "" && keyFile == "" {
+		var err error
+		dir := filepath.Join(filepath.Dir(DatabasePath(cfg)), "tls")
+		certFile, keyFile, err = EnsureSelfSignedCert(dir, certificateHosts(s.bindAddress))
+		if err != nil {
+			return fmt.Errorf("failed to create a self-signed certificate: %w", err)
+		}
+		if fingerprint, err := certificateFingerprint(certFile); err == nil {
+			s.log.Info("Using the self-signed certificate %s, SHA-256 fingerprint %s", certFile, fingerprint)
+		}
+	} else if certFile == "" || keyFile == "" {
+		return errors.New("web_tls_cert and web_tls_key must be set together")
+	}
+	s.SetTLS(certFile, keyFile)
+	return nil
+}
+
+// SetBindAddress sets the interface to listen on, DefaultWebBindAddress if empty
+func (s *WebService) SetBindAddress(address string) {
+	if address == "" {
+		address = DefaultWebBindAddress
+	}
+	s.bindAddress = address
+}
+
+// SetTLS serves HTTPS with a certificate and key file, or HTTP if both are empty
+func (s *WebService) SetTLS(certFile, keyFile string) {
+	s.certFile, s.keyFile = certFile, keyFile
+}
+
+// Listen opens the port, so that an address in use is reported before the server runs in
+// the background. Run calls it if it was not called before.
+func (s *WebService) Listen() error {
+	var tlsConfig *tls.Config
+	if s.certFile != "" {
+		cert, err := tls.LoadX509KeyPair(s.certFile, s.keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		tlsConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	}
+
+	addr := net.JoinHostPort(s.listenHost(), strconv.Itoa(s.port))
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return listenError(addr, err)
+	}
+	if tlsConfig != nil {
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+
+	s.listener = ln
+	s.server = &http.Server{
+		Handler:           s.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return nil
+}
+
+// listenHost returns the interface to listen on
+func (s *WebService) listenHost() string {
+	if s.bindAddress == "" {
+		return DefaultWebBindAddress
+	}
+	return s.bindAddress
+}
+
+// URL returns the address of the dashboard in a browser on this computer
+func (s *WebService) URL() string {
+	scheme := "http"
+	if s.certFile != "" {
+		scheme = "https"
+	}
+	host := s.listenHost()
+	if ip := net.ParseIP(host); ip != nil && ip.IsUnspecified() {
+		host = "localhost"
+	}
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, strconv.Itoa(s.port)))
+}
+
+// listenError explains why a port could not be opened
+func listenError(addr string, err error) error {
+	switch {
+	case errors.Is(err, errAddrInUse):
+		return fmt.Errorf("%s is already in use by another program; choose another web server port: %w", addr, err)
+	case errors.Is(err, errAccessDenied), errors.Is(err, os.ErrPermission):
+		return fmt.Errorf("not permitted to listen on %s; ports below 1024 need administrator rights: %w", addr, err)
+	case errors.Is(err, errAddrNotAvailable):
+		return fmt.Errorf("%s is not an address of this computer; check web_bind_address: %w", addr, err)
+	}
+	return fmt.Errorf("failed to listen on %s: %w", addr, err)
+}
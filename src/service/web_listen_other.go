@@ -0,0 +1,12 @@
+//go:build !windows
+
+package service
+
+import "syscall"
+
+// Errors of net.Listen
+const (
+	errAddrInUse        = syscall.EADDRINUSE
+	errAddrNotAvailable = syscall.EADDRNOTAVAIL
+	errAccessDenied     = syscall.EACCES
+)
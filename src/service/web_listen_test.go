@@ -0,0 +1,175 @@
+package service
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"eu-clams/internal/config"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListenPortInUse(t *testing.T) {
+	t.Parallel()
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer taken.Close()
+
+	s := newTestWebService(t)
+	s.port = taken.Addr().(*net.TCPAddr).Port
+	s.SetBindAddress("")
+	err = s.Listen()
+	if err == nil {
+		s.Stop()
+		t.Fatal("Listen on a port in use succeeded")
+	}
+	if !strings.Contains(err.Error(), "already in use") {
+		t.Errorf("error = %q, want it to say the port is in use", err)
+	}
+	if err := s.Run(); err == nil || !strings.Contains(err.Error(), "already in use") {
+		t.Errorf("Run error = %v, want the port in use", err)
+	}
+}
+
+func TestStopBeforeRun(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+	s.port = 0
+	s.SetBindAddress("127.0.0.1")
+	if err := s.Listen(); err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	addr := s.listener.Addr().String()
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatalf("port still bound after Stop: %v", err)
+	}
+	ln.Close()
+}
+
+func TestListenTLSSelfSigned(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	s := newTestWebService(t)
+	cfg := config.NewDefaultConfig()
+	cfg.DatabasePath = filepath.Join(dir, "db.yaml")
+	cfg.WebTLS = true
+	if err := ConfigureWebService(s, cfg); err != nil {
+		t.Fatalf("ConfigureWebService: %v", err)
+	}
+	if err := s.Listen(); err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	s.port = s.listener.Addr().(*net.TCPAddr).Port
+	done := make(chan error, 1)
+	go func() { done <- s.Run() }()
+
+	if !strings.HasPrefix(s.URL(), "https://127.0.0.1:") {
+		t.Errorf("URL = %s, want https on 127.0.0.1", s.URL())
+	}
+
+	// Trust the generated certificate like a browser after accepting it
+	pemData, err := os.ReadFile(filepath.Join(dir, "tls", "cert.pem"))
+	if err != nil {
+		t.Fatalf("reading certificate: %v", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(pemData)
+	client := &http.Client{Timeout: 5 * time.Second, Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	resp, err := client.Get(s.URL() + "/api/stats")
+	if err != nil {
+		t.Fatalf("GET over HTTPS: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+
+	s.Stop()
+	if err := <-done; err != nil {
+		t.Errorf("Run after Stop = %v, want nil", err)
+	}
+}
+
+func TestEnsureSelfSignedCert(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	certFile, keyFile, err := EnsureSelfSignedCert(dir, []string{"localhost", "127.0.0.1"})
+	if err != nil {
+		t.Fatalf("EnsureSelfSignedCert: %v", err)
+	}
+	if info, err := os.Stat(keyFile); err != nil {
+		t.Fatalf("key file: %v", err)
+	} else if runtime.GOOS != "windows" && info.Mode().Perm()&0077 != 0 {
+		t.Errorf("key file mode = %v, want it private", info.Mode().Perm())
+	}
+	first, _ := os.ReadFile(certFile)
+
+	// Kept while it covers the hosts
+	EnsureSelfSignedCert(dir, []string{"localhost"})
+	if again, _ := os.ReadFile(certFile); string(again) != string(first) {
+		t.Error("certificate was replaced although it covers the hosts")
+	}
+
+	// Replaced for a new host
+	EnsureSelfSignedCert(dir, []string{"localhost", "192.168.1.20"})
+	if again, _ := os.ReadFile(certFile); string(again) == string(first) {
+		t.Error("certificate was kept although it lacks 192.168.1.20")
+	}
+	if !selfSignedCertValid(certFile, keyFile, []string{"192.168.1.20"}, time.Now()) {
+		t.Error("new certificate does not cover 192.168.1.20")
+	}
+
+	// Renewed before it expires
+	if selfSignedCertValid(certFile, keyFile, nil, time.Now().Add(selfSignedValidity-selfSignedRenewal/2)) {
+		t.Error("certificate close to expiry is still considered valid")
+	}
+}
+
+func TestConfigureWebServiceTLSFiles(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+	cfg := config.NewDefaultConfig()
+	cfg.WebTLS = true
+	cfg.WebTLSCert = "cert.pem"
+	if err := ConfigureWebService(s, cfg); err == nil {
+		t.Error("ConfigureWebService with a certificate but no key succeeded")
+	}
+
+	cfg.WebTLSKey = filepath.Join(t.TempDir(), "missing.pem")
+	if err := ConfigureWebService(s, cfg); err != nil {
+		t.Fatalf("ConfigureWebService: %v", err)
+	}
+	if err := s.Listen(); err == nil || !strings.Contains(err.Error(), "TLS certificate") {
+		s.Stop()
+		t.Errorf("Listen with missing files = %v, want a certificate error", err)
+	}
+}
+
+func TestConfigureWebServiceHubTokens(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+	cfg := config.NewDefaultConfig()
+	cfg.HubMode = true
+	cfg.WebTokens = []config.WebToken{{Name: "viewer", Token: testReadToken, Scope: ScopeRead}}
+	if err := ConfigureWebService(s, cfg); err == nil || !strings.Contains(err.Error(), "member") {
+		t.Errorf("ConfigureWebService in hub mode without member tokens = %v, want an error", err)
+	}
+
+	cfg.WebTokens = append(cfg.WebTokens, config.WebToken{Name: "jane", Token: testMemberToken, Scope: ScopeMember})
+	if err := ConfigureWebService(s, cfg); err != nil || !s.hubMode {
+		t.Errorf("ConfigureWebService with a member token = %v, hub mode %v", err, s.hubMode)
+	}
+}
@@ -0,0 +1,12 @@
+//go:build windows
+
+package service
+
+import "syscall"
+
+// Winsock errors of net.Listen
+const (
+	errAddrInUse        = syscall.Errno(10048) // WSAEADDRINUSE
+	errAddrNotAvailable = syscall.Errno(10049) // WSAEADDRNOTAVAIL
+	errAccessDenied     = syscall.WSAEACCES
+)
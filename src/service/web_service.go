@@ -2,14 +2,17 @@ package service
 
 import (
 	"encoding/json"
+	"errors"
+	"eu-clams/internal/analysis"
 	"eu-clams/internal/logger"
 	"eu-clams/internal/model"
+	"eu-clams/internal/stats"
 	"eu-clams/internal/storage"
 	"fmt"
 	"html/template"
+	"io/fs"
+	"net"
 	"net/http"
-	"os"
-	"path/filepath"
 	"strconv"
 	"sync"
 	"time"
@@ -25,54 +28,59 @@ type WebService struct {
 	playerName  string
 	teamName    string
 	port        int
+	bindAddress string
+	certFile    string
+	keyFile     string
+	listener    net.Listener
 	server      *http.Server
-	clients     map[*websocket.Conn]bool
-	clientsLock sync.Mutex
-	// Add a map to store write mutexes for each connection
-	writeLockers map[*websocket.Conn]*sync.Mutex
-	upgrader     websocket.Upgrader
-	templates    *template.Template
-	templateDir  string
-	staticDir    string
+	events      *eventHub
+	pongWait    time.Duration // Time allowed without a pong from a WebSocket client
+	upgrader    websocket.Upgrader
+	templates   *template.Template
+	themeDir    string
+	auth        *webAuth
+	authLock    sync.RWMutex
+	hubMode     bool // Accept globals pushed by team members
 }
 
 // NewWebService creates a new WebService instance
 func NewWebService(log *logger.Logger, db *storage.EntropyDB, playerName, teamName string, port int) *WebService {
-	return &WebService{
-		BaseService:  NewBaseService("WebService"),
-		log:          log,
-		db:           db,
-		playerName:   playerName,
-		teamName:     teamName,
-		port:         port,
-		clients:      make(map[*websocket.Conn]bool),
-		writeLockers: make(map[*websocket.Conn]*sync.Mutex),
-		upgrader:     websocket.Upgrader{CheckOrigin: func(r *http.Request) bool { return true }},
-		templateDir:  getTemplateDir(),
-		staticDir:    getStaticDir(),
-	}
-}
-
-// getTemplateDir returns the path to the templates directory
-func getTemplateDir() string {
-	// Get executable directory
-	exePath, err := os.Executable()
-	if err != nil {
-		return "templates"
+	s := &WebService{
+		BaseService: NewBaseService("WebService"),
+		log:         log,
+		db:          db,
+		playerName:  playerName,
+		teamName:    teamName,
+		port:        port,
+		events:      newEventHub(),
+		pongWait:    wsPongWait,
+	}
+	s.upgrader = websocket.Upgrader{
+		Subprotocols: []string{WebSocketProtocolV2},
+		CheckOrigin: func(r *http.Request) bool {
+			auth := s.currentAuth()
+			return auth.originAllowed(r, auth.enabled() || s.hostTrusted(r))
+		},
 	}
-	exeDir := filepath.Dir(exePath)
-	return filepath.Join(exeDir, "templates")
+	return s
 }
 
-// getStaticDir returns the path to the static files directory
-func getStaticDir() string {
-	// Get executable directory
-	exePath, err := os.Executable()
-	if err != nil {
-		return "templates/static"
-	}
-	exeDir := filepath.Dir(exePath)
-	return filepath.Join(exeDir, "templates/static")
+// templateFuncs are the helper functions available in the HTML templates
+var templateFuncs = template.FuncMap{
+	// percent converts a 0 to 1 ratio to a percentage
+	"percent": func(ratio float64) float64 { return ratio * 100 },
+	// change formats a relative change as a signed percentage
+	"change": stats.FormatChange,
+	// deltaClass returns the CSS class of a positive or negative delta
+	"deltaClass": func(delta float64) string {
+		switch {
+		case delta > 0:
+			return "up"
+		case delta < 0:
+			return "down"
+		}
+		return ""
+	},
 }
 
 // Initialize initializes the web service
@@ -84,22 +92,12 @@ func (s *WebService) Initialize() error {
 		return fmt.Errorf("database is required for web service")
 	}
 
-	// Check if template directory exists
-	if _, err := os.Stat(s.templateDir); os.IsNotExist(err) {
-		// Try working directory
-		cwd, _ := os.Getwd()
-		s.templateDir = filepath.Join(cwd, "templates")
-		s.staticDir = filepath.Join(cwd, "templates/static")
+	// Templates are embedded, with the files of a theme directory replacing them
+	if s.themeDir != "" {
+		s.log.Info("Using web theme directory: %s", s.themeDir)
 	}
-
-	s.log.Info("Using template directory: %s", s.templateDir)
-
-	// Initialize templates
-	var err error
-	templatePath := filepath.Join(s.templateDir, "index.html")
-	s.templates, err = template.ParseFiles(templatePath)
-	if err != nil {
-		return fmt.Errorf("failed to parse templates: %w", err)
+	if err := s.loadTemplates(); err != nil {
+		return err
 	}
 
 	// Register with the service registry
@@ -113,48 +111,97 @@ func (s *WebService) Run() error {
 	s.log.Info("WebService starting on port %d...", s.port)
 	defer s.log.LogTiming("WebService.Run")()
 
-	// Set up routes
+	if s.listener == nil {
+		if err := s.Listen(); err != nil {
+			return err
+		}
+	}
+	if !s.currentAuth().enabled() {
+		s.log.Warn("No web_tokens configured: anyone who can reach port %d can use the web server", s.port)
+	}
+
+	// Start the server
+	s.log.Info("Web UI available at %s", s.URL())
+	if err := s.server.Serve(s.listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// routes sets up the pages, API endpoints, WebSocket and static files behind the access control
+func (s *WebService) routes() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", s.handleIndex)
 	mux.HandleFunc("/api/stats", s.handleStats)
+	mux.HandleFunc("/api/stats/timeseries", s.handleTimeSeries)
+	mux.HandleFunc("/api/stats/targets", s.handleTargetStats)
+	mux.HandleFunc("/api/stats/locations", s.handleLocationStats)
+	mux.HandleFunc("/api/stats/droughts", s.handleDroughts)
+	mux.HandleFunc("/api/stats/values", s.handleValueDistribution)
 	mux.HandleFunc("/api/globals", s.handleGlobals)
+	mux.HandleFunc("GET /api/globals/{id}", s.handleGlobal)
+	mux.HandleFunc("PATCH /api/globals/{id}", s.handleEditGlobal)
+	mux.HandleFunc("DELETE /api/globals/{id}", s.handleDeleteGlobal)
 	mux.HandleFunc("/api/hofs", s.handleHofs)
+	mux.HandleFunc("/api/sessions", s.handleSessions)
+	mux.HandleFunc("/api/leaderboards", s.handleLeaderboards)
+	mux.HandleFunc("/api/heatmap", s.handleHeatmap)
+	mux.HandleFunc("/api/heatmap.svg", s.handleHeatmapSVG)
+	mux.HandleFunc("/api/compare", s.handleCompare)
+	mux.HandleFunc("GET /api/goals", s.handleGoals)
+	mux.HandleFunc("POST /api/goals", s.handleAddGoal)
+	mux.HandleFunc("DELETE /api/goals/{id}", s.handleRemoveGoal)
+	mux.HandleFunc("/api/achievements", s.handleAchievements)
+	mux.HandleFunc("/api/records", s.handleRecords)
+	mux.HandleFunc("/api/markups", s.handleMarkups)
+	mux.HandleFunc("GET /api/openapi.json", s.handleOpenAPI)
+	mux.HandleFunc("/compare", s.handleComparePage)
+	mux.HandleFunc("GET /overlay", s.handleOverlay)
+	mux.HandleFunc("GET /api/overlays", s.handleOverlays)
+	mux.HandleFunc("PUT /api/overlays/{name}", s.handleSaveOverlay)
+	mux.HandleFunc("DELETE /api/overlays/{name}", s.handleRemoveOverlay)
+	mux.HandleFunc("GET /api/sessions/{id}", s.handleSession)
 	mux.HandleFunc("/ws", s.handleWebSocket)
+	mux.HandleFunc("GET /api/events", s.handleEventStream)
+	mux.HandleFunc("GET /api/export", s.handleExport)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	mux.HandleFunc("POST /api/hub/ingest", s.handleHubIngest)
+	mux.HandleFunc("GET /api/hub/members", s.handleHubMembers)
+	mux.HandleFunc("GET /api/hub/globals", s.handleHubGlobals)
+	mux.HandleFunc("GET /api/hub/leaderboards", s.handleHubLeaderboards)
+	mux.HandleFunc("GET /team", s.handleTeamPage)
+	mux.HandleFunc("GET /login", s.handleLoginPage)
+	mux.HandleFunc("POST /login", s.handleLogin)
+	mux.HandleFunc("POST /logout", s.handleLogout)
+	s.registerAPIv1(mux)
 
 	// Serve static files
-	staticHandler := http.FileServer(http.Dir(s.staticDir))
-	mux.Handle("/static/", http.StripPrefix("/static/", staticHandler))
+	static, _ := fs.Sub(s.assets(), "static")
+	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.FS(static))))
 
-	// Create server
-	s.server = &http.Server{
-		Addr:    fmt.Sprintf(":%d", s.port),
-		Handler: mux,
-	}
-
-	// Start the server
-	s.log.Info("Web UI available at http://localhost:%d", s.port)
-	return s.server.ListenAndServe()
+	return serviceMetrics.instrument(s.protect(mux))
 }
 
 // Stop stops the web server
 func (s *WebService) Stop() error {
 	s.log.Info("WebService stopping...")
 	// Close all websocket connections
-	s.clientsLock.Lock()
-	for client := range s.clients {
-		client.Close()
-		delete(s.writeLockers, client)
-	}
-	s.clientsLock.Unlock()
+	s.events.close()
 
 	// Unregister from the service registry
 	UnregisterWebService(fmt.Sprintf("web_%d", s.port))
 
-	// Shutdown the server
+	// Shutdown the server and close the port, which the server does not own if it never ran
+	var err error
 	if s.server != nil {
-		return s.server.Close()
+		err = s.server.Close()
 	}
-	return nil
+	if s.listener != nil {
+		if closeErr := s.listener.Close(); err == nil && !errors.Is(closeErr, net.ErrClosed) {
+			err = closeErr
+		}
+	}
+	return err
 }
 
 // handleIndex handles the index page
@@ -165,9 +212,11 @@ func (s *WebService) handleIndex(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Generate stats - always get fresh data from the database
+	s.db.RLock()
 	statsData := s.db.GetStatsData()
 	allGlobals := s.db.GetPlayerGlobals()
 	allHofs := s.db.GetPlayerHofs()
+	s.db.RUnlock()
 
 	// Limit to 10 entries
 	var globals, hofs []storage.GlobalEntry
@@ -184,12 +233,13 @@ func (s *WebService) handleIndex(w http.ResponseWriter, r *http.Request) {
 	}
 	// Prepare template data
 	data := map[string]interface{}{
-		"PlayerName": s.playerName,
-		"TeamName":   s.teamName,
-		"Stats":      statsData,
-		"Globals":    globals,
-		"Hofs":       hofs,
-		"Generated":  time.Now().UTC().Format(time.RFC3339),
+		"PlayerName":  s.playerName,
+		"TeamName":    s.teamName,
+		"Stats":       statsData,
+		"Globals":     globals,
+		"Hofs":        hofs,
+		"Generated":   time.Now().UTC().Format(time.RFC3339),
+		"AuthEnabled": s.currentAuth().enabled(),
 	}
 	// Set headers to prevent caching
 	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
@@ -207,7 +257,9 @@ func (s *WebService) handleIndex(w http.ResponseWriter, r *http.Request) {
 // handleStats handles the stats API endpoint
 func (s *WebService) handleStats(w http.ResponseWriter, r *http.Request) {
 	// Always get fresh stats from the database
+	s.db.RLock()
 	statsData := s.db.GetStatsData()
+	s.db.RUnlock()
 
 	// Set headers to prevent caching
 	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
@@ -218,6 +270,144 @@ func (s *WebService) handleStats(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(statsData)
 }
 
+// handleTimeSeries handles the time series API endpoint
+func (s *WebService) handleTimeSeries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	interval := query.Get("interval")
+	if interval == "" {
+		interval = model.IntervalDay
+	}
+	from, to, err := model.ParseTimeRange(query.Get("from"), query.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	byType, _ := strconv.ParseBool(query.Get("by_type"))
+
+	s.db.RLock()
+	series, err := s.db.GetTimeSeries(interval, from, to, byType)
+	s.db.RUnlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Set headers to prevent caching
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(series)
+}
+
+// handleTargetStats handles the per-target statistics API endpoint
+func (s *WebService) handleTargetStats(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter := model.TargetFilter{
+		Type:   query.Get("type"),
+		Search: query.Get("q"),
+	}
+	if minStr := query.Get("min_count"); minStr != "" {
+		if m, err := strconv.Atoi(minStr); err == nil && m > 0 {
+			filter.MinCount = m
+		}
+	}
+
+	s.db.RLock()
+	targets := model.FilterTargetStats(s.db.GetStatsData().ByTarget, filter)
+	s.db.RUnlock()
+	if err := model.SortTargetStats(targets, query.Get("sort"), query.Get("order") != "asc"); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && len(targets) > l {
+			targets = targets[:l]
+		}
+	}
+
+	// Set headers to prevent caching
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(targets)
+}
+
+// handleLocationStats handles the per-location statistics API endpoint
+func (s *WebService) handleLocationStats(w http.ResponseWriter, r *http.Request) {
+	s.db.RLock()
+	locations := s.db.GetStatsData().Locations
+	s.db.RUnlock()
+
+	// Set headers to prevent caching
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(locations)
+}
+
+// handleValueDistribution handles the value histogram API endpoint
+func (s *WebService) handleValueDistribution(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var edges []float64
+	if edgesStr := query.Get("edges"); edgesStr != "" {
+		var err error
+		if edges, err = model.ParseHistogramEdges(edgesStr); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	s.db.RLock()
+	dist, err := s.db.GetValueDistribution(query.Get("interval"), query.Get("type"), edges)
+	s.db.RUnlock()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Set headers to prevent caching
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(dist)
+}
+
+// handleDroughts handles the drought and streak analysis API endpoint
+func (s *WebService) handleDroughts(w http.ResponseWriter, r *http.Request) {
+	streakWindow := analysis.DefaultStreakWindow
+	if windowStr := r.URL.Query().Get("streak_window"); windowStr != "" {
+		d, err := time.ParseDuration(windowStr)
+		if err != nil || d <= 0 {
+			http.Error(w, fmt.Sprintf("invalid streak_window %q: must be a positive duration such as 10m", windowStr), http.StatusBadRequest)
+			return
+		}
+		streakWindow = d
+	}
+
+	s.db.RLock()
+	report := s.db.GetDroughtReport(analysis.WallClockNow(), streakWindow)
+	s.db.RUnlock()
+
+	// Set headers to prevent caching
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(report)
+}
+
 // handleGlobals handles the globals API endpoint
 func (s *WebService) handleGlobals(w http.ResponseWriter, r *http.Request) {
 	limit := 10 // Default to 10 to match the initial page load
@@ -228,7 +418,9 @@ func (s *WebService) handleGlobals(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Always get fresh globals from the database
+	s.db.RLock()
 	globals := s.db.GetPlayerGlobals()
+	s.db.RUnlock()
 	if len(globals) > limit {
 		globals = globals[:limit] // Take first 10 (already sorted newest first)
 	}
@@ -236,17 +428,7 @@ func (s *WebService) handleGlobals(w http.ResponseWriter, r *http.Request) {
 	// Convert to JSON-friendly objects with ISO8601 UTC timestamps
 	jsonGlobals := make([]model.GlobalEntryJSON, len(globals))
 	for i, g := range globals {
-		jsonGlobals[i] = model.GlobalEntryJSON{
-			Timestamp:  g.Timestamp.UTC().Format(time.RFC3339),
-			Type:       g.Type,
-			PlayerName: g.PlayerName,
-			TeamName:   g.TeamName,
-			Target:     g.Target,
-			Value:      g.Value,
-			Location:   g.Location,
-			IsHof:      g.IsHof,
-			RawMessage: g.RawMessage,
-		}
+		jsonGlobals[i] = toGlobalEntryJSON(g)
 	}
 
 	// Set headers to prevent caching
@@ -268,7 +450,9 @@ func (s *WebService) handleHofs(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Always get fresh HOFs from the database
+	s.db.RLock()
 	hofs := s.db.GetPlayerHofs()
+	s.db.RUnlock()
 	if len(hofs) > limit {
 		hofs = hofs[:limit] // Take first 10 (already sorted newest first)
 	}
@@ -276,17 +460,7 @@ func (s *WebService) handleHofs(w http.ResponseWriter, r *http.Request) {
 	// Convert to JSON-friendly objects with ISO8601 UTC timestamps
 	jsonHofs := make([]model.GlobalEntryJSON, len(hofs))
 	for i, h := range hofs {
-		jsonHofs[i] = model.GlobalEntryJSON{
-			Timestamp:  h.Timestamp.UTC().Format(time.RFC3339),
-			Type:       h.Type,
-			PlayerName: h.PlayerName,
-			TeamName:   h.TeamName,
-			Target:     h.Target,
-			Value:      h.Value,
-			Location:   h.Location,
-			IsHof:      h.IsHof,
-			RawMessage: h.RawMessage,
-		}
+		jsonHofs[i] = toGlobalEntryJSON(h)
 	}
 
 	// Set headers to prevent caching
@@ -298,89 +472,417 @@ func (s *WebService) handleHofs(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(jsonHofs)
 }
 
-// handleWebSocket handles WebSocket connections
-func (s *WebService) handleWebSocket(w http.ResponseWriter, r *http.Request) {
-	conn, err := s.upgrader.Upgrade(w, r, nil)
+// leaderboardsResponse is the body of /api/leaderboards
+type leaderboardsResponse struct {
+	CaptureEnabled bool                `json:"capture_enabled"`
+	Interval       string              `json:"interval"`
+	Leaderboards   []model.Leaderboard `json:"leaderboards"`
+}
+
+// handleLeaderboards handles the universe leaderboards API endpoint
+func (s *WebService) handleLeaderboards(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	interval := query.Get("interval")
+	if interval == "" {
+		interval = model.IntervalDay
+	}
+	limit := model.DefaultLeaderboardSize
+	if limitStr := query.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
+			limit = l
+		}
+	}
+
+	s.db.RLock()
+	boards, err := s.db.GetLeaderboards(interval, limit)
+	captureEnabled := s.db.CapturesUniverse()
+	s.db.RUnlock()
 	if err != nil {
-		s.log.Error("Failed to upgrade to WebSocket: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	defer conn.Close()
-	// Register client
-	s.clientsLock.Lock()
-	s.clients[conn] = true
-	s.writeLockers[conn] = &sync.Mutex{}
-	s.clientsLock.Unlock()
-	// Remove client when connection closes
-	defer func() {
-		s.clientsLock.Lock()
-		delete(s.clients, conn)
-		delete(s.writeLockers, conn)
-		s.clientsLock.Unlock()
-	}()
-
-	// Keep connection alive
-	for {
-		_, _, err := conn.ReadMessage()
-		if err != nil {
-			break
+
+	// Either a single period, or the latest periods
+	if period := query.Get("period"); period != "" {
+		found := []model.Leaderboard{}
+		for _, board := range boards {
+			if board.Period == period {
+				found = append(found, board)
+			}
+		}
+		boards = found
+	} else if periodsStr := query.Get("periods"); periodsStr != "" {
+		if p, err := strconv.Atoi(periodsStr); err == nil && p > 0 && len(boards) > p {
+			boards = boards[:p]
 		}
 	}
+
+	// Set headers to prevent caching
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(leaderboardsResponse{
+		CaptureEnabled: captureEnabled,
+		Interval:       interval,
+		Leaderboards:   boards,
+	})
 }
 
-// BroadcastEvent sends an event to all connected WebSocket clients
-func (s *WebService) BroadcastEvent(eventType string, data interface{}) {
-	// Format timestamps for global and hof events
-	if eventType == "new_global" || eventType == "new_hof" {
-		if entry, ok := data.(*storage.GlobalEntry); ok {
-			// Convert to JSON-friendly object with ISO8601 UTC timestamp
-			jsonEntry := model.GlobalEntryJSON{
-				Timestamp:  entry.Timestamp.UTC().Format(time.RFC3339),
-				Type:       entry.Type,
-				PlayerName: entry.PlayerName,
-				TeamName:   entry.TeamName,
-				Target:     entry.Target,
-				Value:      entry.Value,
-				Location:   entry.Location,
-				IsHof:      entry.IsHof,
-				RawMessage: entry.RawMessage,
-			}
-			data = jsonEntry
+// heatmapFromQuery builds the heatmap selected by the scope, type and target query parameters
+func (s *WebService) heatmapFromQuery(r *http.Request) (model.Heatmap, error) {
+	query := r.URL.Query()
+	filter := model.HeatmapFilter{
+		Type:   query.Get("type"),
+		Target: query.Get("target"),
+	}
+	s.db.RLock()
+	defer s.db.RUnlock()
+	return s.db.GetHeatmap(query.Get("scope"), filter)
+}
+
+// handleHeatmap handles the weekday and hour heatmap API endpoint
+func (s *WebService) handleHeatmap(w http.ResponseWriter, r *http.Request) {
+	heatmap, err := s.heatmapFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Set headers to prevent caching
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(heatmap)
+}
+
+// handleHeatmapSVG handles the heatmap image endpoint
+func (s *WebService) handleHeatmapSVG(w http.ResponseWriter, r *http.Request) {
+	heatmap, err := s.heatmapFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	metric := r.URL.Query().Get("metric")
+	if metric != "" && metric != "count" && metric != "value" {
+		http.Error(w, fmt.Sprintf("invalid metric %q: must be count or value", metric), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Content-Type", "image/svg+xml")
+	fmt.Fprint(w, stats.RenderHeatmapSVG(heatmap, metric))
+}
+
+// compareQuery reads the datasets of a comparison from the query parameters preset, a_label,
+// a_from, a_to, a_player, a_team and the same for b. Without any, the previous month is
+// compared with the current one.
+func compareQuery(r *http.Request) (string, model.DatasetQuery, model.DatasetQuery) {
+	query := r.URL.Query()
+	side := func(prefix string) model.DatasetQuery {
+		return model.DatasetQuery{
+			Label:  query.Get(prefix + "_label"),
+			Player: query.Get(prefix + "_player"),
+			Team:   query.Get(prefix + "_team"),
+			From:   query.Get(prefix + "_from"),
+			To:     query.Get(prefix + "_to"),
 		}
 	}
+	a, b := side("a"), side("b")
 
-	event := map[string]interface{}{
-		"type": eventType,
-		"data": data,
-		"time": time.Now().UTC().Format(time.RFC3339),
+	preset := query.Get("preset")
+	if preset == "" && a == (model.DatasetQuery{}) && b == (model.DatasetQuery{}) {
+		preset = model.IntervalMonth
 	}
+	return preset, a, b
+}
 
-	payload, err := json.Marshal(event)
+// handleCompare handles the comparison API endpoint
+func (s *WebService) handleCompare(w http.ResponseWriter, r *http.Request) {
+	preset, a, b := compareQuery(r)
+	filterA, filterB, err := model.ParseDatasets(preset, a, b, analysis.WallClockNow())
 	if err != nil {
-		s.log.Error("Failed to marshal event: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	s.clientsLock.Lock()
-	clientsCopy := make(map[*websocket.Conn]*sync.Mutex)
-	for client := range s.clients {
-		clientsCopy[client] = s.writeLockers[client]
+
+	// Set headers to prevent caching
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "application/json")
+
+	s.db.RLock()
+	comparison := s.db.CompareDatasets(filterA, filterB)
+	s.db.RUnlock()
+	json.NewEncoder(w).Encode(comparison)
+}
+
+// handleComparePage renders the comparison page, with a form to choose the datasets
+func (s *WebService) handleComparePage(w http.ResponseWriter, r *http.Request) {
+	preset, a, b := compareQuery(r)
+	data := map[string]interface{}{
+		"PlayerName": s.playerName,
+		"Preset":     preset,
+		"A":          a,
+		"B":          b,
+		"Generated":  time.Now().UTC().Format(time.RFC3339),
+	}
+
+	filterA, filterB, err := model.ParseDatasets(preset, a, b, analysis.WallClockNow())
+	if err != nil {
+		data["Error"] = err.Error()
+	} else {
+		s.db.RLock()
+		comparison := s.db.CompareDatasets(filterA, filterB)
+		s.db.RUnlock()
+		data["Comparison"] = comparison
+		data["DescriptionA"] = stats.DatasetDescription(comparison.A)
+		data["DescriptionB"] = stats.DatasetDescription(comparison.B)
 	}
-	s.clientsLock.Unlock()
 
-	for client, mutex := range clientsCopy {
-		go func(c *websocket.Conn, mu *sync.Mutex) {
-			mu.Lock()
-			defer mu.Unlock()
+	// Set headers to prevent caching
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "text/html")
 
-			if err := c.WriteMessage(websocket.TextMessage, payload); err != nil {
-				s.log.Error("Failed to send WebSocket message: %v", err)
-				c.Close()
+	if err := s.templates.ExecuteTemplate(w, "compare.html", data); err != nil {
+		s.log.Error("Failed to render template: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+	}
+}
 
-				s.clientsLock.Lock()
-				delete(s.clients, c)
-				delete(s.writeLockers, c)
-				s.clientsLock.Unlock()
-			}
-		}(client, mutex)
+// handleGoals handles the goal progress API endpoint
+func (s *WebService) handleGoals(w http.ResponseWriter, r *http.Request) {
+	// Set headers to prevent caching
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "application/json")
+
+	s.db.RLock()
+	progress := s.db.GetGoalProgress(analysis.WallClockNow())
+	s.db.RUnlock()
+	json.NewEncoder(w).Encode(progress)
+}
+
+// handleAddGoal adds the goal in the JSON request body
+func (s *WebService) handleAddGoal(w http.ResponseWriter, r *http.Request) {
+	var goal model.Goal
+	if err := json.NewDecoder(r.Body).Decode(&goal); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	added, err := AddGoal(s.db, goal, s.log)
+	if err != nil {
+		writeEditError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(added)
+}
+
+// handleRemoveGoal removes a goal
+func (s *WebService) handleRemoveGoal(w http.ResponseWriter, r *http.Request) {
+	if err := RemoveGoal(s.db, r.PathValue("id"), s.log); err != nil {
+		writeEditError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// achievementsResponse is the body of /api/achievements
+type achievementsResponse struct {
+	Unlocked []model.AchievementUnlock `json:"unlocked"`
+	Locked   []model.Achievement       `json:"locked"`
+}
+
+// handleAchievements handles the achievements API endpoint
+func (s *WebService) handleAchievements(w http.ResponseWriter, r *http.Request) {
+	s.db.RLock()
+	unlocked, locked := s.db.GetAchievements()
+	s.db.RUnlock()
+
+	// Set headers to prevent caching
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(achievementsResponse{Unlocked: unlocked, Locked: locked})
+}
+
+// handleRecords handles the personal records API endpoint
+func (s *WebService) handleRecords(w http.ResponseWriter, r *http.Request) {
+	// Set headers to prevent caching
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "application/json")
+
+	s.db.RLock()
+	book := s.db.GetRecordBook()
+	s.db.RUnlock()
+	json.NewEncoder(w).Encode(book)
+}
+
+// handleMarkups handles the markups API endpoint, which lists the markups in use and the
+// targets that are still valued at TT
+func (s *WebService) handleMarkups(w http.ResponseWriter, r *http.Request) {
+	// Set headers to prevent caching
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "application/json")
+
+	s.db.RLock()
+	report := s.db.GetMarkupReport()
+	s.db.RUnlock()
+	json.NewEncoder(w).Encode(report)
+}
+
+// handleSessions handles the sessions API endpoint
+func (s *WebService) handleSessions(w http.ResponseWriter, r *http.Request) {
+	s.db.RLock()
+	sessions := s.db.GetSessions()
+	s.db.RUnlock()
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && len(sessions) > l {
+			sessions = sessions[:l]
+		}
+	}
+
+	// Set headers to prevent caching
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Pragma", "no-cache")
+	w.Header().Set("Expires", "0")
+	w.Header().Set("Content-Type", "application/json")
+
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// handleSession handles the API endpoint for a single session with its globals
+func (s *WebService) handleSession(w http.ResponseWriter, r *http.Request) {
+	s.db.RLock()
+	detail, ok := s.db.GetSession(r.PathValue("id"))
+	s.db.RUnlock()
+	if !ok {
+		http.Error(w, "session not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
+// handleGlobal handles requests for a single stored global by ID
+func (s *WebService) handleGlobal(w http.ResponseWriter, r *http.Request) {
+	s.db.RLock()
+	entry, ok := s.db.GetGlobal(r.PathValue("id"))
+	s.db.RUnlock()
+	if !ok {
+		http.Error(w, storage.ErrGlobalNotFound.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toGlobalEntryJSON(entry))
+}
+
+// handleEditGlobal handles edits to a single stored global
+func (s *WebService) handleEditGlobal(w http.ResponseWriter, r *http.Request) {
+	var edit storage.GlobalEdit
+	if err := json.NewDecoder(r.Body).Decode(&edit); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := EditGlobal(s.db, r.PathValue("id"), edit, s.author(r), s.log)
+	if err != nil {
+		writeEditError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(toGlobalEntryJSON(*entry))
+}
+
+// handleDeleteGlobal handles deletion of a single stored global
+func (s *WebService) handleDeleteGlobal(w http.ResponseWriter, r *http.Request) {
+	if err := DeleteGlobal(s.db, r.PathValue("id"), s.author(r), s.log); err != nil {
+		writeEditError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeEditError maps a mutation error to an HTTP status
+func writeEditError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, storage.ErrGlobalNotFound), errors.Is(err, errGoalNotFound), errors.Is(err, errOverlayNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, errSaveFailed):
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// wsEvent is a message sent to WebSocket clients
+type wsEvent struct {
+	Op   string      `json:"op"`  // Always event, to tell events from v2 protocol messages
+	Seq  uint64      `json:"seq"` // Increases by one with every event of the stream
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+	Time string      `json:"time"` // RFC3339 in UTC
+}
+
+// newWSEvent creates an event sent now, with globals converted to GlobalEntryJSON
+func newWSEvent(eventType string, data interface{}) wsEvent {
+	// Format timestamps for global and hof events
+	switch entry := data.(type) {
+	case *storage.GlobalEntry:
+		// Convert to JSON-friendly object with ISO8601 UTC timestamp
+		data = toGlobalEntryJSON(*entry)
+	case storage.GlobalEntry:
+		data = toGlobalEntryJSON(entry)
+	}
+	return wsEvent{Op: "event", Type: eventType, Data: data, Time: time.Now().UTC().Format(time.RFC3339)}
+}
+
+// BroadcastEvent numbers an event and sends it to the WebSocket clients that subscribed to it
+func (s *WebService) BroadcastEvent(eventType string, data interface{}) {
+	if _, err := s.events.publish(eventType, data); err != nil {
+		s.log.Error("Failed to marshal event: %v", err)
+	}
+}
+
+// toGlobalEntryJSON converts a stored global to its JSON form with an ISO8601 UTC timestamp
+func toGlobalEntryJSON(g storage.GlobalEntry) model.GlobalEntryJSON {
+	return model.GlobalEntryJSON{
+		ID:         g.ID,
+		Timestamp:  g.Timestamp.UTC().Format(time.RFC3339),
+		Type:       g.Type,
+		PlayerName: g.PlayerName,
+		TeamName:   g.TeamName,
+		Target:     g.Target,
+		Value:      g.Value,
+		Location:   g.Location,
+		IsHof:      g.IsHof,
+		RawMessage: g.RawMessage,
+		Records:    g.Records,
+		Member:     g.Member,
 	}
 }
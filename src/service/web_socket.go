@@ -0,0 +1,164 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// WebSocketProtocolV2 is the subprotocol of clients that subscribe to events and resume
+// after a reconnect. Clients without it receive every event as it happens.
+const WebSocketProtocolV2 = "eu-clams.v2"
+
+// WebSocket timing
+const (
+	wsWriteWait      = 10 * time.Second    // Time allowed to write a message
+	wsPongWait       = 60 * time.Second    // Default time allowed without a pong or message from the client
+	wsPingPeriod     = wsPongWait * 9 / 10 // Pings are sent before the pong wait runs out
+	wsMaxMessageSize = 4096                // Largest message accepted from a client
+)
+
+// wsClientMessage is a message from a v2 client. With op subscribe, the filter replaces the
+// previous one and stream and seq of the last event received replay the events since then.
+type wsClientMessage struct {
+	Op string `json:"op"` // subscribe or unsubscribe
+	eventFilter
+	Stream string `json:"stream,omitempty"`
+	Seq    uint64 `json:"seq,omitempty"`
+}
+
+// wsControl is a protocol message to a v2 client
+type wsControl struct {
+	Op       string `json:"op"` // welcome, subscribed or error
+	Version  int    `json:"version,omitempty"`
+	Stream   string `json:"stream,omitempty"`
+	Seq      uint64 `json:"seq"`              // Sequence number of the latest event
+	Oldest   uint64 `json:"oldest,omitempty"` // Oldest event that can be replayed
+	Replayed int    `json:"replayed"`
+	Missed   bool   `json:"missed"` // Events since seq are lost; reload the state instead
+	Message  string `json:"message,omitempty"`
+}
+
+// handleWebSocket handles WebSocket connections. Every client gets a writer with a queue, so
+// a slow client cannot hold up the others, and is pinged to detect dead connections.
+func (s *WebService) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		s.log.Error("Failed to upgrade to WebSocket: %v", err)
+		return
+	}
+	v2 := conn.Subprotocol() == WebSocketProtocolV2
+
+	// v2 clients receive nothing until they subscribe
+	sub := s.events.join(!v2, eventFilter{})
+	defer s.events.leave(sub)
+	serviceMetrics.webSocketClients.Inc()
+	defer serviceMetrics.webSocketClients.Dec()
+
+	var welcome []byte
+	if v2 {
+		stream, seq, oldest := s.events.position()
+		welcome, _ = json.Marshal(wsControl{Op: "welcome", Version: 2, Stream: stream, Seq: seq, Oldest: oldest})
+	}
+	done := make(chan struct{})
+	defer close(done)
+	go s.writeWebSocket(conn, sub, welcome, done)
+
+	s.readWebSocket(conn, sub, v2)
+}
+
+// readWebSocket handles the messages of a client until the connection fails or the client
+// stops answering pings
+func (s *WebService) readWebSocket(conn *websocket.Conn, sub *eventSubscriber, v2 bool) {
+	conn.SetReadLimit(wsMaxMessageSize)
+	conn.SetReadDeadline(time.Now().Add(s.pongWait))
+	conn.SetPongHandler(func(string) error { return conn.SetReadDeadline(time.Now().Add(s.pongWait)) })
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		conn.SetReadDeadline(time.Now().Add(s.pongWait))
+		if !v2 {
+			continue
+		}
+
+		var msg wsClientMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			s.replyWebSocket(sub, wsControl{Op: "error", Message: "invalid message: " + err.Error()})
+			continue
+		}
+		switch msg.Op {
+		case "subscribe":
+			if err := validateEventTypes(msg.Types); err != nil {
+				s.replyWebSocket(sub, wsControl{Op: "error", Message: err.Error()})
+				continue
+			}
+			s.events.subscribe(sub, msg.eventFilter, msg.Stream, msg.Seq, func(seq uint64, replayed int, missed bool) []byte {
+				reply, _ := json.Marshal(wsControl{Op: "subscribed", Stream: s.events.stream, Seq: seq, Replayed: replayed, Missed: missed})
+				return reply
+			})
+		case "unsubscribe":
+			s.events.pause(sub)
+		default:
+			s.replyWebSocket(sub, wsControl{Op: "error", Message: fmt.Sprintf("unknown op %q: must be subscribe or unsubscribe", msg.Op)})
+		}
+	}
+}
+
+// replyWebSocket queues a protocol message for a client
+func (s *WebService) replyWebSocket(sub *eventSubscriber, msg wsControl) {
+	payload, _ := json.Marshal(msg)
+	s.events.notify(sub, payload)
+}
+
+// writeWebSocket writes the first message, the queued messages and pings until the queue is
+// closed, a write fails or the reader is done
+func (s *WebService) writeWebSocket(conn *websocket.Conn, sub *eventSubscriber, first []byte, done <-chan struct{}) {
+	ticker := time.NewTicker(s.pongWait * 9 / 10)
+	defer ticker.Stop()
+	defer conn.Close()
+
+	write := func(messageType int, data []byte) error {
+		conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+		return conn.WriteMessage(messageType, data)
+	}
+	if first != nil && write(websocket.TextMessage, first) != nil {
+		return
+	}
+	for {
+		select {
+		case e, ok := <-sub.send:
+			if !ok {
+				// Dropped for falling behind or shut down: the client should reconnect and resume
+				write(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "reconnect and resume"))
+				return
+			}
+			if err := write(websocket.TextMessage, e.payload); err != nil {
+				s.log.Error("Failed to send WebSocket message: %v", err)
+				return
+			}
+		case <-ticker.C:
+			if err := write(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// validateEventTypes checks that a subscription only names known event types
+func validateEventTypes(types []string) error {
+	for _, t := range types {
+		if !slices.ContainsFunc(webSocketEvents, func(e webSocketEvent) bool { return e.Type == t }) {
+			return fmt.Errorf("unknown event type %q", t)
+		}
+	}
+	return nil
+}
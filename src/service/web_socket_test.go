@@ -0,0 +1,202 @@
+package service
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialWebSocket connects to the WebSocket of a test server, with the v2 subprotocol if v2 is set
+func dialWebSocket(t *testing.T, server *httptest.Server, v2 bool) *websocket.Conn {
+	t.Helper()
+	dialer := *websocket.DefaultDialer
+	if v2 {
+		dialer.Subprotocols = []string{WebSocketProtocolV2}
+	}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readMessage reads the next message of a connection as a map
+func readMessage(t *testing.T, conn *websocket.Conn) map[string]interface{} {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var msg map[string]interface{}
+	if err := conn.ReadJSON(&msg); err != nil {
+		t.Fatalf("reading message: %v", err)
+	}
+	return msg
+}
+
+// waitForSubscribers waits until the hub has n subscribers
+func waitForSubscribers(t *testing.T, s *WebService, n int) {
+	t.Helper()
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		s.events.mu.Lock()
+		count := len(s.events.subs)
+		s.events.mu.Unlock()
+		if count == n {
+			return
+		}
+	}
+	t.Fatalf("hub does not have %d subscribers", n)
+}
+
+func TestWebSocketV1(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+	server := httptest.NewServer(s.routes())
+	defer server.Close()
+
+	conn := dialWebSocket(t, server, false)
+	waitForSubscribers(t, s, 1)
+	s.BroadcastEvent("new_global", s.db.Globals[0])
+	s.BroadcastEvent("stats_update", s.db.GetStatsData())
+
+	if msg := readMessage(t, conn); msg["type"] != "new_global" || msg["seq"] != 1.0 || msg["op"] != "event" {
+		t.Errorf("first message = %v, want new_global with seq 1", msg)
+	}
+	if msg := readMessage(t, conn); msg["type"] != "stats_update" || msg["seq"] != 2.0 {
+		t.Errorf("second message = %v, want stats_update with seq 2", msg)
+	}
+}
+
+func TestWebSocketV2Subscribe(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+	server := httptest.NewServer(s.routes())
+	defer server.Close()
+
+	conn := dialWebSocket(t, server, true)
+	if conn.Subprotocol() != WebSocketProtocolV2 {
+		t.Fatalf("subprotocol = %q, want %s", conn.Subprotocol(), WebSocketProtocolV2)
+	}
+	welcome := readMessage(t, conn)
+	if welcome["op"] != "welcome" || welcome["version"] != 2.0 || welcome["stream"] == "" {
+		t.Fatalf("welcome = %v", welcome)
+	}
+
+	// Nothing is sent before subscribing
+	s.BroadcastEvent("new_global", s.db.Globals[1])
+
+	conn.WriteJSON(map[string]interface{}{"op": "subscribe", "types": []string{"new_global", "new_hof"}, "min_value": 80})
+	if msg := readMessage(t, conn); msg["op"] != "subscribed" || msg["seq"] != 1.0 || msg["replayed"] != 0.0 {
+		t.Fatalf("reply = %v, want subscribed at seq 1", msg)
+	}
+	s.BroadcastEvent("stats_update", s.db.GetStatsData())
+	s.BroadcastEvent("new_global", s.db.Globals[1]) // 60 PED
+	s.BroadcastEvent("new_hof", s.db.Globals[2])
+	if msg := readMessage(t, conn); msg["type"] != "new_hof" || msg["seq"] != 4.0 {
+		t.Errorf("event = %v, want only the HoF with seq 4", msg)
+	}
+
+	conn.WriteJSON(map[string]interface{}{"op": "subscribe", "types": []string{"loot"}})
+	if msg := readMessage(t, conn); msg["op"] != "error" || !strings.Contains(msg["message"].(string), "loot") {
+		t.Errorf("reply = %v, want an error about the unknown type", msg)
+	}
+}
+
+func TestWebSocketV2Resume(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+	server := httptest.NewServer(s.routes())
+	defer server.Close()
+
+	for _, g := range s.db.Globals {
+		s.BroadcastEvent("new_global", g)
+	}
+	stream, _, _ := s.events.position()
+
+	conn := dialWebSocket(t, server, true)
+	readMessage(t, conn)
+	conn.WriteJSON(map[string]interface{}{"op": "subscribe", "stream": stream, "seq": 1})
+	if msg := readMessage(t, conn); msg["op"] != "subscribed" || msg["replayed"] != 2.0 || msg["missed"] != false {
+		t.Fatalf("reply = %v, want 2 replayed and none missed", msg)
+	}
+	for _, want := range []float64{2, 3} {
+		if msg := readMessage(t, conn); msg["seq"] != want {
+			t.Errorf("replayed seq = %v, want %v", msg["seq"], want)
+		}
+	}
+
+	// Numbers of another stream cannot be resumed
+	conn.WriteJSON(map[string]interface{}{"op": "subscribe", "stream": "restarted", "seq": 2})
+	if msg := readMessage(t, conn); msg["missed"] != true || msg["replayed"] != 0.0 {
+		t.Errorf("reply = %v, want missed events", msg)
+	}
+}
+
+func TestWebSocketDeadPeer(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+	s.pongWait = 200 * time.Millisecond
+	server := httptest.NewServer(s.routes())
+	defer server.Close()
+
+	// The client never reads, so it never answers the pings
+	dialWebSocket(t, server, false)
+	waitForSubscribers(t, s, 1)
+	waitForSubscribers(t, s, 0)
+
+	// A client that reads answers them and stays connected
+	conn := dialWebSocket(t, server, false)
+	go func() {
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+	time.Sleep(3 * s.pongWait)
+	waitForSubscribers(t, s, 1)
+}
+
+func TestEventHubDropsSlowSubscribers(t *testing.T) {
+	t.Parallel()
+	h := newEventHub()
+	slow := h.join(true, eventFilter{})
+	for i := 0; i < cap(slow.send)+1; i++ {
+		h.publish("stats_update", i)
+	}
+	if _, ok := <-slow.send; !ok {
+		t.Fatal("queue closed before its events were read")
+	}
+	for range slow.send {
+	}
+	if len(h.subs) != 0 {
+		t.Error("slow subscriber was not dropped")
+	}
+
+	// Only the latest events are kept
+	stream, seq, oldest := h.position()
+	if seq-oldest+1 != eventBufferSize {
+		t.Errorf("buffer holds %d events, want %d", seq-oldest+1, eventBufferSize)
+	}
+	if replay, missed := h.since(eventFilter{}, stream, 1); !missed || len(replay) != eventBufferSize {
+		t.Errorf("since(1) = %d events, missed %v, want the buffer and missed", len(replay), missed)
+	}
+}
+
+func TestWebSocketStopClosesClients(t *testing.T) {
+	t.Parallel()
+	s := newTestWebService(t)
+	server := httptest.NewServer(s.routes())
+	defer server.Close()
+
+	conn := dialWebSocket(t, server, false)
+	waitForSubscribers(t, s, 1)
+	s.Stop()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, _, err := conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseTryAgainLater) {
+		t.Errorf("read after Stop = %v, want a try-again-later close", err)
+	}
+}
@@ -0,0 +1,10 @@
+// Package templates holds the web dashboard's HTML templates and static files, embedded in
+// the binary so the web server works without the templates folder next to it
+package templates
+
+import "embed"
+
+// FS contains index.html, compare.html, login.html, overlay.html, team.html and the static directory
+//
+//go:embed *.html static
+var FS embed.FS
@@ -26,6 +26,56 @@ document.addEventListener('DOMContentLoaded', function() {
         }
     });
     
+    // Redraw the time series chart when its options change
+    document.getElementById('timeseries-interval').addEventListener('change', refreshTimeSeries);
+    document.getElementById('timeseries-metric').addEventListener('change', refreshTimeSeries);
+
+    // Redraw the value distribution when its options change
+    document.getElementById('values-view').addEventListener('change', refreshValues);
+    document.getElementById('values-type').addEventListener('change', refreshValues);
+
+    // Redraw the heatmap for the selected globals; changing the type resets the target
+    ['heatmap-scope', 'heatmap-type'].forEach(function(id) {
+        document.getElementById(id).addEventListener('change', function() {
+            document.getElementById('heatmap-target').value = '';
+            refreshHeatmap();
+        });
+    });
+    document.getElementById('heatmap-metric').addEventListener('change', refreshHeatmap);
+    document.getElementById('heatmap-target').addEventListener('change', refreshHeatmap);
+
+    // Switch the leaderboard period
+    document.getElementById('leaderboard-interval').addEventListener('change', function() {
+        document.getElementById('leaderboard-period').value = '';
+        refreshLeaderboards();
+    });
+    document.getElementById('leaderboard-period').addEventListener('change', refreshLeaderboards);
+
+    // Recompute droughts when the streak window or grouping changes
+    document.getElementById('drought-window').addEventListener('change', refreshDroughts);
+    document.getElementById('drought-group').addEventListener('change', refreshDroughts);
+
+    // Add a goal from the goal form
+    document.getElementById('goal-form').addEventListener('submit', function(event) {
+        event.preventDefault();
+        addGoal();
+    });
+
+    // Filter and sort the target table
+    document.getElementById('target-search').addEventListener('input', refreshTargets);
+    document.getElementById('target-type').addEventListener('change', refreshTargets);
+    document.querySelectorAll('#target-headers th').forEach(function(th) {
+        th.addEventListener('click', function() {
+            if (targetSort.field === th.dataset.sort) {
+                targetSort.order = targetSort.order === 'desc' ? 'asc' : 'desc';
+            } else {
+                targetSort.field = th.dataset.sort;
+                targetSort.order = th.dataset.sort === 'target' || th.dataset.sort === 'type' ? 'asc' : 'desc';
+            }
+            refreshTargets();
+        });
+    });
+
     // Immediately refresh data when the page loads
     refreshData();
     
@@ -58,15 +108,561 @@ function refreshData() {
             updateHofs(hofs);
         })
         .catch(error => console.error('Error fetching HOFs:', error));
+
+    refreshTimeSeries();
+    refreshTargets();
+    refreshDroughts();
+    refreshValues();
+    refreshSessions();
+    refreshGoals();
+    refreshRecords();
+    refreshMarkups();
+    refreshLeaderboards();
+    refreshHeatmap();
+
       // Update last updated time with browser-localized format
     document.getElementById('last-updated').textContent = new Date().toLocaleString();
 }
 
+// Current sort order of the target table
+const targetSort = { field: 'total_value', order: 'desc' };
+
+// Function to fetch per-target statistics with the current filter and sort order
+function refreshTargets() {
+    const params = new URLSearchParams({
+        q: document.getElementById('target-search').value,
+        type: document.getElementById('target-type').value,
+        sort: targetSort.field,
+        order: targetSort.order
+    });
+
+    fetch(`/api/stats/targets?${params}`)
+        .then(response => response.json())
+        .then(targets => updateTargets(targets))
+        .catch(error => console.error('Error fetching target statistics:', error));
+}
+
+// Function to update the target table
+function updateTargets(targets) {
+    const table = document.getElementById('globals-by-target');
+    table.innerHTML = '';
+
+    if (targets.length === 0) {
+        const row = table.insertRow();
+        const cell = row.insertCell(0);
+        cell.colSpan = 10;
+        cell.textContent = "No target data available";
+        cell.className = "no-data";
+        return;
+    }
+
+    for (const target of targets) {
+        const row = table.insertRow();
+        row.insertCell().textContent = target.target;
+        row.insertCell().textContent = target.type;
+        row.insertCell().textContent = target.count;
+        row.insertCell().textContent = target.total_value.toFixed(2);
+        const market = row.insertCell();
+        market.textContent = target.market_value.toFixed(2);
+        if (!target.has_markup) {
+            market.className = 'no-markup';
+            market.title = 'No markup, valued at TT';
+        }
+        row.insertCell().textContent = target.average_value.toFixed(2);
+        row.insertCell().textContent = target.median_value.toFixed(2);
+        row.insertCell().textContent = target.max_value.toFixed(2);
+        row.insertCell().textContent = target.hofs;
+        row.insertCell().textContent = (target.hof_rate * 100).toFixed(1) + '%';
+    }
+
+    // Mark the sorted column
+    document.querySelectorAll('#target-headers th').forEach(function(th) {
+        th.classList.toggle('sorted-asc', th.dataset.sort === targetSort.field && targetSort.order === 'asc');
+        th.classList.toggle('sorted-desc', th.dataset.sort === targetSort.field && targetSort.order === 'desc');
+    });
+}
+
+// Function to reload the heatmap image and the targets that can be drilled down into
+function refreshHeatmap() {
+    const targetSelect = document.getElementById('heatmap-target');
+    const params = new URLSearchParams({
+        scope: document.getElementById('heatmap-scope').value,
+        type: document.getElementById('heatmap-type').value,
+        target: targetSelect.value
+    });
+
+    document.getElementById('heatmap-image').src =
+        `/api/heatmap.svg?${params}&metric=${document.getElementById('heatmap-metric').value}&t=${Date.now()}`;
+
+    // The target list follows scope and type, not the selected target
+    const listParams = new URLSearchParams({ scope: params.get('scope'), type: params.get('type') });
+    fetch(`/api/heatmap?${listParams}`)
+        .then(response => response.json())
+        .then(heatmap => {
+            const selected = targetSelect.value;
+            targetSelect.innerHTML = '';
+            targetSelect.add(new Option('All targets', ''));
+            for (const t of heatmap.targets) {
+                targetSelect.add(new Option(`${t.target} (${t.count})`, t.target));
+            }
+            targetSelect.value = selected;
+        })
+        .catch(error => console.error('Error fetching heatmap:', error));
+}
+
+// Function to fetch the universe leaderboards of the selected period
+function refreshLeaderboards() {
+    const interval = document.getElementById('leaderboard-interval').value;
+
+    fetch(`/api/leaderboards?interval=${interval}&limit=10`)
+        .then(response => response.json())
+        .then(result => updateLeaderboards(result))
+        .catch(error => console.error('Error fetching leaderboards:', error));
+}
+
+// Function to update the period list and the leaderboard tables
+function updateLeaderboards(result) {
+    const note = document.getElementById('leaderboard-note');
+    const periodSelect = document.getElementById('leaderboard-period');
+    const selected = periodSelect.value;
+
+    periodSelect.innerHTML = '';
+    for (const board of result.leaderboards) {
+        periodSelect.add(new Option(board.period, board.period));
+    }
+    if (result.leaderboards.some(board => board.period === selected)) {
+        periodSelect.value = selected;
+    }
+
+    const board = result.leaderboards.find(b => b.period === periodSelect.value);
+    note.hidden = !!board;
+    note.textContent = result.capture_enabled
+        ? 'No globals captured yet'
+        : 'Universe capture is off. Set capture_universe: true in the configuration to collect everyone\'s globals.';
+
+    const rankRows = (id, entries) => {
+        const table = document.getElementById(id);
+        table.innerHTML = '';
+        (entries || []).forEach((e, i) => {
+            const row = table.insertRow();
+            row.insertCell().textContent = i + 1;
+            row.insertCell().textContent = e.name;
+            row.insertCell().textContent = e.count;
+            row.insertCell().textContent = e.total_value.toFixed(2);
+        });
+    };
+    rankRows('leaderboard-players', board && board.players);
+    rankRows('leaderboard-teams', board && board.teams);
+    rankRows('leaderboard-creatures', board && board.creatures);
+
+    const hofs = document.getElementById('leaderboard-hofs');
+    hofs.innerHTML = '';
+    ((board && board.hofs) || []).forEach((hof, i) => {
+        const row = hofs.insertRow();
+        row.insertCell().textContent = i + 1;
+        row.insertCell().textContent = hof.playerName || hof.teamName;
+        row.insertCell().textContent = hof.target;
+        row.insertCell().textContent = hof.value.toFixed(2);
+    });
+}
+
+// ID of the session shown in detail, if any
+let selectedSession = null;
+
+// Function to fetch the session list
+function refreshSessions() {
+    fetch('/api/sessions?limit=20')
+        .then(response => response.json())
+        .then(sessions => updateSessions(sessions))
+        .catch(error => console.error('Error fetching sessions:', error));
+}
+
+// Function to update the session list
+function updateSessions(sessions) {
+    const table = document.getElementById('sessions');
+    table.innerHTML = '';
+
+    if (sessions.length === 0) {
+        const row = table.insertRow();
+        const cell = row.insertCell(0);
+        cell.colSpan = 6;
+        cell.textContent = "No sessions recorded";
+        cell.className = "no-data";
+        return;
+    }
+
+    for (const session of sessions) {
+        const row = table.insertRow();
+        row.insertCell().textContent = session.start;
+        row.insertCell().textContent = formatDuration(session.duration_seconds) + (session.open ? ' (open)' : '');
+        row.insertCell().textContent = session.globals;
+        row.insertCell().textContent = session.hofs;
+        row.insertCell().textContent = session.total_value.toFixed(2);
+        row.insertCell().textContent = session.locations.join(', ');
+        row.dataset.id = session.id;
+        row.classList.toggle('selected', session.id === selectedSession);
+        row.addEventListener('click', () => showSession(session.id));
+    }
+
+    if (selectedSession) {
+        showSession(selectedSession);
+    }
+}
+
+// Function to fetch the personal records
+function refreshRecords() {
+    fetch('/api/records')
+        .then(response => response.json())
+        .then(book => updateRecords(book))
+        .catch(error => console.error('Error fetching records:', error));
+}
+
+// Function to fetch the markups and the targets without one
+function refreshMarkups() {
+    fetch('/api/markups')
+        .then(response => response.json())
+        .then(report => updateMarkups(report))
+        .catch(error => console.error('Error fetching markups:', error));
+}
+
+// Function to update the markups card
+function updateMarkups(report) {
+    const markups = document.getElementById('markups');
+    markups.innerHTML = '';
+    if (report.markups.length === 0) {
+        const row = markups.insertRow();
+        const cell = row.insertCell(0);
+        cell.colSpan = 2;
+        cell.textContent = "No markups configured, everything is valued at TT";
+        cell.className = "no-data";
+    }
+    for (const m of report.markups) {
+        const row = markups.insertRow();
+        row.insertCell().textContent = m.item;
+        row.insertCell().textContent = m.percent ? m.percent + '%' : (m.absolute >= 0 ? '+' : '') + (m.absolute || 0).toFixed(2);
+    }
+
+    const missing = document.getElementById('missing-markups');
+    missing.innerHTML = '';
+    if (report.missing.length === 0) {
+        const row = missing.insertRow();
+        const cell = row.insertCell(0);
+        cell.colSpan = 4;
+        cell.textContent = "Every target has a markup";
+        cell.className = "no-data";
+        return;
+    }
+    for (const m of report.missing.slice(0, 20)) {
+        const row = missing.insertRow();
+        row.insertCell().textContent = m.item;
+        row.insertCell().textContent = m.type;
+        row.insertCell().textContent = m.count;
+        row.insertCell().textContent = m.total_value.toFixed(2);
+    }
+}
+
+// Function to update the personal records card
+function updateRecords(book) {
+    document.getElementById('record-overall').textContent = book.overall
+        ? book.overall.value.toFixed(2) + ' PED, ' + book.overall.target + ' (' + book.overall.timestamp + ')'
+        : '-';
+
+    const byType = document.getElementById('records-by-type');
+    byType.innerHTML = '';
+    for (const e of book.by_type) {
+        const row = byType.insertRow();
+        row.insertCell().textContent = e.type;
+        row.insertCell().textContent = e.target;
+        row.insertCell().textContent = e.value.toFixed(2);
+        row.insertCell().textContent = e.timestamp;
+    }
+
+    const history = document.getElementById('records-history');
+    history.innerHTML = '';
+    if (book.history.length === 0) {
+        const row = history.insertRow();
+        const cell = row.insertCell(0);
+        cell.colSpan = 4;
+        cell.textContent = "No records set yet";
+        cell.className = "no-data";
+        return;
+    }
+    for (const e of book.history.slice(0, 10)) {
+        const row = history.insertRow();
+        row.className = 'record';
+        row.insertCell().textContent = e.timestamp;
+        row.insertCell().textContent = e.target;
+        row.insertCell().textContent = e.value.toFixed(2);
+        row.insertCell().textContent = e.records.join(', ');
+    }
+}
+
+// Function to fetch goal progress and achievements
+function refreshGoals() {
+    fetch('/api/goals')
+        .then(response => response.json())
+        .then(goals => updateGoals(goals))
+        .catch(error => console.error('Error fetching goals:', error));
+
+    fetch('/api/achievements')
+        .then(response => response.json())
+        .then(achievements => updateAchievements(achievements))
+        .catch(error => console.error('Error fetching achievements:', error));
+}
+
+// Function to update the goal table
+function updateGoals(goals) {
+    const table = document.getElementById('goals');
+    table.innerHTML = '';
+
+    if (goals.length === 0) {
+        const row = table.insertRow();
+        const cell = row.insertCell(0);
+        cell.colSpan = 3;
+        cell.textContent = "No goals set";
+        cell.className = "no-data";
+        return;
+    }
+
+    for (const p of goals) {
+        const row = table.insertRow();
+        row.insertCell().textContent = p.goal.name + (p.period ? ' [' + p.period + ']' : '');
+
+        const progressCell = row.insertCell();
+        const bar = document.createElement('progress');
+        bar.max = 1;
+        bar.value = p.progress;
+        progressCell.appendChild(bar);
+        progressCell.append(' ' + p.current.toFixed(p.goal.metric === 'value' ? 2 : 0) + ' / ' + p.goal.threshold + (p.completed ? ' \u2714' : ''));
+
+        const removeButton = document.createElement('button');
+        removeButton.textContent = 'Remove';
+        removeButton.addEventListener('click', () => removeGoal(p.goal.id));
+        row.insertCell().appendChild(removeButton);
+    }
+}
+
+// Function to update the achievement list, unlocked ones first
+function updateAchievements(achievements) {
+    const list = document.getElementById('achievements');
+    list.innerHTML = '';
+
+    for (const a of achievements.unlocked.slice().reverse()) {
+        const item = document.createElement('li');
+        item.textContent = a.name + ' (' + a.unlocked_at + ') - ' + a.description;
+        list.appendChild(item);
+    }
+    for (const a of achievements.locked) {
+        const item = document.createElement('li');
+        item.className = 'locked';
+        item.textContent = a.name + ' - ' + a.description;
+        list.appendChild(item);
+    }
+}
+
+// Function to add a goal from the goal form
+function addGoal() {
+    const goal = {
+        metric: document.getElementById('goal-metric').value,
+        threshold: parseFloat(document.getElementById('goal-threshold').value),
+        type: document.getElementById('goal-type').value,
+        target: document.getElementById('goal-target').value,
+        period: document.getElementById('goal-period').value
+    };
+    fetch('/api/goals', { method: 'POST', body: JSON.stringify(goal) })
+        .then(response => {
+            if (!response.ok) {
+                return response.text().then(text => { throw new Error(text); });
+            }
+            document.getElementById('goal-form').reset();
+            refreshGoals();
+        })
+        .catch(error => alert('Could not add goal: ' + error.message));
+}
+
+// Function to remove a goal
+function removeGoal(id) {
+    fetch('/api/goals/' + encodeURIComponent(id), { method: 'DELETE' })
+        .then(() => refreshGoals())
+        .catch(error => console.error('Error removing goal:', error));
+}
+
+// Function to show the globals of a session below the session list
+function showSession(id) {
+    selectedSession = id;
+
+    fetch(`/api/sessions/${encodeURIComponent(id)}`)
+        .then(response => response.json())
+        .then(detail => {
+            document.getElementById('session-detail-title').textContent =
+                `Session ${detail.start} to ${detail.end}: ${detail.globals} globals, ${detail.total_value.toFixed(2)} PED`;
+
+            const table = document.getElementById('session-globals');
+            table.innerHTML = '';
+            for (const entry of detail.entries) {
+                const row = table.insertRow();
+                row.insertCell().textContent = entry.timestamp;
+                row.insertCell().textContent = entry.type;
+                row.insertCell().textContent = entry.target;
+                row.insertCell().textContent = entry.location || '';
+                row.insertCell().textContent = entry.value.toFixed(2);
+                if (entry.isHof) {
+                    row.classList.add('hof');
+                }
+            }
+            document.getElementById('session-detail').hidden = false;
+
+            document.querySelectorAll('#sessions tr').forEach(row => row.classList.toggle('selected', row.dataset.id === id));
+        })
+        .catch(error => console.error('Error fetching session:', error));
+}
+
+// Function to fetch the value distribution and redraw its chart
+function refreshValues() {
+    const view = document.getElementById('values-view').value;
+    const type = document.getElementById('values-type').value;
+
+    fetch(`/api/stats/values?type=${encodeURIComponent(type)}`)
+        .then(response => response.json())
+        .then(dist => {
+            const bins = dist.overall[view];
+            drawBarChart(document.getElementById('values-chart'),
+                bins.map(b => `${b.label} (${(b.share * 100).toFixed(0)}%)`),
+                bins.map(b => b.count));
+        })
+        .catch(error => console.error('Error fetching value distribution:', error));
+}
+
+// Function to format a number of seconds compactly, e.g. "12m" or "3h20m"
+function formatDuration(seconds) {
+    seconds = Math.floor(seconds);
+    if (seconds < 60) return `${seconds}s`;
+    if (seconds < 3600) return `${Math.floor(seconds / 60)}m`;
+    const minutes = String(Math.floor(seconds / 60) % 60).padStart(2, '0');
+    if (seconds < 86400) return `${Math.floor(seconds / 3600)}h${minutes}m`;
+    return `${Math.floor(seconds / 86400)}d${Math.floor(seconds / 3600) % 24}h`;
+}
+
+// Function to fetch the drought analysis for the selected streak window
+function refreshDroughts() {
+    const streakWindow = document.getElementById('drought-window').value;
+
+    fetch(`/api/stats/droughts?streak_window=${streakWindow}`)
+        .then(response => response.json())
+        .then(report => updateDroughts(report))
+        .catch(error => console.error('Error fetching droughts:', error));
+}
+
+// Function to update the drought summary and table
+function updateDroughts(report) {
+    const overall = report.overall;
+    document.getElementById('current-drought').textContent =
+        overall.globals > 0 ? formatDuration(overall.current_drought_seconds) : '-';
+    document.getElementById('longest-drought').textContent =
+        overall.longest_drought ? formatDuration(overall.longest_drought.seconds) : '-';
+    document.getElementById('median-gap').textContent =
+        overall.globals > 1 ? formatDuration(overall.median_gap_seconds) : '-';
+    document.getElementById('longest-streak').textContent =
+        overall.longest_streak ? overall.longest_streak.count : '-';
+
+    const groups = report[document.getElementById('drought-group').value] || {};
+    const names = Object.keys(groups).sort((a, b) => groups[b].globals - groups[a].globals || a.localeCompare(b));
+
+    const table = document.getElementById('drought-groups');
+    table.innerHTML = '';
+
+    if (names.length === 0) {
+        const row = table.insertRow();
+        const cell = row.insertCell(0);
+        cell.colSpan = 8;
+        cell.textContent = "No drought data available";
+        cell.className = "no-data";
+        return;
+    }
+
+    for (const name of names) {
+        const group = groups[name];
+        const row = table.insertRow();
+        row.insertCell().textContent = name;
+        row.insertCell().textContent = group.globals;
+        row.insertCell().textContent = formatDuration(group.current_drought_seconds);
+        row.insertCell().textContent = group.longest_drought ? formatDuration(group.longest_drought.seconds) : '-';
+        row.insertCell().textContent = group.globals > 1 ? formatDuration(group.average_gap_seconds) : '-';
+        row.insertCell().textContent = group.globals > 1 ? formatDuration(group.median_gap_seconds) : '-';
+        row.insertCell().textContent = group.globals > 1 ? formatDuration(group.p90_gap_seconds) : '-';
+        row.insertCell().textContent = group.streaks;
+    }
+}
+
+// Function to fetch the time series for the selected interval and redraw the chart
+function refreshTimeSeries() {
+    const interval = document.getElementById('timeseries-interval').value;
+    const metric = document.getElementById('timeseries-metric').value;
+
+    fetch(`/api/stats/timeseries?interval=${interval}`)
+        .then(response => response.json())
+        .then(series => {
+            drawBarChart(document.getElementById('timeseries-chart'),
+                series.buckets.map(b => b.period),
+                series.buckets.map(b => b[metric]));
+        })
+        .catch(error => console.error('Error fetching time series:', error));
+}
+
+// Function to draw a simple bar chart on a canvas
+function drawBarChart(canvas, labels, values) {
+    const ctx = canvas.getContext('2d');
+    const width = canvas.width;
+    const height = canvas.height;
+    const padding = { top: 20, right: 10, bottom: 50, left: 60 };
+    const textColor = document.body.classList.contains('dark-mode') ? '#eee' : '#333';
+
+    ctx.clearRect(0, 0, width, height);
+    ctx.font = '12px sans-serif';
+    ctx.fillStyle = textColor;
+
+    if (values.length === 0) {
+        ctx.textAlign = 'center';
+        ctx.fillText('No data available', width / 2, height / 2);
+        return;
+    }
+
+    const max = Math.max(...values, 1);
+    const plotWidth = width - padding.left - padding.right;
+    const plotHeight = height - padding.top - padding.bottom;
+    const slot = plotWidth / values.length;
+    const barWidth = Math.max(1, slot * 0.8);
+
+    // Y axis labels
+    ctx.textAlign = 'right';
+    for (let i = 0; i <= 4; i++) {
+        const value = max * i / 4;
+        const y = padding.top + plotHeight - plotHeight * i / 4;
+        ctx.fillText(Number.isInteger(value) ? value : value.toFixed(1), padding.left - 8, y + 4);
+    }
+
+    // Bars, with labels thinned out so they don't overlap
+    const labelEvery = Math.ceil(values.length / Math.max(1, Math.floor(plotWidth / 70)));
+    values.forEach((value, i) => {
+        const barHeight = plotHeight * value / max;
+        const x = padding.left + i * slot + (slot - barWidth) / 2;
+        ctx.fillStyle = '#2980b9';
+        ctx.fillRect(x, padding.top + plotHeight - barHeight, barWidth, barHeight);
+
+        if (i % labelEvery === 0) {
+            ctx.fillStyle = textColor;
+            ctx.textAlign = 'center';
+            ctx.fillText(labels[i], x + barWidth / 2, height - padding.bottom + 16);
+        }
+    });
+}
+
 // Function to update stats display
 function updateStats(stats) {
     document.getElementById('total-globals').textContent = stats.TotalGlobals;
     document.getElementById('total-hofs').textContent = stats.TotalHofs;
     document.getElementById('total-value').textContent = stats.TotalValue.toFixed(2);
+    document.getElementById('total-market-value').textContent = stats.TotalMarketValue.toFixed(2);
     document.getElementById('highest-value').textContent = stats.HighestValue.toFixed(2);
     
     // Update globals by type
@@ -81,14 +677,23 @@ function updateStats(stats) {
     }
     
     // Update globals by location
+    updateLocations(stats.Locations || []);
+}
+
+// Function to update the location table
+function updateLocations(locations) {
     const locationTable = document.getElementById('globals-by-location');
     locationTable.innerHTML = '';
-    for (const [location, count] of Object.entries(stats.ByLocation)) {
+    for (const location of locations) {
         const row = locationTable.insertRow();
-        const locationCell = row.insertCell(0);
-        const countCell = row.insertCell(1);
-        locationCell.textContent = location;
-        countCell.textContent = count;
+        row.insertCell().textContent = location.location;
+        row.insertCell().textContent = location.count;
+        row.insertCell().textContent = location.total_value.toFixed(2);
+        row.insertCell().textContent = location.average_value.toFixed(2);
+        row.insertCell().textContent = location.hofs;
+        row.insertCell().textContent = location.top_targets.map(t => t.target).join(', ');
+        row.insertCell().textContent = location.first_seen;
+        row.insertCell().textContent = location.last_seen;
     }
 }
 
@@ -123,6 +728,10 @@ function updateGlobals(globals) {
         typeCell.textContent = global.type;
         targetCell.textContent = global.target;
         valueCell.textContent = global.value;
+        if (global.records) {
+            row.className = 'record';
+            row.title = 'Personal record';
+        }
     }
 }
 